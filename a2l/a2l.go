@@ -0,0 +1,376 @@
+// Package a2l parses ASAM MCD-2 MC (ASAP2) description files of ECUs and
+// checks MF4 channels recorded with XCP or CCP against them: measurements,
+// conversion methods, conversion tables, record layouts and axis points.
+// Characteristics, functions, groups, IF_DATA and A2ML sections are skipped,
+// and /include directives are not followed.
+package a2l
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+	"github.com/soniah/evaler"
+)
+
+// File is an A2L file
+type File struct {
+	Project string
+
+	//name of the first module of the project
+	Module string
+
+	//measurements and axis points, in the order of the file
+	Measurements []*Measurement
+	AxisPts      []*AxisPts
+
+	//conversion methods, conversion tables and record layouts, by name
+	CompuMethods  map[string]*CompuMethod
+	CompuTabs     map[string]*CompuTab
+	RecordLayouts map[string]*RecordLayout
+}
+
+// ConversionType is the type of a conversion method or table
+type ConversionType string
+
+const (
+	Identical ConversionType = "IDENTICAL"
+	Linear    ConversionType = "LINEAR"
+	RatFunc   ConversionType = "RAT_FUNC"
+	TabIntp   ConversionType = "TAB_INTP"
+	TabNoIntp ConversionType = "TAB_NOINTP"
+	TabVerb   ConversionType = "TAB_VERB"
+	Form      ConversionType = "FORM"
+)
+
+// Measurement is a quantity measured in the ECU
+type Measurement struct {
+	Name           string
+	LongIdentifier string
+
+	//data type, for instance UBYTE, SLONG or FLOAT32_IEEE
+	DataType string
+
+	//name of the conversion method, or NO_COMPU_METHOD
+	Conversion  string
+	CompuMethod *CompuMethod
+
+	Resolution int
+	Accuracy   float64
+	LowerLimit float64
+	UpperLimit float64
+
+	//optional parameters, zero when missing. MatrixDim holds the
+	//dimensions of MATRIX_DIM, or the ARRAY_SIZE.
+	PhysUnit          string
+	DisplayIdentifier string
+	Address           uint32
+	BitMask           uint64
+	ByteOrder         string
+	MatrixDim         []int
+}
+
+// CompuMethod is a conversion method from the raw values of the ECU to
+// physical values
+type CompuMethod struct {
+	Name           string
+	LongIdentifier string
+	Type           ConversionType
+	Format         string
+	Unit           string
+
+	//coefficients a to f of RAT_FUNC, or a and b of LINEAR
+	Coeffs []float64
+
+	//table of TAB_INTP, TAB_NOINTP and TAB_VERB
+	Table *CompuTab
+
+	//formula of FORM, with X1 or X as raw value
+	Formula string
+
+	//texts of values out of the range of the conversion, or 'nil'
+	StatusStrings *CompuTab
+}
+
+// CompuTab is a conversion table: COMPU_TAB with numeric values,
+// COMPU_VTAB with texts or COMPU_VTAB_RANGE with texts of value ranges
+type CompuTab struct {
+	Name           string
+	LongIdentifier string
+	Type           ConversionType
+
+	//raw values, and the upper limits of the ranges of COMPU_VTAB_RANGE
+	Keys    []float64
+	KeysMax []float64
+
+	//physical values of COMPU_TAB, or texts of COMPU_VTAB and
+	//COMPU_VTAB_RANGE
+	Values []float64
+	Texts  []string
+
+	//value of raw values out of the table. Default is the text of
+	//DEFAULT_VALUE, DefaultNumeric is set by DEFAULT_VALUE_NUMERIC.
+	Default        string
+	DefaultNumeric *float64
+}
+
+// RecordLayout describes how calibration objects are stored in memory
+type RecordLayout struct {
+	Name string
+
+	//elements of the layout, in the order of the file
+	Entries []LayoutEntry
+}
+
+// LayoutEntry is an element of a record layout, for instance FNC_VALUES,
+// AXIS_PTS_X or NO_AXIS_PTS_X
+type LayoutEntry struct {
+	Keyword string
+
+	//position in the record and data type, zero for entries without them
+	//like ALIGNMENT_BYTE or FIX_NO_AXIS_PTS_X
+	Position int
+	DataType string
+
+	//other parameters, for instance the index mode and address type of
+	//FNC_VALUES
+	Params []string
+}
+
+// AxisPts is an axis shared by characteristics
+type AxisPts struct {
+	Name           string
+	LongIdentifier string
+	Address        uint32
+
+	//measurement used as input of the axis, or NO_INPUT_QUANTITY
+	InputQuantity string
+
+	//record layout of the axis points
+	Deposit string
+	Layout  *RecordLayout
+
+	MaxDiff       float64
+	Conversion    string
+	CompuMethod   *CompuMethod
+	MaxAxisPoints int
+	LowerLimit    float64
+	UpperLimit    float64
+
+	PhysUnit          string
+	DisplayIdentifier string
+	ByteOrder         string
+}
+
+// ParseFile parses the A2L file at `path`
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// ParseAttachment parses an A2L embedded in, or referenced by, an attachment
+// of an MF4 file
+func ParseAttachment(at AT.AttFile) (*File, error) {
+	r, err := at.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	f, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("attachment %s: %w", at.Name, err)
+	}
+	return f, nil
+}
+
+// Measurement returns the measurement `name`, or 'nil'
+func (f *File) Measurement(name string) *Measurement {
+	for _, m := range f.Measurements {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// AxisPtsByName returns the axis points `name`, or 'nil'
+func (f *File) AxisPtsByName(name string) *AxisPts {
+	for _, a := range f.AxisPts {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Unit returns the physical unit of the measurement, from PHYS_UNIT or from
+// its conversion method
+func (m *Measurement) Unit() string {
+	return unit(m.PhysUnit, m.CompuMethod)
+}
+
+// Unit returns the physical unit of the axis points, from PHYS_UNIT or from
+// their conversion method
+func (a *AxisPts) Unit() string {
+	return unit(a.PhysUnit, a.CompuMethod)
+}
+
+func unit(physUnit string, cm *CompuMethod) string {
+	if physUnit != "" || cm == nil {
+		return physUnit
+	}
+	return cm.Unit
+}
+
+// Entry returns the entry `keyword` of the record layout
+func (r *RecordLayout) Entry(keyword string) (LayoutEntry, bool) {
+	for _, e := range r.Entries {
+		if e.Keyword == keyword {
+			return e, true
+		}
+	}
+	return LayoutEntry{}, false
+}
+
+// Physical returns the physical value of the raw value `raw`, and `false` for
+// verbal conversions, formulas that can't be evaluated and rational functions
+// that can't be inverted. RAT_FUNC coefficients convert physical values to
+// raw values, so only the rational functions with a = d = 0 are inverted.
+func (cm *CompuMethod) Physical(raw float64) (float64, bool) {
+	if cm == nil {
+		return raw, true
+	}
+
+	switch cm.Type {
+	case Identical:
+		return raw, true
+	case Linear:
+		if len(cm.Coeffs) < 2 {
+			return 0, false
+		}
+		return cm.Coeffs[0]*raw + cm.Coeffs[1], true
+	case RatFunc:
+		if len(cm.Coeffs) < 6 {
+			return 0, false
+		}
+		a, b, c, d, e, f := cm.Coeffs[0], cm.Coeffs[1], cm.Coeffs[2], cm.Coeffs[3], cm.Coeffs[4], cm.Coeffs[5]
+		// raw = (b*phys + c) / (e*phys + f)
+		if a != 0 || d != 0 || e*raw-b == 0 {
+			return 0, false
+		}
+		return (c - f*raw) / (e*raw - b), true
+	case TabIntp, TabNoIntp:
+		if cm.Table == nil {
+			return 0, false
+		}
+		return cm.Table.value(raw, cm.Type == TabIntp)
+	case Form:
+		return evaluate(cm.Formula, raw)
+	default:
+		return 0, false
+	}
+}
+
+// Text returns the text of the raw value `raw` for verbal conversions, the
+// default text for values out of the table, and `false` without one
+func (cm *CompuMethod) Text(raw float64) (string, bool) {
+	if cm == nil || cm.Type != TabVerb || cm.Table == nil {
+		return "", false
+	}
+	return cm.Table.text(raw)
+}
+
+// value returns the physical value of a COMPU_TAB, interpolated between the
+// keys or taken from the nearest key. Raw values out of the table take the
+// numeric default value, or the value of the nearest key.
+func (t *CompuTab) value(raw float64, interpolate bool) (float64, bool) {
+	n := len(t.Keys)
+	if n == 0 || len(t.Values) != n {
+		return 0, false
+	}
+	if raw < t.Keys[0] || raw > t.Keys[n-1] {
+		if t.DefaultNumeric != nil {
+			return *t.DefaultNumeric, true
+		}
+	}
+
+	i := sort.SearchFloat64s(t.Keys, raw)
+	switch {
+	case i == 0:
+		return t.Values[0], true
+	case i == n:
+		return t.Values[n-1], true
+	case t.Keys[i] == raw:
+		return t.Values[i], true
+	}
+
+	x0, x1, y0, y1 := t.Keys[i-1], t.Keys[i], t.Values[i-1], t.Values[i]
+	if interpolate {
+		return y0 + (raw-x0)*(y1-y0)/(x1-x0), true
+	}
+	if raw-x0 > x1-raw {
+		return y1, true
+	}
+	return y0, true
+}
+
+// text returns the text of a COMPU_VTAB or COMPU_VTAB_RANGE
+func (t *CompuTab) text(raw float64) (string, bool) {
+	for i, key := range t.Keys {
+		if i >= len(t.Texts) {
+			break
+		}
+		if t.KeysMax == nil && raw == key {
+			return t.Texts[i], true
+		}
+		if t.KeysMax != nil && raw >= key && raw <= t.KeysMax[i] {
+			return t.Texts[i], true
+		}
+	}
+	return t.Default, t.Default != ""
+}
+
+// evaluate returns the value of a FORM formula for the raw value `x`
+func evaluate(formula string, x float64) (float64, bool) {
+	// the raw value is X1, or X in older files
+	value := "(" + strconv.FormatFloat(x, 'f', -1, 64) + ")"
+	var expr strings.Builder
+	for i := 0; i < len(formula); {
+		if formula[i] != 'X' && formula[i] != 'x' || i > 0 && isIdentChar(formula[i-1]) {
+			expr.WriteByte(formula[i])
+			i++
+			continue
+		}
+		j := i + 1
+		if j < len(formula) && formula[j] == '1' {
+			j++
+		}
+		if j < len(formula) && isIdentChar(formula[j]) {
+			expr.WriteByte(formula[i])
+			i++
+			continue
+		}
+		expr.WriteString(value)
+		i = j
+	}
+
+	r, err := evaler.Eval(expr.String())
+	if err != nil {
+		return 0, false
+	}
+	v := evaler.BigratToFloat(r)
+	return v, !math.IsInf(v, 0) && !math.IsNaN(v)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
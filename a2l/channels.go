@@ -0,0 +1,243 @@
+package a2l
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+	"github.com/LincolnG4/GoMDF/blocks/CC"
+)
+
+// Find returns the A2L attached to a channel of the file, or else to the
+// file
+func Find(m *mf4.MF4) (*File, error) {
+	for i := range m.ChannelGroup {
+		cg := &m.ChannelGroup[i]
+		for _, name := range channelNames(cg) {
+			attachments, err := cg.Channels[name].Attachments()
+			if err != nil {
+				return nil, err
+			}
+			if at, ok := a2lAttachment(attachments); ok {
+				return ParseAttachment(at)
+			}
+		}
+	}
+
+	attachments, err := m.GetAttachments()
+	if err != nil {
+		return nil, err
+	}
+	if at, ok := a2lAttachment(attachments); ok {
+		return ParseAttachment(at)
+	}
+	return nil, fmt.Errorf("no A2L attachment")
+}
+
+func a2lAttachment(attachments []AT.AttFile) (AT.AttFile, bool) {
+	for _, at := range attachments {
+		if strings.EqualFold(filepath.Ext(at.Name), ".a2l") {
+			return at, true
+		}
+	}
+	return AT.AttFile{}, false
+}
+
+// channelNames returns the names of the channels of the group, sorted
+func channelNames(cg *mf4.ChannelGroup) []string {
+	names := make([]string, 0, len(cg.Channels))
+	for name := range cg.Channels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// quantity is a measurement or axis points described by the A2L
+type quantity struct {
+	description string
+	unit        string
+	method      *CompuMethod
+}
+
+// arrayIndex matches the indices of array elements in channel names, for
+// instance "_[1][0]" in "ASAM_[1][0].M.MATRIX_DIM_8_2.UBYTE.IDENTICAL"
+var arrayIndex = regexp.MustCompile(`_?(\[\d+\])+`)
+
+// Lookup returns the measurement or the axis points recorded in the channel
+// `name`. The name of the measurement is the channel name without the
+// source path after a backslash and without array indices.
+func (f *File) Lookup(name string) (*Measurement, *AxisPts) {
+	name, _, _ = strings.Cut(name, "\\")
+	for _, n := range []string{name, arrayIndex.ReplaceAllString(name, "")} {
+		if m := f.Measurement(n); m != nil {
+			return m, nil
+		}
+		if a := f.AxisPtsByName(n); a != nil {
+			return nil, a
+		}
+	}
+	return nil, nil
+}
+
+func (f *File) quantity(name string) (quantity, bool) {
+	m, a := f.Lookup(name)
+	switch {
+	case m != nil:
+		return quantity{m.LongIdentifier, m.Unit(), m.CompuMethod}, true
+	case a != nil:
+		return quantity{a.LongIdentifier, a.Unit(), a.CompuMethod}, true
+	default:
+		return quantity{}, false
+	}
+}
+
+// Mismatch is a difference between a channel and the A2L
+type Mismatch struct {
+	Channel string
+
+	//"unit" or "conversion"
+	Field string
+
+	//values of the A2L and of the channel. For conversions, the physical
+	//value of the first raw value converted differently.
+	Expected string
+	Actual   string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s is %s, expected %s", m.Channel, m.Field, m.Actual, m.Expected)
+}
+
+// Validate returns the units and conversions of the channels that don't
+// match the A2L. Conversions are compared on the keys of conversion tables,
+// or on a few raw values, skipping the values the A2L can't convert. Channels
+// missing from the A2L and master channels are skipped.
+func Validate(m *mf4.MF4, f *File) ([]Mismatch, error) {
+	mismatches := make([]Mismatch, 0)
+	for i := range m.ChannelGroup {
+		cg := &m.ChannelGroup[i]
+		for _, name := range channelNames(cg) {
+			c := cg.Channels[name]
+			q, ok := f.quantity(name)
+			if !ok || c.IsMaster() {
+				continue
+			}
+
+			unit := strings.TrimSpace(c.GetUnit())
+			if expected := strings.TrimSpace(q.unit); unit != "" && expected != "" && unit != expected {
+				mismatches = append(mismatches, Mismatch{Channel: name, Field: "unit", Expected: expected, Actual: unit})
+			}
+
+			conversion, err := c.GetConversion()
+			if err != nil {
+				return nil, fmt.Errorf("channel %s: %w", name, err)
+			}
+			if mismatch, ok := compare(q.method, conversion); !ok {
+				mismatch.Channel = name
+				mismatches = append(mismatches, mismatch)
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+// testValues are the raw values conversions without table are compared on
+var testValues = []float64{0, 1, 2, 5, 10, 100, 255}
+
+// compare returns `false` and the first raw value converted differently by
+// the conversion method and by the conversion of the channel
+func compare(cm *CompuMethod, conversion CC.Conversion) (Mismatch, bool) {
+	raws := testValues
+	if cm != nil && cm.Table != nil {
+		raws = slices.Concat(cm.Table.Keys, cm.Table.KeysMax)
+		if cm.Type == TabIntp {
+			for i := 1; i < len(cm.Table.Keys); i++ {
+				raws = append(raws, (cm.Table.Keys[i-1]+cm.Table.Keys[i])/2)
+			}
+		}
+	}
+
+	for _, raw := range raws {
+		var expected interface{}
+		if text, ok := cm.Text(raw); ok {
+			expected = text
+		} else if v, ok := cm.Physical(raw); ok {
+			expected = v
+		} else {
+			continue
+		}
+
+		actual := []interface{}{raw}
+		if conversion != nil {
+			conversion.Apply(&actual)
+		}
+		if !sameValue(expected, actual[0]) {
+			return Mismatch{
+				Field:    "conversion",
+				Expected: fmt.Sprintf("%g -> %v", raw, expected),
+				Actual:   fmt.Sprintf("%g -> %v", raw, actual[0]),
+			}, false
+		}
+	}
+	return Mismatch{}, true
+}
+
+// sameValue compares a physical value of the A2L to one of a channel
+func sameValue(expected, actual interface{}) bool {
+	switch e := expected.(type) {
+	case string:
+		a, ok := actual.(string)
+		return ok && a == e
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		return math.Abs(a-e) <= 1e-9*math.Max(1, math.Abs(e))
+	}
+	return false
+}
+
+// Enrich sets the units and descriptions missing from the channels of the
+// edited file from the A2L: the unit of the measurement or of its conversion
+// method, and its long identifier as comment. It returns the number of
+// channels changed.
+func Enrich(e *mf4.Editor, m *mf4.MF4, f *File) (int, error) {
+	changed := 0
+	for i := range m.ChannelGroup {
+		cg := &m.ChannelGroup[i]
+		for _, name := range channelNames(cg) {
+			c := cg.Channels[name]
+			q, ok := f.quantity(name)
+			if !ok || c.IsMaster() {
+				continue
+			}
+
+			updated := false
+			if strings.TrimSpace(c.GetUnit()) == "" && q.unit != "" {
+				if err := e.SetChannelUnit(c, q.unit); err != nil {
+					return changed, err
+				}
+				updated = true
+			}
+			if c.Meta().TX == "" && q.description != "" {
+				comment, err := e.Channel(c)
+				if err != nil {
+					return changed, err
+				}
+				comment.TX = q.description
+				updated = true
+			}
+			if updated {
+				changed++
+			}
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,663 @@
+package a2l
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// token is a word, number or string of an A2L file
+type token struct {
+	text string
+
+	//the token is a quoted string, without the quotes
+	quoted bool
+
+	line int
+}
+
+// lex splits an A2L file in tokens, without comments
+func lex(src string) ([]token, error) {
+	tokens := make([]token, 0, len(src)/6)
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			// quotes are escaped as \" or ""
+			var s strings.Builder
+			start := line
+			j := i + 1
+			for ; j < len(src); j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+					s.WriteByte(src[j])
+					continue
+				}
+				if src[j] == '"' {
+					if j+1 < len(src) && src[j+1] == '"' {
+						s.WriteByte('"')
+						j++
+						continue
+					}
+					break
+				}
+				if src[j] == '\n' {
+					line++
+				}
+				s.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", start)
+			}
+			tokens = append(tokens, token{text: s.String(), quoted: true, line: start})
+			i = j + 1
+		default:
+			j := i
+			for j < len(src) && strings.IndexByte(" \t\r\n\"", src[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, token{text: src[i:j], line: line})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// element is a /begin <keyword> ... /end <keyword> section, with its
+// parameters and nested sections
+type element struct {
+	keyword  string
+	line     int
+	args     []token
+	children []*element
+}
+
+// tree returns the sections of the file as children of a root element
+func tree(tokens []token) (*element, error) {
+	root := &element{}
+	stack := []*element{root}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		top := stack[len(stack)-1]
+		switch {
+		case t.quoted:
+			top.args = append(top.args, t)
+		case t.text == "/begin":
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("line %d: /begin without keyword", t.line)
+			}
+			i++
+			e := &element{keyword: tokens[i].text, line: t.line}
+			top.children = append(top.children, e)
+			stack = append(stack, e)
+		case t.text == "/end":
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("line %d: /end without keyword", t.line)
+			}
+			i++
+			if len(stack) == 1 || tokens[i].text != top.keyword {
+				return nil, fmt.Errorf("line %d: unexpected /end %s", t.line, tokens[i].text)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			top.args = append(top.args, t)
+		}
+	}
+	if len(stack) > 1 {
+		top := stack[len(stack)-1]
+		return nil, fmt.Errorf("line %d: %s without /end", top.line, top.keyword)
+	}
+	return root, nil
+}
+
+// parser reads the sections of an A2L file
+type parser struct {
+	f *File
+
+	//tables referenced by the conversion methods, resolved once the whole
+	//file is read
+	tableRefs []tableRef
+}
+
+// tableRef is a COMPU_TAB_REF and STATUS_STRING_REF of a conversion method
+type tableRef struct {
+	method *CompuMethod
+	table  string
+	status string
+	line   int
+}
+
+// Parse parses an A2L file
+func Parse(r io.Reader) (*File, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := lex(string(src))
+	if err != nil {
+		return nil, err
+	}
+	root, err := tree(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		f: &File{
+			Measurements:  make([]*Measurement, 0),
+			AxisPts:       make([]*AxisPts, 0),
+			CompuMethods:  make(map[string]*CompuMethod),
+			CompuTabs:     make(map[string]*CompuTab),
+			RecordLayouts: make(map[string]*RecordLayout),
+		},
+	}
+	if err := p.read(root); err != nil {
+		return nil, err
+	}
+	if err := p.resolve(); err != nil {
+		return nil, err
+	}
+	return p.f, nil
+}
+
+// read reads the sections nested in `e`
+func (p *parser) read(e *element) error {
+	for _, c := range e.children {
+		a := &args{e: c}
+		var err error
+		switch c.keyword {
+		case "PROJECT":
+			p.f.Project, err = a.word()
+		case "MODULE":
+			if p.f.Module == "" {
+				p.f.Module, err = a.word()
+			}
+		case "MEASUREMENT":
+			err = p.measurement(a)
+		case "COMPU_METHOD":
+			err = p.compuMethod(a)
+		case "COMPU_TAB", "COMPU_VTAB", "COMPU_VTAB_RANGE":
+			err = p.compuTab(a)
+		case "RECORD_LAYOUT":
+			err = p.recordLayout(a)
+		case "AXIS_PTS":
+			err = p.axisPts(a)
+		case "A2ML", "IF_DATA":
+			// tool and interface specific
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.read(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve links the measurements, axis points and conversion methods to the
+// conversion methods, tables and record layouts they reference
+func (p *parser) resolve() error {
+	f := p.f
+	for _, ref := range p.tableRefs {
+		cm := ref.method
+		if ref.table != "" {
+			if cm.Table = f.CompuTabs[ref.table]; cm.Table == nil {
+				return fmt.Errorf("line %d: COMPU_METHOD %s: unknown conversion table %s", ref.line, cm.Name, ref.table)
+			}
+		}
+		if ref.status != "" {
+			if cm.StatusStrings = f.CompuTabs[ref.status]; cm.StatusStrings == nil {
+				return fmt.Errorf("line %d: COMPU_METHOD %s: unknown conversion table %s", ref.line, cm.Name, ref.status)
+			}
+		}
+	}
+
+	method := func(kind, name, conversion string) (*CompuMethod, error) {
+		if conversion == "NO_COMPU_METHOD" {
+			return nil, nil
+		}
+		cm, ok := f.CompuMethods[conversion]
+		if !ok {
+			return nil, fmt.Errorf("%s %s: unknown COMPU_METHOD %s", kind, name, conversion)
+		}
+		return cm, nil
+	}
+
+	var err error
+	for _, m := range f.Measurements {
+		if m.CompuMethod, err = method("MEASUREMENT", m.Name, m.Conversion); err != nil {
+			return err
+		}
+	}
+	for _, a := range f.AxisPts {
+		if a.CompuMethod, err = method("AXIS_PTS", a.Name, a.Conversion); err != nil {
+			return err
+		}
+		if a.Layout = f.RecordLayouts[a.Deposit]; a.Layout == nil {
+			return fmt.Errorf("AXIS_PTS %s: unknown RECORD_LAYOUT %s", a.Name, a.Deposit)
+		}
+	}
+	return nil
+}
+
+// args reads the parameters of a section
+type args struct {
+	e   *element
+	pos int
+}
+
+func (a *args) errorf(format string, v ...interface{}) error {
+	line := a.e.line
+	if a.pos > 0 && a.pos <= len(a.e.args) {
+		line = a.e.args[a.pos-1].line
+	}
+	return fmt.Errorf("line %d: %s: %s", line, a.e.keyword, fmt.Sprintf(format, v...))
+}
+
+func (a *args) next() (token, error) {
+	if a.pos >= len(a.e.args) {
+		return token{}, a.errorf("missing parameter")
+	}
+	a.pos++
+	return a.e.args[a.pos-1], nil
+}
+
+// word reads an identifier or a string
+func (a *args) word() (string, error) {
+	t, err := a.next()
+	return t.text, err
+}
+
+func (a *args) float() (float64, error) {
+	t, err := a.next()
+	if err != nil {
+		return 0, err
+	}
+	v, ok := number(t)
+	if !ok {
+		return 0, a.errorf("expected a number, got %q", t.text)
+	}
+	return v, nil
+}
+
+// int reads a decimal or hexadecimal integer
+func (a *args) int() (int64, error) {
+	t, err := a.next()
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(t.text, 0, 64)
+	if err != nil || t.quoted {
+		return 0, a.errorf("expected an integer, got %q", t.text)
+	}
+	return v, nil
+}
+
+// number parses a decimal, hexadecimal or floating point number
+func number(t token) (float64, bool) {
+	if t.quoted {
+		return 0, false
+	}
+	if v, err := strconv.ParseFloat(t.text, 64); err == nil {
+		return v, true
+	}
+	if v, err := strconv.ParseInt(t.text, 0, 64); err == nil {
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// options returns the values following each of the `keywords` in the
+// remaining parameters, up to the next keyword
+func (a *args) options(keywords ...string) map[string][]token {
+	r := make(map[string][]token)
+	current := ""
+	for _, t := range a.e.args[a.pos:] {
+		if !t.quoted && slices.Contains(keywords, t.text) {
+			current = t.text
+			r[current] = make([]token, 0, 1)
+			continue
+		}
+		if current != "" {
+			r[current] = append(r[current], t)
+		}
+	}
+	return r
+}
+
+// first returns the first value of an option, or ""
+func first(values []token) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0].text
+}
+
+// integer returns the first value of an option as an unsigned integer
+func integer(values []token) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+	v, _ := strconv.ParseUint(values[0].text, 0, 64)
+	return v
+}
+
+// measurement reads <name> "<long identifier>" <data type> <conversion>
+// <resolution> <accuracy> <lower limit> <upper limit> [options]
+func (p *parser) measurement(a *args) error {
+	m := &Measurement{}
+	var err error
+	if m.Name, err = a.word(); err != nil {
+		return err
+	}
+	if m.LongIdentifier, err = a.word(); err != nil {
+		return err
+	}
+	if m.DataType, err = a.word(); err != nil {
+		return err
+	}
+	if m.Conversion, err = a.word(); err != nil {
+		return err
+	}
+	resolution, err := a.int()
+	if err != nil {
+		return err
+	}
+	m.Resolution = int(resolution)
+	if m.Accuracy, err = a.float(); err != nil {
+		return err
+	}
+	if m.LowerLimit, err = a.float(); err != nil {
+		return err
+	}
+	if m.UpperLimit, err = a.float(); err != nil {
+		return err
+	}
+
+	o := a.options("PHYS_UNIT", "DISPLAY_IDENTIFIER", "ECU_ADDRESS", "ECU_ADDRESS_EXTENSION", "BIT_MASK", "BYTE_ORDER",
+		"MATRIX_DIM", "ARRAY_SIZE", "FORMAT", "READ_WRITE", "ERROR_MASK", "LAYOUT", "ADDRESS_TYPE", "DISCRETE", "REF_MEMORY_SEGMENT")
+	m.PhysUnit = first(o["PHYS_UNIT"])
+	m.DisplayIdentifier = first(o["DISPLAY_IDENTIFIER"])
+	m.Address = uint32(integer(o["ECU_ADDRESS"]))
+	m.BitMask = integer(o["BIT_MASK"])
+	m.ByteOrder = first(o["BYTE_ORDER"])
+	for _, keyword := range []string{"ARRAY_SIZE", "MATRIX_DIM"} {
+		for _, t := range o[keyword] {
+			if v, err := strconv.Atoi(t.text); err == nil {
+				m.MatrixDim = append(m.MatrixDim, v)
+			}
+		}
+	}
+
+	p.f.Measurements = append(p.f.Measurements, m)
+	return nil
+}
+
+// compuMethod reads <name> "<long identifier>" <type> "<format>" "<unit>"
+// [COEFFS | COEFFS_LINEAR | COMPU_TAB_REF | /begin FORMULA]
+func (p *parser) compuMethod(a *args) error {
+	cm := &CompuMethod{}
+	var err error
+	if cm.Name, err = a.word(); err != nil {
+		return err
+	}
+	if cm.LongIdentifier, err = a.word(); err != nil {
+		return err
+	}
+	kind, err := a.word()
+	if err != nil {
+		return err
+	}
+	cm.Type = ConversionType(kind)
+	if cm.Format, err = a.word(); err != nil {
+		return err
+	}
+	if cm.Unit, err = a.word(); err != nil {
+		return err
+	}
+
+	o := a.options("COEFFS", "COEFFS_LINEAR", "COMPU_TAB_REF", "REF_UNIT", "STATUS_STRING_REF")
+	coeffs, n := o["COEFFS"], 6
+	if cm.Type == Linear {
+		coeffs, n = o["COEFFS_LINEAR"], 2
+	}
+	if cm.Type == RatFunc || cm.Type == Linear {
+		if len(coeffs) < n {
+			return a.errorf("%s %s has %d coefficients, expected %d", cm.Type, cm.Name, len(coeffs), n)
+		}
+		cm.Coeffs = make([]float64, n)
+		for i := range cm.Coeffs {
+			v, ok := number(coeffs[i])
+			if !ok {
+				return a.errorf("invalid coefficient %q of %s", coeffs[i].text, cm.Name)
+			}
+			cm.Coeffs[i] = v
+		}
+	}
+
+	for _, c := range a.e.children {
+		if c.keyword == "FORMULA" && len(c.args) > 0 {
+			cm.Formula = c.args[0].text
+		}
+	}
+	p.f.CompuMethods[cm.Name] = cm
+	p.tableRefs = append(p.tableRefs, tableRef{
+		method: cm,
+		table:  first(o["COMPU_TAB_REF"]),
+		status: first(o["STATUS_STRING_REF"]),
+		line:   a.e.line,
+	})
+	return nil
+}
+
+// compuTab reads the tables
+//
+//	COMPU_TAB <name> "<long identifier>" <type> <n> (<raw> <physical>)...
+//	COMPU_VTAB <name> "<long identifier>" TAB_VERB <n> (<raw> "<text>")...
+//	COMPU_VTAB_RANGE <name> "<long identifier>" <n> (<min> <max> "<text>")...
+//
+// followed by DEFAULT_VALUE "<text>" or DEFAULT_VALUE_NUMERIC <value>
+func (p *parser) compuTab(a *args) error {
+	t := &CompuTab{Type: TabVerb}
+	var err error
+	if t.Name, err = a.word(); err != nil {
+		return err
+	}
+	if t.LongIdentifier, err = a.word(); err != nil {
+		return err
+	}
+	if a.e.keyword != "COMPU_VTAB_RANGE" {
+		kind, err := a.word()
+		if err != nil {
+			return err
+		}
+		t.Type = ConversionType(kind)
+	}
+	n, err := a.int()
+	if err != nil {
+		return err
+	}
+
+	for i := int64(0); i < n; i++ {
+		key, err := a.float()
+		if err != nil {
+			return err
+		}
+		t.Keys = append(t.Keys, key)
+
+		switch a.e.keyword {
+		case "COMPU_TAB":
+			v, err := a.float()
+			if err != nil {
+				return err
+			}
+			t.Values = append(t.Values, v)
+			continue
+		case "COMPU_VTAB_RANGE":
+			max, err := a.float()
+			if err != nil {
+				return err
+			}
+			t.KeysMax = append(t.KeysMax, max)
+		}
+		text, err := a.word()
+		if err != nil {
+			return err
+		}
+		t.Texts = append(t.Texts, text)
+	}
+
+	if a.e.keyword == "COMPU_TAB" && !sort.Float64sAreSorted(t.Keys) {
+		// sort the pairs by raw value for the interpolation
+		pairs := make([][2]float64, len(t.Keys))
+		for i := range pairs {
+			pairs[i] = [2]float64{t.Keys[i], t.Values[i]}
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+		for i := range pairs {
+			t.Keys[i], t.Values[i] = pairs[i][0], pairs[i][1]
+		}
+	}
+
+	o := a.options("DEFAULT_VALUE", "DEFAULT_VALUE_NUMERIC")
+	t.Default = first(o["DEFAULT_VALUE"])
+	if values := o["DEFAULT_VALUE_NUMERIC"]; len(values) > 0 {
+		v, ok := number(values[0])
+		if !ok {
+			return a.errorf("invalid default value %q of %s", values[0].text, t.Name)
+		}
+		t.DefaultNumeric = &v
+	}
+	p.f.CompuTabs[t.Name] = t
+	return nil
+}
+
+// layoutKeywords are the entries of record layouts
+var layoutKeywords = []string{
+	"FNC_VALUES", "IDENTIFICATION", "RESERVED", "STATIC_RECORD_LAYOUT", "STATIC_ADDRESS_OFFSETS",
+	"ALIGNMENT_BYTE", "ALIGNMENT_WORD", "ALIGNMENT_LONG", "ALIGNMENT_INT64", "ALIGNMENT_FLOAT16_IEEE", "ALIGNMENT_FLOAT32_IEEE", "ALIGNMENT_FLOAT64_IEEE",
+}
+
+// axisLayoutKeywords are the entries of record layouts repeated for each
+// axis, with the suffixes _X, _Y, _Z, _4 and _5
+var axisLayoutKeywords = []string{
+	"AXIS_PTS", "AXIS_RESCALE", "DIST_OP", "FIX_NO_AXIS_PTS", "NO_AXIS_PTS", "NO_RESCALE",
+	"OFFSET", "RIP_ADDR", "SRC_ADDR", "SHIFT_OP",
+}
+
+func init() {
+	for _, keyword := range axisLayoutKeywords {
+		for _, axis := range []string{"_X", "_Y", "_Z", "_4", "_5"} {
+			layoutKeywords = append(layoutKeywords, keyword+axis)
+		}
+	}
+	layoutKeywords = append(layoutKeywords, "RIP_ADDR_W")
+}
+
+// recordLayout reads <name> followed by the entries of the layout, most of
+// them <keyword> <position> <data type> [parameters]
+func (p *parser) recordLayout(a *args) error {
+	name, err := a.word()
+	if err != nil {
+		return err
+	}
+
+	r := &RecordLayout{Name: name, Entries: make([]LayoutEntry, 0)}
+	for a.pos < len(a.e.args) {
+		t, _ := a.next()
+		if t.quoted || !slices.Contains(layoutKeywords, t.text) {
+			return a.errorf("unknown entry %q of record layout %s", t.text, name)
+		}
+		e := LayoutEntry{Keyword: t.text, Params: make([]string, 0)}
+		for a.pos < len(a.e.args) {
+			next := a.e.args[a.pos]
+			if !next.quoted && slices.Contains(layoutKeywords, next.text) {
+				break
+			}
+			e.Params = append(e.Params, next.text)
+			a.pos++
+		}
+		// position and data type
+		if len(e.Params) >= 2 {
+			if pos, err := strconv.Atoi(e.Params[0]); err == nil && e.Keyword != "RESERVED" {
+				e.Position, e.DataType, e.Params = pos, e.Params[1], e.Params[2:]
+			}
+		}
+		r.Entries = append(r.Entries, e)
+	}
+	p.f.RecordLayouts[name] = r
+	return nil
+}
+
+// axisPts reads <name> "<long identifier>" <address> <input quantity>
+// <deposit> <max diff> <conversion> <max axis points> <lower limit> <upper
+// limit> [options]
+func (p *parser) axisPts(a *args) error {
+	ap := &AxisPts{}
+	var err error
+	if ap.Name, err = a.word(); err != nil {
+		return err
+	}
+	if ap.LongIdentifier, err = a.word(); err != nil {
+		return err
+	}
+	address, err := a.int()
+	if err != nil {
+		return err
+	}
+	ap.Address = uint32(address)
+	if ap.InputQuantity, err = a.word(); err != nil {
+		return err
+	}
+	if ap.Deposit, err = a.word(); err != nil {
+		return err
+	}
+	if ap.MaxDiff, err = a.float(); err != nil {
+		return err
+	}
+	if ap.Conversion, err = a.word(); err != nil {
+		return err
+	}
+	points, err := a.int()
+	if err != nil {
+		return err
+	}
+	ap.MaxAxisPoints = int(points)
+	if ap.LowerLimit, err = a.float(); err != nil {
+		return err
+	}
+	if ap.UpperLimit, err = a.float(); err != nil {
+		return err
+	}
+
+	o := a.options("PHYS_UNIT", "DISPLAY_IDENTIFIER", "BYTE_ORDER", "DEPOSIT", "FORMAT", "READ_ONLY", "GUARD_RAILS",
+		"ECU_ADDRESS_EXTENSION", "EXTENDED_LIMITS", "MONOTONY", "STEP_SIZE", "REF_MEMORY_SEGMENT", "CALIBRATION_ACCESS")
+	ap.PhysUnit = first(o["PHYS_UNIT"])
+	ap.DisplayIdentifier = first(o["DISPLAY_IDENTIFIER"])
+	ap.ByteOrder = first(o["BYTE_ORDER"])
+
+	p.f.AxisPts = append(p.f.AxisPts, ap)
+	return nil
+}
@@ -0,0 +1,323 @@
+package mf4_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/a2l"
+)
+
+// demoA2L describes measurements of ASAP2_Demo_V171.mf4. The unit of
+// ASAM.M.SCALAR.UBYTE.IDENTICAL and the conversion of
+// ASAM.M.SCALAR.UWORD.IDENTICAL don't match the file.
+const demoA2L = `ASAP2_VERSION 1 71
+/* ECU of the ASAM demo recording */
+/begin PROJECT ASAP2_Example "demo project"
+  /begin HEADER "ASAP2 example file"
+    VERSION "V1.7.1"
+  /end HEADER
+  /begin MODULE Example "demo ""ECU"""
+    /begin A2ML
+      struct Protocol_Layer { uint; uint; };
+      block "IF_DATA" taggedunion if_data { "XCP" struct Protocol_Layer; };
+    /end A2ML
+    /begin MOD_PAR ""
+      NO_OF_INTERFACES 1
+    /end MOD_PAR
+
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.RAT_FUNC.DIV_10 "Scalar measurement divided by 10"
+      UBYTE CM.RAT_FUNC.DIV_10 0 0 0 25.5
+      ECU_ADDRESS 0x13A00
+      /begin IF_DATA XCP
+        /begin DAQ_EVENT FIXED_EVENT_LIST EVENT 2 /end DAQ_EVENT
+      /end IF_DATA
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.FORM_X_PLUS_4 "Scalar measurement plus 4"
+      UBYTE CM.FORM.X_PLUS_4 0 0 4 259
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.SBYTE.LINEAR_MUL_2 "Scalar measurement"
+      SBYTE CM.LINEAR.MUL_2 0 0 -256 254
+      PHYS_UNIT "m/s"
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.VIRTUAL.SCALAR.SWORD.PHYSICAL "Virtual measurement"
+      SWORD CM.LINEAR.IDENT 0 0 -65536 65534
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.HYPERBOLIC "Scalar measurement"
+      UBYTE CM.RAT_FUNC.HYPERBOLIC 0 0 0 1
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.TAB_INTP_DEFAULT_VALUE "Table with interpolation"
+      UBYTE CM.TAB_INTP.DEFAULT_VALUE 0 0 98 111
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.TAB_NOINTP_DEFAULT_VALUE "Table without interpolation"
+      UBYTE CM.TAB_NOINTP.DEFAULT_VALUE 0 0 98 111
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.TAB_VERB_DEFAULT_VALUE "Verbal table"
+      UBYTE CM.TAB_VERB.DEFAULT_VALUE 0 0 0 255
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.VTAB_RANGE_DEFAULT_VALUE "Verbal range table"
+      UBYTE CM.VTAB_RANGE.DEFAULT_VALUE 0 0 0 255
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.MATRIX_DIM_16.UBYTE.IDENTICAL "Array measurement"
+      UBYTE CM.IDENTICAL 0 0 0 255
+      MATRIX_DIM 16
+      BYTE_ORDER MSB_LAST
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UBYTE.IDENTICAL "Scalar measurement"
+      UBYTE NO_COMPU_METHOD 0 0 0 255
+      PHYS_UNIT "min"
+    /end MEASUREMENT
+    /begin MEASUREMENT ASAM.M.SCALAR.UWORD.IDENTICAL "Scalar measurement"
+      UWORD CM.LINEAR.MUL_3 0 0 0 196605
+      BIT_MASK 0xFFFF
+    /end MEASUREMENT
+
+    /begin COMPU_METHOD CM.IDENTICAL "identical" IDENTICAL "%3.0" "hours" /end COMPU_METHOD
+    /begin COMPU_METHOD CM.RAT_FUNC.DIV_10 "impl = phys * 10"
+      RAT_FUNC "%4.1" "km/h"
+      COEFFS 0 10 0 0 0 1
+    /end COMPU_METHOD
+    /begin COMPU_METHOD CM.RAT_FUNC.HYPERBOLIC "impl = 1 / phys"
+      RAT_FUNC "%4.1" "km/h"
+      COEFFS 0 0 1 0 1 0
+    /end COMPU_METHOD
+    /begin COMPU_METHOD CM.FORM.X_PLUS_4 "phys = impl + 4"
+      FORM "%6.1" "rpm"
+      /begin FORMULA "X1+4" /end FORMULA
+    /end COMPU_METHOD
+    /begin COMPU_METHOD CM.LINEAR.MUL_2 "phys = impl * 2" LINEAR "%3.1" "m/s" COEFFS_LINEAR 2 0 /end COMPU_METHOD
+    /begin COMPU_METHOD CM.LINEAR.IDENT "phys = impl" LINEAR "%3.1" "m/s" COEFFS_LINEAR 1 0 /end COMPU_METHOD
+    /begin COMPU_METHOD CM.LINEAR.MUL_3 "phys = impl * 3" LINEAR "%3.1" "hours" COEFFS_LINEAR 3 0 /end COMPU_METHOD
+    /begin COMPU_METHOD CM.TAB_INTP.DEFAULT_VALUE "table"
+      TAB_INTP "%8.4" "U/  min"
+      COMPU_TAB_REF CM.TAB_INTP.DEFAULT_VALUE.REF
+    /end COMPU_METHOD
+    /begin COMPU_METHOD CM.TAB_NOINTP.DEFAULT_VALUE "table"
+      TAB_NOINTP "%8.4" "U/  min"
+      COMPU_TAB_REF CM.TAB_NOINTP.DEFAULT_VALUE.REF
+    /end COMPU_METHOD
+    /begin COMPU_METHOD CM.TAB_VERB.DEFAULT_VALUE "verbal table"
+      TAB_VERB "%12.0" ""
+      COMPU_TAB_REF CM.TAB_VERB.DEFAULT_VALUE.REF
+    /end COMPU_METHOD
+    /begin COMPU_METHOD CM.VTAB_RANGE.DEFAULT_VALUE "verbal range table"
+      TAB_VERB "%4.2" ""
+      COMPU_TAB_REF CM.VTAB_RANGE.DEFAULT_VALUE.REF
+    /end COMPU_METHOD
+
+    /begin COMPU_TAB CM.TAB_INTP.DEFAULT_VALUE.REF "" TAB_INTP 12
+      -3 98  -1 99  0 100  2 102  4 104  5 105  6 106  7 107  8 108  9 109  10 110  13 111
+      DEFAULT_VALUE_NUMERIC 300.56
+    /end COMPU_TAB
+    /begin COMPU_TAB CM.TAB_NOINTP.DEFAULT_VALUE.REF "" TAB_NOINTP 12
+      13 111  -3 98  -1 99  0 100  2 102  4 104  5 105  6 106  7 107  8 108  9 109  10 110
+    /end COMPU_TAB
+    /begin COMPU_VTAB CM.TAB_VERB.DEFAULT_VALUE.REF "" TAB_VERB 3
+      1 "SawTooth"
+      2 "Square"
+      3 "Sinus"
+      DEFAULT_VALUE "unknown signal type"
+    /end COMPU_VTAB
+    /begin COMPU_VTAB_RANGE CM.VTAB_RANGE.DEFAULT_VALUE.REF "" 11
+      0 1 "Zero_to_one"
+      2 3 "two_to_three"
+      4 7 "four_to_seven"
+      14 17 "fourteen_to_seventeen"
+      18 99 "eigteen_to_ninetynine"
+      100 100 "hundred"
+      101 101 "hundredone"
+      102 102 "hundredtwo"
+      103 103 "hundredthree"
+      104 104 "hundredfour"
+      105 105 "hundredfive"
+      DEFAULT_VALUE "out of range value"
+    /end COMPU_VTAB_RANGE
+
+    /begin RECORD_LAYOUT RL.AXIS_PTS.UBYTE
+      NO_AXIS_PTS_X 1 UBYTE
+      AXIS_PTS_X 2 UBYTE INDEX_INCR DIRECT
+      ALIGNMENT_BYTE 1
+    /end RECORD_LAYOUT
+    /begin AXIS_PTS ASAM.C.AXIS_PTS.UBYTE_8 "Axis points"
+      0x810000 NO_INPUT_QUANTITY RL.AXIS_PTS.UBYTE 0 CM.RAT_FUNC.DIV_10 8 0 25.5
+      DEPOSIT ABSOLUTE
+    /end AXIS_PTS
+  /end MODULE
+/end PROJECT
+`
+
+func parseDemoA2L(t *testing.T) *a2l.File {
+	t.Helper()
+	f, err := a2l.Parse(strings.NewReader(demoA2L))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestParseA2L(t *testing.T) {
+	f := parseDemoA2L(t)
+	if f.Project != "ASAP2_Example" || f.Module != "Example" || len(f.Measurements) != 12 || len(f.CompuMethods) != 11 || len(f.CompuTabs) != 4 {
+		t.Fatalf("wrong A2L %s %s: %d measurements, %d methods, %d tables", f.Project, f.Module, len(f.Measurements), len(f.CompuMethods), len(f.CompuTabs))
+	}
+
+	div := f.Measurement("ASAM.M.SCALAR.UBYTE.RAT_FUNC.DIV_10")
+	if div.DataType != "UBYTE" || div.Address != 0x13a00 || div.UpperLimit != 25.5 || div.Unit() != "km/h" || div.CompuMethod.Type != a2l.RatFunc {
+		t.Fatalf("wrong measurement %+v", div)
+	}
+	if v, ok := div.CompuMethod.Physical(25); !ok || v != 2.5 {
+		t.Fatalf("expected 2.5, got %f", v)
+	}
+	if m := f.Measurement("ASAM.M.MATRIX_DIM_16.UBYTE.IDENTICAL"); len(m.MatrixDim) != 1 || m.MatrixDim[0] != 16 || m.ByteOrder != "MSB_LAST" {
+		t.Fatalf("wrong array measurement %+v", m)
+	}
+	if m := f.Measurement("ASAM.M.SCALAR.UBYTE.IDENTICAL"); m.CompuMethod != nil || m.Unit() != "min" {
+		t.Fatalf("expected no conversion method %+v", m)
+	}
+
+	if v, ok := f.CompuMethods["CM.FORM.X_PLUS_4"].Physical(-2); !ok || v != 2 {
+		t.Fatalf("expected the formula value 2, got %f", v)
+	}
+	intp := f.CompuMethods["CM.TAB_INTP.DEFAULT_VALUE"]
+	if v, _ := intp.Physical(11.5); v != 110.5 {
+		t.Fatalf("expected the interpolated value 110.5, got %f", v)
+	}
+	if v, _ := intp.Physical(20); v != 300.56 {
+		t.Fatalf("expected the default value, got %f", v)
+	}
+	// the pairs are sorted by raw value
+	if v, _ := f.CompuMethods["CM.TAB_NOINTP.DEFAULT_VALUE"].Physical(12); v != 111 {
+		t.Fatalf("expected the value of the nearest key, got %f", v)
+	}
+	if text, ok := f.CompuMethods["CM.VTAB_RANGE.DEFAULT_VALUE"].Text(50); !ok || text != "eigteen_to_ninetynine" {
+		t.Fatalf("wrong range text %q", text)
+	}
+	if text, _ := f.CompuMethods["CM.TAB_VERB.DEFAULT_VALUE"].Text(9); text != "unknown signal type" {
+		t.Fatalf("expected the default text, got %q", text)
+	}
+
+	axis := f.AxisPtsByName("ASAM.C.AXIS_PTS.UBYTE_8")
+	if axis == nil || axis.Address != 0x810000 || axis.MaxAxisPoints != 8 || axis.Unit() != "km/h" || axis.Layout == nil {
+		t.Fatalf("wrong axis points %+v", axis)
+	}
+	entry, ok := axis.Layout.Entry("AXIS_PTS_X")
+	if !ok || entry.Position != 2 || entry.DataType != "UBYTE" || len(entry.Params) != 2 || entry.Params[0] != "INDEX_INCR" {
+		t.Fatalf("wrong record layout entry %+v", entry)
+	}
+	if entry, _ := axis.Layout.Entry("ALIGNMENT_BYTE"); entry.Position != 0 || len(entry.Params) != 1 {
+		t.Fatalf("wrong alignment %+v", entry)
+	}
+
+	for src, line := range map[string]string{
+		"/begin MEASUREMENT M \"\" UBYTE CM.UNKNOWN 0 0 0 1 /end MEASUREMENT":             "unknown COMPU_METHOD",
+		"/begin PROJECT P \"\"\n/begin MODULE M \"\"\n/end PROJECT":                       "line 3",
+		"/begin COMPU_METHOD CM \"\" LINEAR \"\" \"\"\nCOEFFS_LINEAR 2 /end COMPU_METHOD": "line 1",
+	} {
+		if _, err := a2l.Parse(strings.NewReader(src)); err == nil || !strings.Contains(err.Error(), line) {
+			t.Fatalf("expected an error with %q, got %v", line, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "demo.a2l")
+	if err := os.WriteFile(path, []byte(demoA2L), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := a2l.ParseFile(path); err != nil || len(f.AxisPts) != 1 {
+		t.Fatalf("expected the A2L file %v", err)
+	}
+}
+
+func TestValidateA2L(t *testing.T) {
+	f := parseDemoA2L(t)
+	m := reopen(t, "./samples/ASAP2_Demo_V171.mf4")
+
+	if m, a := f.Lookup("ASAM_[1][0].M.MATRIX_DIM_16.UBYTE.IDENTICAL"); m == nil || a != nil {
+		t.Fatal("expected the measurement of the array element")
+	}
+
+	mismatches, err := a2l.Validate(m, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("expected 2 mismatches, got %v", mismatches)
+	}
+	if unit := mismatches[0]; unit.Channel != "ASAM.M.SCALAR.UBYTE.IDENTICAL" || unit.Field != "unit" || unit.Expected != "min" || unit.Actual != "hours" {
+		t.Fatalf("wrong unit mismatch %v", unit)
+	}
+	if conversion := mismatches[1]; conversion.Channel != "ASAM.M.SCALAR.UWORD.IDENTICAL" || conversion.Field != "conversion" || conversion.Expected != "1 -> 3" {
+		t.Fatalf("wrong conversion mismatch %v", conversion)
+	}
+}
+
+func TestEnrichA2L(t *testing.T) {
+	m := reopen(t, "./samples/ASAP2_Demo_V171.mf4")
+	if _, err := a2l.Find(m); err == nil {
+		t.Fatal("expected no A2L")
+	}
+
+	path := filepath.Join(t.TempDir(), "demo.mf4")
+	e, err := m.EditCopy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddAttachment("demo.a2l", strings.NewReader(demoA2L), mf4.AttachmentOptions{Compress: true}); err != nil {
+		t.Fatal(err)
+	}
+	n, err := a2l.Enrich(e, m, parseDemoA2L(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the unit of the virtual measurement comes from its conversion method
+	if n != 1 {
+		t.Fatalf("expected 1 channel changed, got %d", n)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	enriched := reopen(t, path)
+	f, err := a2l.Find(enriched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cg := range enriched.ChannelGroup {
+		if c, ok := cg.Channels["ASAM.M.VIRTUAL.SCALAR.SWORD.PHYSICAL"]; ok && c.GetUnit() != "m/s" {
+			t.Fatalf("expected unit m/s, got %q", c.GetUnit())
+		}
+	}
+	if mismatches, err := a2l.Validate(enriched, f); err != nil || len(mismatches) != 2 {
+		t.Fatalf("expected the same mismatches %v %v", mismatches, err)
+	}
+
+	// descriptions of channels without comment
+	plain := filepath.Join(t.TempDir(), "plain.mf4")
+	w, err := mf4.NewWriter(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddGroup("ECU", []float64{0, 0.1}, mf4.WriterChannel{Name: "ASAM.M.SCALAR.UBYTE.RAT_FUNC.DIV_10", Values: []float64{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m = reopen(t, plain)
+	path = filepath.Join(t.TempDir(), "described.mf4")
+	if e, err = m.EditCopy(path); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := a2l.Enrich(e, m, f); err != nil || n != 1 {
+		t.Fatalf("expected 1 channel changed, got %d %v", n, err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c := reopen(t, path).ChannelGroup[0].Channels["ASAM.M.SCALAR.UBYTE.RAT_FUNC.DIV_10"]
+	if c.GetUnit() != "km/h" || c.Meta().TX != "Scalar measurement divided by 10" {
+		t.Fatalf("expected the unit and description of the A2L, got %q %q", c.GetUnit(), c.Meta().TX)
+	}
+}
@@ -0,0 +1,179 @@
+package mf4
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+)
+
+// AlignedSamples holds samples of channels, possibly from different files, on
+// a common absolute timeline
+type AlignedSamples struct {
+	//timestamps of the common timeline, in nanoseconds since 1970-01-01
+	TimestampsNs []int64
+
+	//samples of each channel, in the order of the aligned channels. Each value
+	//is the last sample of the channel at or before the timestamp.
+	Samples [][]interface{}
+}
+
+// Times returns the timestamps of the common timeline in UTC
+func (a *AlignedSamples) Times() []time.Time {
+	r := make([]time.Time, len(a.TimestampsNs))
+	for i, ns := range a.TimestampsNs {
+		r[i] = time.Unix(0, ns).UTC()
+	}
+	return r
+}
+
+// AbsoluteTimesNs returns the timestamp of each sample of the channel, in
+// nanoseconds since 1970-01-01: the start time of the measurement plus the
+// value of its time master in seconds. If the header stores local time, the
+// timestamps are the wall clock of the recording, see MF4.IsLocalTime.
+func (c *Channel) AbsoluteTimesNs() ([]int64, error) {
+	master := c
+	if !c.IsMaster() {
+		master = c.Master
+	}
+	if master == nil || master.block == nil || master.SyncDomain() != blocks.TimeSyncDomain {
+		return nil, fmt.Errorf("channel %s has no time master channel", c.Name)
+	}
+
+	values, err := c.MasterValues()
+	if err != nil {
+		return nil, err
+	}
+
+	start := c.mf4.GetStartTimeNs()
+	r := make([]int64, len(values))
+	for i, v := range values {
+		r[i] = start + int64(math.Round(v*float64(time.Second)))
+	}
+	return r, nil
+}
+
+// AbsoluteTimes returns the timestamp of each sample of the channel, in the
+// time zone of the start time, see AbsoluteTimesNs and MF4.StartTime
+func (c *Channel) AbsoluteTimes() ([]time.Time, error) {
+	ns, err := c.AbsoluteTimesNs()
+	if err != nil {
+		return nil, err
+	}
+
+	location := c.mf4.StartTime().Location()
+	r := make([]time.Time, len(ns))
+	for i, v := range ns {
+		r[i] = time.Unix(0, v).In(location)
+	}
+	return r, nil
+}
+
+// SampleAbsolute returns the samples of the channel together with their
+// absolute timestamps
+func (c *Channel) SampleAbsolute() ([]time.Time, []interface{}, error) {
+	times, err := c.AbsoluteTimes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sample, err := c.Sample()
+	if err != nil {
+		return nil, nil, err
+	}
+	return times, sample, nil
+}
+
+// AlignChannels aligns channels, for instance of a vehicle logger file and of
+// a test bench file, on a common absolute timeline. The timeline covers the
+// interval where all channels have samples; with `step` 0 it is made of all
+// timestamps of the channels, otherwise of timestamps every `step` from the
+// start of the interval. Channels are sampled by holding their last value.
+//
+// Files storing local time can only be aligned with each other.
+func AlignChannels(step time.Duration, channels ...*Channel) (*AlignedSamples, error) {
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("no channel to align")
+	}
+	if step < 0 {
+		return nil, fmt.Errorf("invalid step %v", step)
+	}
+
+	times := make([][]int64, len(channels))
+	samples := make([][]interface{}, len(channels))
+	var first, last int64 = math.MinInt64, math.MaxInt64
+	for i, cn := range channels {
+		if cn.mf4.IsLocalTime() != channels[0].mf4.IsLocalTime() {
+			return nil, fmt.Errorf("can't align channel %s in local time with channel %s in UTC", cn.Name, channels[0].Name)
+		}
+
+		var err error
+		times[i], err = cn.AbsoluteTimesNs()
+		if err != nil {
+			return nil, err
+		}
+		samples[i], err = cn.Sample()
+		if err != nil {
+			return nil, err
+		}
+		if len(times[i]) == 0 || len(times[i]) != len(samples[i]) {
+			return nil, fmt.Errorf("channel %s has no samples to align", cn.Name)
+		}
+
+		first = max(first, times[i][0])
+		last = min(last, times[i][len(times[i])-1])
+	}
+
+	a := &AlignedSamples{Samples: make([][]interface{}, len(channels))}
+	if first > last {
+		return a, nil
+	}
+
+	if step == 0 {
+		a.TimestampsNs = mergeAxes(times, first, last)
+	} else {
+		for v := first; v <= last; v += int64(step) {
+			a.TimestampsNs = append(a.TimestampsNs, v)
+		}
+	}
+
+	for i := range channels {
+		a.Samples[i] = holdSamples(a.TimestampsNs, times[i], samples[i])
+	}
+	return a, nil
+}
+
+// mergeAxes returns the sorted values of all `axes` between `first` and
+// `last`, without duplicates
+func mergeAxes[T cmp.Ordered](axes [][]T, first, last T) []T {
+	r := make([]T, 0)
+	for _, axis := range axes {
+		for _, v := range axis {
+			if v >= first && v <= last {
+				r = append(r, v)
+			}
+		}
+	}
+	slices.Sort(r)
+	return slices.Compact(r)
+}
+
+// holdSamples samples the values `sample` of `axis` at each value of
+// `target`, holding the last sample at or before it. Values before the first
+// sample are 'nil'.
+func holdSamples[T cmp.Ordered](target, axis []T, sample []interface{}) []interface{} {
+	r := make([]interface{}, len(target))
+	j := -1
+	for k, v := range target {
+		for j+1 < len(axis) && axis[j+1] <= v {
+			j++
+		}
+		if j >= 0 {
+			r[k] = sample[j]
+		}
+	}
+	return r
+}
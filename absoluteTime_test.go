@@ -0,0 +1,91 @@
+package mf4_test
+
+import (
+	"testing"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+var alignStart = time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+
+// timelineFixture starts `offset` after alignStart, in UTC+2, and has a time
+// master and a float64 channel `name`
+func timelineFixture(t *testing.T, offset time.Duration, name string, times, values []float64) *mf4.MF4 {
+	f := newFixture(410)
+	f.header(hdData{StartTimeNs: uint64(alignStart.Add(offset).UnixNano()), TZOffsetMin: 120, TimeFlags: 2})
+
+	master := f.channel("time", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	value := f.channel(name, cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+	cg := f.block("##CG", []int64{0, f.chain(0, master, value), 0, 0, 0, 0}, encode(cgData{CycleCount: uint64(len(times)), DataBytes: 16}))
+
+	rows := make([][]float64, len(times))
+	for i := range times {
+		rows[i] = []float64{times[i], values[i]}
+	}
+	dg := f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records(rows...)), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestAbsoluteTimes(t *testing.T) {
+	m := timelineFixture(t, 0, "speed", []float64{0, 0.5, 1.25}, []float64{1, 2, 3})
+	cn := m.ChannelGroup[0].Channels["speed"]
+
+	ns, err := cn.AbsoluteTimesNs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 3 || ns[2] != alignStart.Add(1250*time.Millisecond).UnixNano() {
+		t.Fatalf("wrong timestamps %v", ns)
+	}
+
+	times, sample, err := cn.SampleAbsolute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, offset := times[1].Zone(); offset != 7200 || times[1].Hour() != 10 || sample[1] != 2.0 {
+		t.Fatalf("wrong absolute sample %v at %v", sample[1], times[1])
+	}
+}
+
+func TestAlignChannels(t *testing.T) {
+	// the bench file starts one second after the logger file
+	logger := timelineFixture(t, 0, "speed", []float64{0, 1, 2, 3}, []float64{10, 11, 12, 13})
+	bench := timelineFixture(t, time.Second, "torque", []float64{0, 0.5, 1, 1.5}, []float64{100, 101, 102, 103})
+
+	speed := logger.ChannelGroup[0].Channels["speed"]
+	torque := bench.ChannelGroup[0].Channels["torque"]
+
+	a, err := mf4.AlignChannels(0, speed, torque)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSpeed := []float64{11, 11, 12, 12}
+	expectedTorque := []float64{100, 101, 102, 103}
+	if len(a.TimestampsNs) != 4 || a.TimestampsNs[0] != alignStart.Add(time.Second).UnixNano() {
+		t.Fatalf("wrong timeline %v", a.Times())
+	}
+	for i := range expectedSpeed {
+		if a.Samples[0][i] != expectedSpeed[i] || a.Samples[1][i] != expectedTorque[i] {
+			t.Fatalf("%d: expected %f %f, got %v %v", i, expectedSpeed[i], expectedTorque[i], a.Samples[0][i], a.Samples[1][i])
+		}
+	}
+
+	a, err = mf4.AlignChannels(time.Second, speed, torque)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.TimestampsNs) != 2 || a.Samples[0][1] != 12.0 || a.Samples[1][1] != 102.0 {
+		t.Fatalf("wrong regular timeline %v %v", a.Times(), a.Samples)
+	}
+
+	if _, err := mf4.AlignChannels(0, logger.ChannelGroup[0].Channels["time"], speed); err != nil {
+		t.Fatal(err)
+	}
+}
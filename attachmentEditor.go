@@ -0,0 +1,318 @@
+package mf4
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+	"github.com/LincolnG4/GoMDF/blocks/EV"
+	"github.com/LincolnG4/GoMDF/blocks/FH"
+)
+
+// AttachmentOptions describes how Editor embeds an attachment
+type AttachmentOptions struct {
+	//MIME type of the attachment. If empty, it is guessed from the extension
+	//of its name.
+	MimeType string
+
+	//comment of the attachment
+	Comment string
+
+	//compress the embedded data with zlib
+	Compress bool
+
+	//store the MD5 check sum of the data, see AT.AttFile.Verify
+	MD5 bool
+}
+
+// editedAttachment is an ATBLOCK in the attachment list of the edited file
+type editedAttachment struct {
+	name    string
+	address int64
+}
+
+// attachmentData is the fixed part of the data section of an ATBLOCK
+type attachmentData struct {
+	Flags        uint16
+	CreatorIndex uint16
+	Reserved     [4]byte
+	MD5Checksum  [16]byte
+	OriginalSize uint64
+	EmbeddedSize uint64
+}
+
+// blockLink is the link `index` of the block at `address`
+type blockLink struct {
+	address int64
+	index   int
+}
+
+// AddAttachment embeds the content of `r` as an attachment named `name`, for
+// instance a DBC or a test plan, at the end of the attachment list. Its
+// creator is the file history entry written by Close.
+func (e *Editor) AddAttachment(name string, r io.Reader, opts AttachmentOptions) error {
+	if err := e.loadAttachments(); err != nil {
+		return err
+	}
+	if i := e.findAttachment(name); i >= 0 {
+		return fmt.Errorf("attachment %s already exists", name)
+	}
+
+	addr, err := e.appendAttachment(name, r, opts)
+	if err != nil {
+		return err
+	}
+
+	e.attachments = append(e.attachments, editedAttachment{name: name, address: addr})
+	e.changes = append(e.changes, "attachment "+name+" (added)")
+	return nil
+}
+
+// ReplaceAttachment replaces the content of the attachment `name` by the
+// content of `r`. The attachment keeps its place in the list, and channels and
+// events referencing it reference the new content.
+func (e *Editor) ReplaceAttachment(name string, r io.Reader, opts AttachmentOptions) error {
+	if err := e.loadAttachments(); err != nil {
+		return err
+	}
+	i := e.findAttachment(name)
+	if i < 0 {
+		return fmt.Errorf("attachment %s not found", name)
+	}
+
+	references, err := e.attachmentReferences(e.attachments[i].address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := e.appendAttachment(e.attachments[i].name, r, opts)
+	if err != nil {
+		return err
+	}
+	for _, l := range references {
+		if err := e.w.setLink(l.address, l.index, addr); err != nil {
+			return err
+		}
+	}
+
+	e.attachments[i].address = addr
+	e.changes = append(e.changes, "attachment "+name+" (replaced)")
+	return nil
+}
+
+// RemoveAttachment removes the attachment `name` from the attachment list. An
+// attachment still referenced by a channel or an event can't be removed. The
+// ATBLOCK stays in the file until it is compacted, see Editor.Compact.
+func (e *Editor) RemoveAttachment(name string) error {
+	if err := e.loadAttachments(); err != nil {
+		return err
+	}
+	i := e.findAttachment(name)
+	if i < 0 {
+		return fmt.Errorf("attachment %s not found", name)
+	}
+
+	references, err := e.attachmentReferences(e.attachments[i].address)
+	if err != nil {
+		return err
+	}
+	if len(references) > 0 {
+		return fmt.Errorf("attachment %s is referenced by the block at address %d", name, references[0].address)
+	}
+
+	e.attachments = append(e.attachments[:i], e.attachments[i+1:]...)
+	e.changes = append(e.changes, "attachment "+name+" (removed)")
+	return nil
+}
+
+// loadAttachments reads the attachment list of the file, once
+func (e *Editor) loadAttachments() error {
+	if e.attachments != nil {
+		return nil
+	}
+
+	file := e.mf4.reader()
+	e.attachments = make([]editedAttachment, 0)
+	for addr := e.mf4.getFirstAttachment(); addr != 0; {
+		at, err := AT.New(file, addr)
+		if err != nil {
+			return err
+		}
+		e.attachments = append(e.attachments, editedAttachment{
+			name:    at.GetFileName(file, at.GetTxFilename()),
+			address: addr,
+		})
+		addr = at.Next()
+	}
+	return nil
+}
+
+// findAttachment returns the index of the attachment whose file name, or base
+// name, is `name`, or -1
+func (e *Editor) findAttachment(name string) int {
+	for i, a := range e.attachments {
+		if a.name == name || path.Base(filepath.ToSlash(a.name)) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// attachmentReferences returns the links of channels and events to the
+// attachment at `addr`
+func (e *Editor) attachmentReferences(addr int64) ([]blockLink, error) {
+	r := make([]blockLink, 0)
+	for _, cg := range e.mf4.ChannelGroup {
+		for _, cn := range cg.Channels {
+			// cn_data
+			if cn.IsSynchronization() && cn.block.Link.Data == addr {
+				r = append(r, blockLink{address: cn.address, index: 5})
+			}
+			// cn_at_reference, after the 8 fixed links
+			for i, at := range cn.block.Link.AtReference {
+				if at == addr {
+					r = append(r, blockLink{address: cn.address, index: 8 + i})
+				}
+			}
+		}
+	}
+
+	file := e.mf4.reader()
+	for next := e.mf4.getFirstEvent(); next != 0; {
+		ev, err := EV.New(file, e.mf4.MdfVersion(), next)
+		if err != nil {
+			return nil, err
+		}
+		for i, at := range ev.Link.ATReference {
+			// ev_at_reference, after the 5 fixed links and the scope
+			if at == addr {
+				r = append(r, blockLink{address: next, index: 5 + len(ev.Link.Scope) + i})
+			}
+		}
+		next = ev.Next()
+	}
+	return r, nil
+}
+
+// appendAttachment appends an ATBLOCK embedding the content of `r`, spooled
+// to a temporary file to compute its sizes and check sum
+func (e *Editor) appendAttachment(name string, r io.Reader, opts AttachmentOptions) (int64, error) {
+	creator, err := e.historyLength()
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp("", "mf4-attachment-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	data := attachmentData{Flags: AT.EmbeddedFlag, CreatorIndex: creator}
+	sum := md5.New()
+	var w io.WriteCloser = nopWriteCloser{tmp}
+	if opts.Compress {
+		data.Flags |= AT.CompressedFlag
+		w = zlib.NewWriter(tmp)
+	}
+	n, err := io.Copy(w, io.TeeReader(r, sum))
+	if err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	data.OriginalSize = uint64(n)
+	if opts.MD5 {
+		data.Flags |= AT.MD5ValidFlag
+		copy(data.MD5Checksum[:], sum.Sum(nil))
+	}
+
+	embedded, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	data.EmbeddedSize = uint64(embedded)
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	mimeType := opts.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(name))
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	links := make([]int64, 4)
+	if links[1], err = e.appendText(name); err != nil {
+		return 0, err
+	}
+	if links[2], err = e.appendText(mimeType); err != nil {
+		return 0, err
+	}
+	if opts.Comment != "" {
+		if links[3], err = e.appendText(opts.Comment); err != nil {
+			return 0, err
+		}
+	}
+
+	fixed := encodeBlockData(data)
+	return e.w.appendBlockFrom(blocks.AtID, links, int64(len(fixed))+embedded, io.MultiReader(bytes.NewReader(fixed), tmp))
+}
+
+// writeAttachments links the attachment list of the edited file
+func (e *Editor) writeAttachments() error {
+	if e.attachments == nil {
+		return nil
+	}
+
+	// hd_at_first, then at_at_next of each attachment
+	last, index := blocks.IdblockSize, 3
+	for _, a := range e.attachments {
+		if err := e.w.setLink(last, index, a.address); err != nil {
+			return err
+		}
+		last, index = a.address, 0
+	}
+	return e.w.setLink(last, index, 0)
+}
+
+// historyLength returns the number of entries in the file history of the file
+func (e *Editor) historyLength() (uint16, error) {
+	n := uint16(0)
+	file := e.mf4.reader()
+	for next := e.mf4.getFileHistory(); next != 0; n++ {
+		block, err := FH.New(file, next)
+		if err != nil {
+			return 0, err
+		}
+		next = block.Next()
+	}
+	return n, nil
+}
+
+// appendText appends a TXBLOCK with `s`
+func (e *Editor) appendText(s string) (int64, error) {
+	data := make([]byte, (len(s)+8)&^7)
+	copy(data, s)
+	return e.w.appendBlock(blocks.TxID, nil, data)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package mf4_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+)
+
+func readAttachment(t *testing.T, at AT.AttFile) string {
+	t.Helper()
+	if err := at.Verify(); err != nil {
+		t.Fatalf("attachment %s: %v", at.Name, err)
+	}
+	r, err := at.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestEditAttachments(t *testing.T) {
+	m := eventsFixture(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "attached.mf4")
+
+	e, err := m.EditCopy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbc := strings.Repeat("BO_ 100 ENGINE: 8 Vector__XXX\n", 50)
+	if err := e.AddAttachment("vehicle.dbc", strings.NewReader(dbc), mf4.AttachmentOptions{Compress: true, MD5: true, Comment: "powertrain"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddAttachment("plan.pdf", strings.NewReader("%PDF-1.4"), mf4.AttachmentOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddAttachment("plan.pdf", strings.NewReader(""), mf4.AttachmentOptions{}); err == nil {
+		t.Fatal("expected an error adding an existing attachment")
+	}
+	if err := e.RemoveAttachment("trigger.png"); err == nil {
+		t.Fatal("expected an error removing an attachment referenced by an event")
+	}
+	if err := e.ReplaceAttachment("trigger.png", bytes.NewReader([]byte("png")), mf4.AttachmentOptions{MD5: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := reopen(t, path)
+	attachments, err := edited.GetAttachments()
+	if err != nil || len(attachments) != 3 {
+		t.Fatalf("expected 3 attachments, got %v %v", attachments, err)
+	}
+	dbcAt := attachments[1]
+	if attachments[0].Name != "trigger.png" || dbcAt.Name != "vehicle.dbc" || attachments[2].Name != "plan.pdf" {
+		t.Fatalf("wrong attachment list %v", attachments)
+	}
+	if dbcAt.Type != "application/octet-stream" || dbcAt.Comment != "powertrain" || dbcAt.Creator() != 0 {
+		t.Fatalf("wrong attachment %+v", dbcAt)
+	}
+	if readAttachment(t, dbcAt) != dbc || readAttachment(t, attachments[2]) != "%PDF-1.4" {
+		t.Fatal("wrong attachment content")
+	}
+
+	events, err := edited.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	at, err := events[1].Attachments()
+	if err != nil || len(at) != 1 || readAttachment(t, at[0]) != "png" {
+		t.Fatalf("event attachment not replaced %v %v", at, err)
+	}
+
+	history, err := edited.FileHistoryEntries()
+	if err != nil || len(history) != 1 {
+		t.Fatalf("expected 1 history entry, got %v %v", history, err)
+	}
+	if c := history[0].Comment.TX; !strings.Contains(c, "attachment vehicle.dbc (added)") || !strings.Contains(c, "attachment trigger.png (replaced)") {
+		t.Fatalf("wrong history comment %s", c)
+	}
+
+	// removing and compacting drops the unreferenced blocks
+	compacted := filepath.Join(dir, "compacted.mf4")
+	e, err = edited.EditCopy(compacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Compact = true
+	if err := e.RemoveAttachment("plan.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := os.Stat(path)
+	after, _ := os.Stat(compacted)
+	if after.Size() >= before.Size() {
+		t.Fatalf("file not compacted: %d bytes, was %d", after.Size(), before.Size())
+	}
+
+	result := reopen(t, compacted)
+	attachments, err = result.GetAttachments()
+	if err != nil || len(attachments) != 2 || readAttachment(t, attachments[1]) != dbc || attachments[1].Creator() != 0 {
+		t.Fatalf("wrong attachments after compaction %v %v", attachments, err)
+	}
+	if history, err := result.FileHistoryEntries(); err != nil || len(history) != 2 {
+		t.Fatalf("expected 2 history entries, got %v %v", history, err)
+	}
+	if events, err := result.Events(); err != nil || len(events) != 4 || events[1].Name != "overspeed" {
+		t.Fatalf("wrong events after compaction %v %v", events, err)
+	}
+	sample, err := result.ChannelGroup[0].Channels["speed"].Sample()
+	if err != nil || len(sample) != 1 || sample[0] != 1.0 {
+		t.Fatalf("wrong samples after compaction %v %v", sample, err)
+	}
+}
@@ -0,0 +1,221 @@
+package mf4_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+)
+
+// attachment appends an ATBLOCK with `content`, compressed if `compress` is
+// set, and returns its address. A wrong check sum is written if `corrupt` is
+// set.
+func (f *fixture) attachment(name string, content []byte, compress, corrupt bool) int64 {
+	data := content
+	flags := uint16(1 | 1<<2)
+	if compress {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(content)
+		zw.Close()
+		data = z.Bytes()
+		flags |= 1 << 1
+	}
+
+	sum := md5.Sum(content)
+	if corrupt {
+		sum[0]++
+	}
+
+	var d bytes.Buffer
+	binary.Write(&d, binary.LittleEndian, flags)
+	d.Write(make([]byte, 6))
+	d.Write(sum[:])
+	binary.Write(&d, binary.LittleEndian, uint64(len(content)))
+	binary.Write(&d, binary.LittleEndian, uint64(len(data)))
+	d.Write(data)
+	return f.block("##AT", []int64{0, f.text(name), f.text("text/plain"), 0}, d.Bytes())
+}
+
+func attachmentFixture(t *testing.T) []AT.AttFile {
+	f := newFixture(410)
+	content := bytes.Repeat([]byte("calibration data\n"), 100)
+	f.link(hdAddress, 3, f.chain(0,
+		f.attachment("plain.txt", content, false, false),
+		f.attachment("compressed.txt", content, true, false),
+		f.attachment("corrupt.txt", content, true, true),
+		f.attachment(`..\..\escape.txt`, content, false, false),
+		f.block("##AT", []int64{0, f.text("external.dbc"), 0, 0}, make([]byte, 40)),
+	))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachments, err := m.GetAttachments()
+	if err != nil || len(attachments) != 5 {
+		t.Fatalf("expected 5 attachments, got %d %v", len(attachments), err)
+	}
+	return attachments
+}
+
+func TestAttachmentOpen(t *testing.T) {
+	attachments := attachmentFixture(t)
+	content := bytes.Repeat([]byte("calibration data\n"), 100)
+
+	for _, at := range attachments[:2] {
+		r, err := at.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil || !bytes.Equal(data, content) {
+			t.Fatalf("%s: wrong content %v", at.Name, err)
+		}
+		if err := at.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := attachments[2].Verify(); err == nil {
+		t.Fatal("expected MD5 error")
+	}
+	if _, err := attachments[4].Open(); err == nil {
+		t.Fatal("expected error for external attachment")
+	}
+}
+
+func TestAttachmentChain(t *testing.T) {
+	f := newFixture(410)
+	commented := f.block("##AT", []int64{0, f.text("a.txt"), 0, f.metadata("<ATcomment><TX>first</TX></ATcomment>")}, make([]byte, 40))
+	plain := f.block("##AT", []int64{0, f.text("b.txt"), 0, 0}, make([]byte, 40))
+	// at_at_next pointing to a TXBLOCK
+	f.link(plain, 0, f.text("broken"))
+	f.link(hdAddress, 3, f.chain(0, commented, plain))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachments, err := m.GetAttachments()
+	if err == nil {
+		t.Fatal("expected an error for the broken attachment chain")
+	}
+	if len(attachments) != 2 || attachments[0].Comment == "" || attachments[1].Comment != "" {
+		t.Fatalf("wrong attachments before the error %+v", attachments)
+	}
+}
+
+func TestAttachmentSave(t *testing.T) {
+	attachments := attachmentFixture(t)
+	dir := t.TempDir()
+
+	saved, err := attachments[1].Save(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Path != filepath.Join(dir, "compressed.txt") {
+		t.Fatalf("wrong path %s", saved.Path)
+	}
+	if info, err := os.Stat(saved.Path); err != nil || info.Size() != 1700 {
+		t.Fatalf("wrong saved file %v %v", info, err)
+	}
+
+	// the file name can't escape the output directory
+	saved, err = attachments[3].Save(nil, dir)
+	if err != nil || saved.Path != filepath.Join(dir, "escape.txt") {
+		t.Fatalf("wrong path %s %v", saved.Path, err)
+	}
+
+	if _, err := attachments[2].Save(nil, dir); err == nil {
+		t.Fatal("expected MD5 error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "corrupt.txt")); !os.IsNotExist(err) {
+		t.Fatal("corrupt attachment not removed")
+	}
+}
+
+// externalAttachment appends an ATBLOCK referencing the file `name`, with
+// the MD5 check sum of `content`
+func (f *fixture) externalAttachment(name string, content []byte) int64 {
+	sum := md5.Sum(content)
+	var d bytes.Buffer
+	binary.Write(&d, binary.LittleEndian, uint16(1<<2))
+	d.Write(make([]byte, 6))
+	d.Write(sum[:])
+	binary.Write(&d, binary.LittleEndian, uint64(len(content)))
+	binary.Write(&d, binary.LittleEndian, uint64(0))
+	return f.block("##AT", []int64{0, f.text(name), 0, 0}, d.Bytes())
+}
+
+func TestExternalAttachment(t *testing.T) {
+	dbc := []byte("VERSION \"\"\n")
+	a2l := []byte("ASAP2_VERSION 1 71\n")
+
+	f := newFixture(410)
+	f.link(hdAddress, 3, f.chain(0,
+		f.externalAttachment(`dbc\vehicle.dbc`, dbc),
+		f.externalAttachment(`C:\projects\ecu.a2l`, a2l),
+		f.externalAttachment("missing.dbc", dbc),
+		f.externalAttachment("changed.dbc", a2l),
+	))
+	file := f.open(t)
+
+	// the DBC is next to the recording, the A2L in a search path
+	dir := filepath.Dir(file.Name())
+	os.Mkdir(filepath.Join(dir, "dbc"), 0o755)
+	if err := os.WriteFile(filepath.Join(dir, "dbc", "vehicle.dbc"), dbc, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "changed.dbc"), dbc, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	search := fstest.MapFS{"ecu.a2l": &fstest.MapFile{Data: a2l}}
+
+	m, err := mf4.ReadFile(file, &mf4.ReadOptions{AttachmentSearchPath: []fs.FS{search}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachments, err := m.GetAttachments()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range [][]byte{dbc, a2l} {
+		r, err := attachments[i].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("%s: wrong content %q", attachments[i].Name, data)
+		}
+		if err := attachments[i].Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := attachments[2].Open(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if err := attachments[3].Verify(); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("expected error for external file not matching its check sum")
+	}
+
+	saved, err := attachments[1].Save(nil, t.TempDir())
+	if err != nil || filepath.Base(saved.Path) != "ecu.a2l" {
+		t.Fatalf("external attachment not saved %s %v", saved.Path, err)
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -53,6 +55,13 @@ type Data struct {
 	EmbeddedData []byte
 }
 
+// Flags of an attachment
+const (
+	EmbeddedFlag   uint16 = 1 << 0
+	CompressedFlag uint16 = 1 << 1
+	MD5ValidFlag   uint16 = 1 << 2
+)
+
 type AttFile struct {
 	Name         string
 	Type         string
@@ -60,10 +69,18 @@ type AttFile struct {
 	Path         string
 	CreatorIndex string
 	block        *Block
+
+	//file systems where an external attachment is looked for, by its
+	//relative path then by its file name
+	SearchPath []fs.FS
+
+	//reader of the MF4 file, for the embedded data
+	reader io.ReaderAt
 }
 
-func New(file *os.File, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
 	var b Block
+	b.Address = startAddress
 
 	// Seek to the start address
 	if _, err := file.Seek(startAddress, io.SeekStart); err != nil {
@@ -95,10 +112,21 @@ func New(file *os.File, startAddress int64) (*Block, error) {
 		return b.BlankBlock(), fmt.Errorf("failed to decode link block: %w", err)
 	}
 
+	// Read the fixed part of the data block, without the embedded data
+	dataBuf := make([]byte, 40)
+	if _, err := io.ReadFull(file, dataBuf); err != nil {
+		return b.BlankBlock(), fmt.Errorf("failed to read data block: %w", err)
+	}
+	b.Data.Flags = binary.LittleEndian.Uint16(dataBuf[0:2])
+	b.Data.CreatorIndex = binary.LittleEndian.Uint16(dataBuf[2:4])
+	copy(b.Data.MD5Checksum[:], dataBuf[8:24])
+	b.Data.OriginalSize = binary.LittleEndian.Uint64(dataBuf[24:32])
+	b.Data.EmbeddedSize = binary.LittleEndian.Uint64(dataBuf[32:40])
+
 	return &b, nil
 }
 
-func (b *Block) LoadAttachmentFile(file *os.File) *AttFile {
+func (b *Block) LoadAttachmentFile(file io.ReadSeeker) *AttFile {
 	var fileName string
 	var comment string
 
@@ -114,153 +142,198 @@ func (b *Block) LoadAttachmentFile(file *os.File) *AttFile {
 	}
 
 	return &AttFile{
-		Name:    fileName,
-		Type:    mimeType,
-		Comment: comment,
-		block:   b,
+		Name:         fileName,
+		Type:         mimeType,
+		Comment:      comment,
+		CreatorIndex: fmt.Sprint(b.Data.CreatorIndex),
+		block:        b,
+		reader:       readerAt(file),
 	}
 }
 
+// Creator returns the index of the file history entry that created the
+// attachment, or changed it most recently
+func (a AttFile) Creator() uint16 {
+	return a.block.Data.CreatorIndex
+}
+
 func (a AttFile) getBlock() *Block {
 	return a.block
 }
 
-func (a AttFile) Save(file *os.File, outputPath string) AttFile {
+// readerAt returns `file` as io.ReaderAt, or 'nil'
+func readerAt(file io.ReadSeeker) io.ReaderAt {
+	r, _ := file.(io.ReaderAt)
+	return r
+}
+
+// IsEmbedded checks the "embedded" flag (bit 0). Attachments that aren't
+// embedded reference an external file by its path.
+func (b *Block) IsEmbedded() bool {
+	return b.Data.Flags&EmbeddedFlag != 0
+}
+
+// IsCompressed checks the "compressed" flag (bit 1): the embedded data is
+// compressed with zlib
+func (b *Block) IsCompressed() bool {
+	return b.Data.Flags&CompressedFlag != 0
+}
+
+// IsMD5Valid checks the "MD5 check sum valid" flag (bit 2)
+func (b *Block) IsMD5Valid() bool {
+	return b.Data.Flags&MD5ValidFlag != 0
+}
+
+// embeddedDataAddress returns the address of the embedded data
+func (b *Block) embeddedDataAddress() int64 {
+	return b.Address + int64(blocks.HeaderSize) + int64(blocks.CalculateLinkSize(b.Header.LinkCount)) + 40
+}
+
+// Open returns a reader of the content of the attachment. Compressed data is
+// inflated while reading; external files are opened from the search path.
+func (a AttFile) Open() (io.ReadCloser, error) {
 	b := a.getBlock()
-	//Load data to block
-	addr := b.Address + int64(blocks.HeaderSize) + int64(blocks.CalculateLinkSize(a.block.Header.LinkCount))
-	d, err := b.loadData(file, addr)
-	if err != nil {
-		fmt.Println(err)
+	if !b.IsEmbedded() {
+		return a.openExternal()
 	}
-	flag := int(d.Flags)
-	data := d.EmbeddedData
-	t, err := TX.GetText(file, b.GetTxFilename())
-	if err != nil {
-		fmt.Println(err)
+	if a.reader == nil {
+		return nil, fmt.Errorf("attachment %s can't be read", a.Name)
 	}
 
-	a.Path = strings.ReplaceAll(t, "\\", string(os.PathSeparator))
-	filename := filepath.Base(a.Path)
-	filetype, err := TX.GetText(file, b.Link.TxMimetype)
-	if err != nil {
-		fmt.Println(err)
-	}
-	a.CreatorIndex = fmt.Sprint(d.CreatorIndex)
-	//If file has no extension, try to save it by mime
-	if filepath.Ext(filename) == "" {
-		ext, err := mime.ExtensionsByType(filetype)
-		if len(ext) > 0 {
-			if err != nil {
-				fmt.Println(err)
-			}
-			filename = filename + ext[len(ext)-1]
-		} else {
-			fmt.Printf("\n%s file unknow format", filename)
-		}
+	r := io.NewSectionReader(a.reader, b.embeddedDataAddress(), int64(b.Data.EmbeddedSize))
+	if !b.IsCompressed() {
+		return io.NopCloser(r), nil
 	}
 
-	//External File
-	if !blocks.IsBitSet(flag, 0) {
-		MdCommentAdress := b.GetMdComment()
-		if MdCommentAdress != 0 {
-			a.Comment = string(MD.New(file, MdCommentAdress))
-		}
-
-		fmt.Printf("\n%s is external, the path to the file is %s", filename, a.Path)
-		return a
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("attachment %s: %w", a.Name, err)
 	}
+	return z, nil
+}
 
-	//Embbeded file - Compressed Zip
-	if blocks.IsBitSet(flag, 1) {
-		data = decompressFile(d)
+// openExternal opens the file of an external attachment from the first file
+// system of the search path holding it
+func (a AttFile) openExternal() (io.ReadCloser, error) {
+	// paths in MDF files may use '\' as separator
+	name := strings.ReplaceAll(a.Name, "\\", "/")
+	candidates := []string{path.Base(name)}
+	if p := path.Clean(name); fs.ValidPath(p) && p != candidates[0] {
+		candidates = []string{p, candidates[0]}
 	}
 
-	//Embbeded file - MD5 check sum
-	if blocks.IsBitSet(flag, 2) {
-		if md5.Sum(data) != d.MD5Checksum {
-			fmt.Println("Checksums do not match. The file may be corrupted. File:", filename)
+	for _, fsys := range a.SearchPath {
+		for _, c := range candidates {
+			if f, err := fsys.Open(c); err == nil {
+				return f, nil
+			}
 		}
 	}
-
-	p := filepath.Join(outputPath + filename)
-	a.Path = p
-	saveFile(file, p, &data)
-	return a
+	return nil, fmt.Errorf("external attachment %s: %w", a.Name, fs.ErrNotExist)
 }
 
-// decompressFile uses zlib to decompress databyte
-func decompressFile(d *Data) []byte {
-	c := bytes.NewReader(d.EmbeddedData)
-	r, err := zlib.NewReader(c)
-	if err != nil {
-		fmt.Println(err)
-	}
-	data, err := io.ReadAll(r)
+// Verify reads the content of the attachment and checks its size and, when
+// valid, its MD5 check sum. External files are checked too.
+func (a AttFile) Verify() error {
+	r, err := a.Open()
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
-	return data
+	defer r.Close()
+
+	return a.verify(r, io.Discard)
 }
 
-// saveFile saves bytes to target file
-func saveFile(file *os.File, outputPath string, data *[]byte) error {
-	f, err := os.Create(outputPath)
+// verify copies the content `r` of the attachment to `w`, checking its size
+// and MD5 check sum
+func (a AttFile) verify(r io.Reader, w io.Writer) error {
+	b := a.getBlock()
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
 	if err != nil {
-		fmt.Println("error to create the file output: ", err)
-		return err
+		return fmt.Errorf("attachment %s: %w", a.Name, err)
 	}
-	_, err = f.Write(*data)
-	if err != nil {
-		fmt.Println("error to write data to file output: ", err)
-		return err
+
+	// the size of external files is optional
+	if (b.IsEmbedded() || b.Data.OriginalSize != 0) && uint64(n) != b.Data.OriginalSize {
+		return fmt.Errorf("attachment %s: expected %d bytes, got %d", a.Name, b.Data.OriginalSize, n)
+	}
+	if b.IsMD5Valid() && !bytes.Equal(h.Sum(nil), b.Data.MD5Checksum[:]) {
+		return fmt.Errorf("attachment %s: MD5 check sum doesn't match, the file may be corrupted", a.Name)
 	}
 	return nil
 }
 
-func (b *Block) loadData(file *os.File, adress int64) (*Data, error) {
-	_, errs := file.Seek(adress, 0)
-	if errs != nil {
-		if errs != io.EOF {
-			fmt.Println(errs, "Memory Addr out of size")
-		}
+// Save writes the content of the attachment to the directory
+// `outputPath`, checking its size and MD5 check sum. The file is named after
+// the base name of the attachment, so it can't be written outside of
+// `outputPath`; its extension is guessed from the MIME type if missing. The
+// returned attachment has the path of the written file.
+func (a AttFile) Save(file io.ReadSeeker, outputPath string) (AttFile, error) {
+	if a.reader == nil {
+		a.reader, _ = file.(io.ReaderAt)
 	}
-	//Calculates size of Data Block
-	blockSize := blocks.CalculateDataSize(b.Header.Length, b.Header.LinkCount)
-	buffEach := make([]byte, blockSize)
 
-	// Read the Link section from the binary file
-	if err := binary.Read(file, binary.LittleEndian, &buffEach); err != nil {
-		return &Data{}, fmt.Errorf("error reading link section: %v", err)
+	filename, err := a.fileName()
+	if err != nil {
+		return a, err
 	}
 
-	var fixedArray16 [16]byte
+	a.Path = filepath.Join(outputPath, filename)
+	if rel, err := filepath.Rel(outputPath, a.Path); err != nil || rel != filename {
+		return a, fmt.Errorf("attachment %s: invalid file name %s", a.Name, filename)
+	}
 
-	d := Data{}
-	d.Flags = binary.LittleEndian.Uint16(buffEach[0:2])
-	d.CreatorIndex = binary.LittleEndian.Uint16(buffEach[2:4])
+	r, err := a.Open()
+	if err != nil {
+		return a, err
+	}
+	defer r.Close()
+
+	out, err := os.Create(a.Path)
+	if err != nil {
+		return a, err
+	}
+
+	err = a.verify(r, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(a.Path)
+		return a, err
+	}
+	return a, nil
+}
 
-	//md5CheckSum
-	md5CheckSum := buffEach[8:24]
-	copy(fixedArray16[:], md5CheckSum[:])
-	d.MD5Checksum = fixedArray16
+// fileName returns the base name of the attachment, with an extension
+// guessed from the MIME type if missing
+func (a AttFile) fileName() (string, error) {
+	// paths in MDF files may use '\' as separator
+	name := filepath.Base(strings.ReplaceAll(a.Name, "\\", "/"))
+	if name == "." || name == ".." || name == "/" || name == "" {
+		return "", fmt.Errorf("attachment %s has no valid file name", a.Name)
+	}
 
-	d.OriginalSize = binary.LittleEndian.Uint64(buffEach[24:32])
-	d.EmbeddedSize = binary.LittleEndian.Uint64(buffEach[32:40])
-	d.EmbeddedData = buffEach[40:]
-	return &d, nil
+	if filepath.Ext(name) == "" {
+		if ext, _ := mime.ExtensionsByType(a.Type); len(ext) > 0 {
+			name += ext[len(ext)-1]
+		}
+	}
+	return name, nil
 }
 
-func Get(f *os.File, a int64) ([]AttFile, error) {
-	var fileName, comm string
+func Get(f io.ReadSeeker, a int64) ([]AttFile, error) {
 	i := 0
 	arr := make([]AttFile, 0)
 	for a != 0 {
 		atBlock, err := New(f, a)
 		if err != nil {
-			return arr, nil
+			return arr, err
 		}
 
+		var fileName, comm string
+
 		if atBlock.GetTxFilename() != 0 {
 			fileName = atBlock.GetFileName(f, atBlock.GetTxFilename())
 		} else {
@@ -277,10 +350,12 @@ func Get(f *os.File, a int64) ([]AttFile, error) {
 		}
 
 		arr = append(arr, AttFile{
-			Name:    fileName,
-			Type:    mimeType,
-			Comment: comm,
-			block:   atBlock,
+			Name:         fileName,
+			Type:         mimeType,
+			Comment:      comm,
+			CreatorIndex: fmt.Sprint(atBlock.Data.CreatorIndex),
+			block:        atBlock,
+			reader:       readerAt(f),
 		})
 		a = atBlock.Next()
 	}
@@ -295,7 +370,7 @@ func (b *Block) GetTxMimeType() int64 {
 	return b.Link.TxMimetype
 }
 
-func GetTextString(file *os.File, a int64) string {
+func GetTextString(file io.ReadSeeker, a int64) string {
 	t, err := TX.GetText(file, a)
 	if err != nil {
 		return ""
@@ -304,11 +379,11 @@ func GetTextString(file *os.File, a int64) string {
 	return t
 }
 
-func (b *Block) GetFileName(file *os.File, a int64) string {
+func (b *Block) GetFileName(file io.ReadSeeker, a int64) string {
 	return GetTextString(file, a)
 }
 
-func (b *Block) GetMimeType(file *os.File, a int64) string {
+func (b *Block) GetMimeType(file io.ReadSeeker, a int64) string {
 	return GetTextString(file, a)
 }
 
@@ -1,7 +1,7 @@
 package CA
 
 import (
-	"os"
+	"io"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -35,7 +35,7 @@ type Data struct {
 	CycleCount      []uint64
 }
 
-func New(file *os.File, startAdress int64) *Block {
+func New(file io.ReadSeeker, startAdress int64) *Block {
 	var b Block
 	var err error
 
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"os"
 	"sort"
 
 	"github.com/LincolnG4/GoMDF/blocks"
@@ -122,7 +121,7 @@ type BitfieldText struct {
 	Links   []float64
 }
 
-func New(file *os.File, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
 	var b Block
 
 	// Initialize the header
@@ -197,7 +196,7 @@ func New(file *os.File, startAddress int64) (*Block, error) {
 }
 
 // Get returns an conversion struct type
-func (b *Block) Get(file *os.File, channelType uint8) (Conversion, error) {
+func (b *Block) Get(file io.ReadSeeker, channelType uint8) (Conversion, error) {
 	switch b.dataType() {
 	case blocks.CcNoConversion:
 		return nil, nil
@@ -227,7 +226,7 @@ func (b *Block) Get(file *os.File, channelType uint8) (Conversion, error) {
 }
 
 // GetLinear returns linear conversion struct type
-func (b *Block) GetLinear(file *os.File) (Conversion, error) {
+func (b *Block) GetLinear(file io.ReadSeeker) (Conversion, error) {
 	v := b.getVal()
 
 	return &Linear{
@@ -238,7 +237,7 @@ func (b *Block) GetLinear(file *os.File) (Conversion, error) {
 }
 
 // GetVVInterporlation returns value to value tabular look-up with interpolation
-func (b *Block) GetValueToValue(file *os.File) (Conversion, error) {
+func (b *Block) GetValueToValue(file io.ReadSeeker) (Conversion, error) {
 	v := b.getVal()
 	key, value := createKeyValueFloat64(&v)
 	return &ValueValue{
@@ -250,7 +249,7 @@ func (b *Block) GetValueToValue(file *os.File) (Conversion, error) {
 }
 
 // GetVVInterporlation returns value to value tabular look-up with interpolation
-func (b *Block) GetValueRangeToValue(file *os.File, channelType uint8) (Conversion, error) {
+func (b *Block) GetValueRangeToValue(file io.ReadSeeker, channelType uint8) (Conversion, error) {
 	v := b.getVal()
 	keyMin, keyMax, value, def := createKeyMinMaxValue(&v)
 	return &ValueRangeToValue{
@@ -264,7 +263,7 @@ func (b *Block) GetValueRangeToValue(file *os.File, channelType uint8) (Conversi
 }
 
 // GetRational returns rational conversion struct type
-func (b *Block) GetRational(file *os.File) (Conversion, error) {
+func (b *Block) GetRational(file io.ReadSeeker) (Conversion, error) {
 	v := b.getVal()
 
 	return &Rational{
@@ -278,7 +277,7 @@ func (b *Block) GetRational(file *os.File) (Conversion, error) {
 	}, nil
 }
 
-func (b *Block) GetAlgebraic(file *os.File) (Conversion, error) {
+func (b *Block) GetAlgebraic(file io.ReadSeeker) (Conversion, error) {
 	formula, err := b.refToString(file)
 	if err != nil {
 		return nil, err
@@ -289,7 +288,7 @@ func (b *Block) GetAlgebraic(file *os.File) (Conversion, error) {
 	}, nil
 }
 
-func (b *Block) GetValueToText(file *os.File) (Conversion, error) {
+func (b *Block) GetValueToText(file io.ReadSeeker) (Conversion, error) {
 	v := b.getVal()
 	t, err := b.refToString(file)
 	if err != nil {
@@ -298,12 +297,12 @@ func (b *Block) GetValueToText(file *os.File) (Conversion, error) {
 	return &ValueText{
 		Info:    b.getInfo(file),
 		Keys:    v,
-		Links:   t[:len(t)-2],
+		Links:   t[:len(t)-1],
 		Default: t[len(t)-1],
 	}, nil
 }
 
-func (b *Block) GetValueRangeToText(file *os.File, channelType uint8) (Conversion, error) {
+func (b *Block) GetValueRangeToText(file io.ReadSeeker, channelType uint8) (Conversion, error) {
 	v := b.getVal()
 	min, max := createKeyValueFloat64(&v)
 	t, err := b.refToString(file)
@@ -314,13 +313,13 @@ func (b *Block) GetValueRangeToText(file *os.File, channelType uint8) (Conversio
 		Info:     b.getInfo(file),
 		KeyMin:   min,
 		KeyMax:   max,
-		Links:    t[:len(t)-2],
+		Links:    t[:len(t)-1],
 		Default:  t[len(t)-1],
 		DataType: channelType,
 	}, nil
 }
 
-func (b *Block) GetTextToValue(file *os.File) (Conversion, error) {
+func (b *Block) GetTextToValue(file io.ReadSeeker) (Conversion, error) {
 	v := b.getVal()
 	t, err := b.refToString(file)
 	if err != nil {
@@ -335,7 +334,7 @@ func (b *Block) GetTextToValue(file *os.File) (Conversion, error) {
 	}, nil
 }
 
-func (b *Block) GetTextToText(file *os.File) (Conversion, error) {
+func (b *Block) GetTextToText(file io.ReadSeeker) (Conversion, error) {
 	t, err := b.refToString(file)
 	if err != nil {
 		return nil, err
@@ -352,7 +351,7 @@ func (b *Block) GetTextToText(file *os.File) (Conversion, error) {
 	}, nil
 }
 
-func (b *Block) GetBitfield(file *os.File) (Conversion, error) {
+func (b *Block) GetBitfield(file io.ReadSeeker) (Conversion, error) {
 	v := b.getVal()
 	t, err := b.refToString(file)
 	if err != nil {
@@ -412,28 +411,14 @@ func (vt *ValueText) Apply(sample *[]interface{}) {
 
 	for i, v := range s {
 		c := convertToFloat64(v)
+		link := vt.Default
 		for j := 0; j < len(vt.Keys); j++ {
 			if c == vt.Keys[j] {
-				switch v := vt.Links[j].(type) {
-				case string:
-					s[i] = vt.Links[j]
-				case Conversion:
-					a := []interface{}{c}
-					v.Apply(&a)
-					s[i] = a[0]
-				}
+				link = vt.Links[j]
 				break
 			}
-
-			switch v := vt.Default.(type) {
-			case string:
-				s[i] = vt.Default
-			case Conversion:
-				a := []interface{}{c}
-				v.Apply(&a)
-				s[i] = a[0]
-			}
 		}
+		s[i] = applyLink(link, v, c)
 	}
 }
 
@@ -455,32 +440,33 @@ func (vt *ValueRangeToText) Apply(sample *[]interface{}) {
 	}
 
 	for i, v := range s {
-		c := convertToFloat64(v)
+		c = convertToFloat64(v)
 		index := sort.Search(n, f)
 
 		if index != n && c >= vt.KeyMin[index] {
-			switch v := vt.Links[index].(type) {
-			case string:
-				s[i] = v
-			case Conversion:
-				a := []interface{}{c}
-				v.Apply(&a)
-				s[i] = a[0]
-			}
-
+			s[i] = applyLink(vt.Links[index], v, c)
 		} else {
-			switch v := vt.Default.(type) {
-			case string:
-				s[i] = v
-			case Conversion:
-				a := []interface{}{c}
-				v.Apply(&a)
-				s[i] = a[0]
-			}
+			s[i] = applyLink(vt.Default, v, c)
 		}
 	}
 }
 
+// applyLink returns the text referenced by a tabular conversion, or the value
+// converted by it when the reference is a nested conversion. Without
+// reference, the raw value `v` is kept.
+func applyLink(link interface{}, v interface{}, c float64) interface{} {
+	switch l := link.(type) {
+	case string:
+		return l
+	case Conversion:
+		a := []interface{}{c}
+		l.Apply(&a)
+		return a[0]
+	default:
+		return v
+	}
+}
+
 func (vv *ValueValue) Apply(sample *[]interface{}) {
 	if vv.Type == blocks.CcVVLookUpInterpolation {
 		vv.withInterpolation(sample)
@@ -706,7 +692,7 @@ func createKeyMinMaxValue(val *[]float64) ([]float64, []float64, []float64, floa
 	return keyMin, keyMax, vals, def
 }
 
-func (b *Block) getInfo(file *os.File) Info {
+func (b *Block) getInfo(file io.ReadSeeker) Info {
 	return Info{
 		Name:    b.name(file),
 		Unit:    b.unit(file),
@@ -714,13 +700,18 @@ func (b *Block) getInfo(file *os.File) Info {
 	}
 }
 
-func (b *Block) refToString(file *os.File) ([]interface{}, error) {
+func (b *Block) refToString(file io.ReadSeeker) ([]interface{}, error) {
 	var result interface{}
 
 	ref := b.getRef()
 	r := make([]interface{}, 0)
 
 	for i := 0; i < len(ref); i++ {
+		if ref[i] == 0 {
+			r = append(r, nil)
+			continue
+		}
+
 		header, err := blocks.GetBlockType(file, ref[i])
 		if err != nil {
 			return nil, err
@@ -768,7 +759,7 @@ func (b *Block) getRef() []int64 {
 	return b.Link.Ref
 }
 
-func (b *Block) name(file *os.File) string {
+func (b *Block) name(file io.ReadSeeker) string {
 	if b.Link.TxName == 0 {
 		return ""
 	}
@@ -781,7 +772,7 @@ func (b *Block) name(file *os.File) string {
 	return t
 }
 
-func (b *Block) unit(file *os.File) string {
+func (b *Block) unit(file io.ReadSeeker) string {
 	if b.Link.MdUnit == 0 {
 		return ""
 	}
@@ -794,7 +785,7 @@ func (b *Block) unit(file *os.File) string {
 	return t
 }
 
-func (b *Block) comment(file *os.File) string {
+func (b *Block) comment(file io.ReadSeeker) string {
 	if b.Link.MdComment == 0 {
 		return ""
 	}
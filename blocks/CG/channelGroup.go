@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -52,7 +51,14 @@ type Data struct {
 
 const blockID string = blocks.CgID
 
-func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
+// Flags of a channel group
+const (
+	VLSDFlag          uint16 = 1 << 0
+	BusEventFlag      uint16 = 1 << 1
+	PlainBusEventFlag uint16 = 1 << 2
+)
+
+func New(file io.ReadSeeker, version uint16, startAddress int64) (*Block, error) {
 	var b Block
 
 	// Initialize the header
@@ -90,10 +96,9 @@ func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
 		MdComment:   linkFields[5],
 	}
 
-	if version >= blocks.Version420 {
-		// Read additional field for versions >= 420
-		masterField := blocks.ReadInt64FromBinary(file)
-		b.Link.CgMaster = masterField
+	// cg_cg_master is only present for versions >= 420
+	if version >= blocks.Version420 && len(linkFields) > 6 {
+		b.Link.CgMaster = linkFields[6]
 	}
 
 	// Calculate size of Data Block
@@ -165,6 +170,33 @@ func (b *Block) Type(version uint16) []string {
 	return t
 }
 
+// IsBusEvent returns `true` if the records of the group are bus events, for
+// instance CAN frames of a bus logging file (MDF 4.1)
+func (b *Block) IsBusEvent() bool {
+	return blocks.IsBitSet(int(b.getFlag()), 1)
+}
+
+// IsPlainBusEvent returns `true` if the bus events of the group are stored as
+// plain channels instead of a structure of channels
+func (b *Block) IsPlainBusEvent() bool {
+	return b.IsBusEvent() && blocks.IsBitSet(int(b.getFlag()), 2)
+}
+
+// IsRemoteMaster returns `true` if the master channel of the group is located
+// in another channel group, referenced by cg_cg_master
+func (b *Block) IsRemoteMaster() bool {
+	return blocks.IsBitSet(int(b.getFlag()), 3) && b.Link.CgMaster != 0
+}
+
+// RemoteMaster returns the address of the channel group holding the master
+// channel, or 0 if the group uses its own master
+func (b *Block) RemoteMaster() int64 {
+	if !b.IsRemoteMaster() {
+		return 0
+	}
+	return b.Link.CgMaster
+}
+
 func (b *Block) PathSeparator() string {
 	return string(rune(b.Data.PathSeparator))
 }
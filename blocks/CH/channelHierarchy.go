@@ -0,0 +1,153 @@
+package CH
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+)
+
+type Block struct {
+	Header blocks.Header
+	Link   Link
+	Data   Data
+}
+
+type Link struct {
+	Next      int64
+	ChFirst   int64
+	TxName    int64
+	MdComment int64
+
+	//references to the elements of the hierarchy, as DGBLOCK, CGBLOCK and
+	//CNBLOCK address triples
+	Elements []Element
+}
+
+// Element is a reference to a channel: the addresses of its DGBLOCK, CGBLOCK
+// and CNBLOCK
+type Element struct {
+	DataGroup    int64
+	ChannelGroup int64
+	Channel      int64
+}
+
+type Data struct {
+	ElementCount uint32
+	Type         uint8
+	Reserved     [3]byte
+}
+
+// Hierarchy types
+const (
+	// group of objects with no specific semantics
+	Group uint8 = iota
+	// function of an ECU
+	Function
+	// structure of channels, for instance a C struct
+	Structure
+	// map list, the channels of a calibration map
+	MapList
+	// input variables of a function
+	InputVariables
+	// output variables of a function
+	OutputVariables
+	// local variables of a function
+	LocalVariables
+	// calibration objects defined in a function
+	CalibrationDefinition
+	// calibration objects referenced in a function
+	CalibrationReference
+)
+
+var TypeMap map[uint8]string = map[uint8]string{
+	Group:                 "GROUP",
+	Function:              "FUNCTION",
+	Structure:             "STRUCTURE",
+	MapList:               "MAP_LIST",
+	InputVariables:        "INPUT_VARIABLES",
+	OutputVariables:       "OUTPUT_VARIABLES",
+	LocalVariables:        "LOCAL_VARIABLES",
+	CalibrationDefinition: "CALIBRATION_DEFINITION",
+	CalibrationReference:  "CALIBRATION_REFERENCE",
+}
+
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
+	var b Block
+
+	// Seek to the start address
+	if _, err := file.Seek(startAddress, io.SeekStart); err != nil {
+		return b.BlankBlock(), fmt.Errorf("failed to seek to address %d: %w", startAddress, err)
+	}
+
+	// Read and decode the header
+	headerBuf := make([]byte, blocks.HeaderSize)
+	if _, err := io.ReadFull(file, headerBuf); err != nil {
+		return b.BlankBlock(), fmt.Errorf("failed to read header: %w", err)
+	}
+	if err := binary.Read(bytes.NewReader(headerBuf), binary.LittleEndian, &b.Header); err != nil {
+		return b.BlankBlock(), fmt.Errorf("failed to decode header: %w", err)
+	}
+
+	// Validate block ID
+	if string(b.Header.ID[:]) != blocks.ChID {
+		return b.BlankBlock(), fmt.Errorf("invalid block ID: expected %s, got %s", blocks.ChID, b.Header.ID)
+	}
+
+	// Read the link block
+	links := make([]int64, b.Header.LinkCount)
+	if err := binary.Read(file, binary.LittleEndian, links); err != nil {
+		return b.BlankBlock(), fmt.Errorf("failed to read link block: %w", err)
+	}
+
+	// Read and decode the data block
+	if err := binary.Read(file, binary.LittleEndian, &b.Data); err != nil {
+		return b.BlankBlock(), fmt.Errorf("failed to decode data block: %w", err)
+	}
+
+	elementsEnd := 4 + 3*int(b.Data.ElementCount)
+	if len(links) < elementsEnd {
+		return b.BlankBlock(), fmt.Errorf("expected %d links, got %d", elementsEnd, len(links))
+	}
+
+	b.Link = Link{
+		Next:      links[0],
+		ChFirst:   links[1],
+		TxName:    links[2],
+		MdComment: links[3],
+		Elements:  make([]Element, b.Data.ElementCount),
+	}
+	for i := range b.Link.Elements {
+		e := links[4+3*i:]
+		b.Link.Elements[i] = Element{DataGroup: e[0], ChannelGroup: e[1], Channel: e[2]}
+	}
+
+	return &b, nil
+}
+
+func (b *Block) BlankBlock() *Block {
+	return &Block{
+		Header: blocks.Header{
+			ID:        blocks.SplitIdToArray(blocks.ChID),
+			Reserved:  [4]byte{},
+			Length:    blocks.HeaderSize + 4*blocks.LinkSize + 8,
+			LinkCount: 4,
+		},
+		Link: Link{},
+		Data: Data{},
+	}
+}
+
+// Type returns the type of the hierarchy level, for instance "FUNCTION"
+func (b *Block) Type() string {
+	if t, ok := TypeMap[b.Data.Type]; ok {
+		return t
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", b.Data.Type)
+}
+
+func (b *Block) Next() int64 {
+	return b.Link.Next
+}
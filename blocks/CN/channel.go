@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 	"slices"
 
 	"github.com/LincolnG4/GoMDF/blocks"
@@ -28,9 +27,9 @@ type Link struct {
 	Data         int64
 	MdUnit       int64
 	MdComment    int64
-	//Version 4.1
-	AtReference int64
-	//Version 4.1
+	//Version 4.1, links to the ATBLOCKs referenced by the channel
+	AtReference []int64
+	//Version 4.1, DG, CG and CN of the default X axis
 	DefaultX [3]int64
 }
 
@@ -91,7 +90,13 @@ const (
 	VirtualData
 )
 
-func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
+// Sync Type
+const (
+	//time master, in seconds
+	TimeSync uint8 = 1
+)
+
+func New(file io.ReadSeeker, version uint16, startAddress int64) (*Block, error) {
 	var b Block
 	var err error
 
@@ -117,11 +122,6 @@ func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
 		linkFields[i] = int64(binary.LittleEndian.Uint64(linkBuffer[i*8 : (i+1)*8]))
 	}
 
-	// Handle version-specific Link fields
-	if version >= 420 {
-		linkFields = append(linkFields, blocks.ReadInt64FromBinary(file))
-	}
-
 	// Populate Link struct fields
 	b.Link = Link{
 		Next:         linkFields[0],
@@ -147,13 +147,18 @@ func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
 	}
 
 	// Handle version-specific fields if version >= 4.10
-	if version >= 410 {
-		for i := 0; i < int(b.Data.AttachmentCount); i++ {
-			b.Link.AtReference = linkFields[8]
+	if version >= blocks.Version410 {
+		attachmentEnd := 8 + int(b.Data.AttachmentCount)
+		if len(linkFields) < attachmentEnd {
+			return b.BlankBlock(), fmt.Errorf("expected %d links, got %d", attachmentEnd, len(linkFields))
+		}
+		if b.Data.AttachmentCount > 0 {
+			b.Link.AtReference = linkFields[8:attachmentEnd]
 		}
 
-		if b.Data.Flags == 12 {
-			b.Link.DefaultX = [3]int64{linkFields[9], linkFields[10], linkFields[11]}
+		// default X flag
+		if blocks.IsBitSet(int(b.Data.Flags), 12) && len(linkFields) >= attachmentEnd+3 {
+			copy(b.Link.DefaultX[:], linkFields[attachmentEnd:attachmentEnd+3])
 		}
 	}
 
@@ -162,7 +167,7 @@ func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
 
 // Conversion return Conversion structs that hold the formula to convert
 // raw sample to desired value.
-func (b *Block) Conversion(file *os.File, channelDataType uint8) (CC.Conversion, error) {
+func (b *Block) Conversion(file io.ReadSeeker, channelDataType uint8) (CC.Conversion, error) {
 	cc, err := b.NewConversion(file)
 	if err != nil {
 		return nil, err
@@ -174,7 +179,7 @@ func (b *Block) Conversion(file *os.File, channelDataType uint8) (CC.Conversion,
 }
 
 // NewConversion create a new CCBlock according to the Link.CcConvertion field.
-func (b *Block) NewConversion(file *os.File) (*CC.Block, error) {
+func (b *Block) NewConversion(file io.ReadSeeker) (*CC.Block, error) {
 	if b.Link.CcConvertion == 0 {
 		return nil, nil
 	}
@@ -234,6 +239,12 @@ func (b *Block) LoadDataType(lenSize int) interface{} {
 	return dtype
 }
 
+// IsNumeric returns `true` if the channel data type is an integer or a
+// floating-point number
+func (b *Block) IsNumeric() bool {
+	return b.DataType() <= IEEE754FloatBE
+}
+
 func LittleEndianArray() []int {
 	return []int{0, 2, 4, 8, 15}
 }
@@ -281,9 +292,42 @@ func (b *Block) IsVLSD() bool {
 	return b.IotaType() == VLSD
 }
 
-// IsVLSD returns `true` if channel is the master. Otherwise it returns `false`
+// IsMaster returns `true` if channel is the master or the virtual master.
+// Otherwise it returns `false`
 func (b *Block) IsMaster() bool {
-	return b.IotaType() == Master
+	return b.IotaType() == Master || b.IotaType() == VirtualMaster
+}
+
+// IsVirtualMaster returns `true` if the master values are not stored in the
+// record but computed from the record index. Otherwise it returns `false`
+func (b *Block) IsVirtualMaster() bool {
+	return b.IotaType() == VirtualMaster
+}
+
+// IsSynchronization returns `true` if channel is a synchronization channel
+// pointing to an external stream (cn_data references an ATBLOCK). Otherwise it
+// returns `false`
+func (b *Block) IsSynchronization() bool {
+	return b.IotaType() == Synchronization
+}
+
+// SyncDomain returns the synchronization domain of the channel
+//
+// - NONE: no master and no synchronization channel
+//
+// - TIME: physical values in seconds
+//
+// - ANGLE: physical values in radians
+//
+// - DISTANCE: physical values in meters
+//
+// - INDEX: physical values are zero-based indexes
+func (b *Block) SyncDomain() string {
+	d, ok := blocks.SyncTypeMap[b.SyncType()]
+	if !ok {
+		return blocks.NoSyncDomain
+	}
+	return d
 }
 
 func (b *Block) Type() string {
@@ -307,7 +351,7 @@ func (b *Block) Type() string {
 	}
 }
 
-func (b *Block) ChannelName(f *os.File) string {
+func (b *Block) ChannelName(f io.ReadSeeker) string {
 	t, err := TX.GetText(f, b.TxName())
 	if err != nil {
 		return ""
@@ -3,7 +3,7 @@ package DG
 import (
 	"encoding/binary"
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -27,7 +27,7 @@ type Data struct {
 	Reserved  [7]byte
 }
 
-func New(file *os.File, startAddress int64) *Block {
+func New(file io.ReadSeeker, startAddress int64) *Block {
 	var b Block
 
 	// Initialize header
@@ -61,7 +61,7 @@ func New(file *os.File, startAddress int64) *Block {
 
 // BytesOfRecordIDSize returns number of Bytes used for record IDs in the data
 // block.
-func (b *Block) BytesOfRecordIDSize(f *os.File, buf []byte) (uint64, error) {
+func (b *Block) BytesOfRecordIDSize(f io.ReadSeeker, buf []byte) (uint64, error) {
 	switch b.RecordIDSize() {
 	case 0:
 		return 0, nil // Sorted record
@@ -3,7 +3,7 @@ package DL
 import (
 	"encoding/binary"
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 	"github.com/LincolnG4/GoMDF/blocks/DT"
@@ -39,7 +39,7 @@ const (
 	Distance
 )
 
-func New(file *os.File, version uint16, startAdress int64) (*Block, error) {
+func New(file io.ReadSeeker, version uint16, startAdress int64) (*Block, error) {
 	var b Block
 	var err error
 
@@ -97,7 +97,8 @@ func New(file *os.File, version uint16, startAdress int64) (*Block, error) {
 		}
 	} else {
 		// Only present if "equal length" flag (bit 0 in dl_flags) is not set.
-		err = binary.Read(buf, binary.LittleEndian, &b.Data.Offset)
+		b.Data.Offset = make([]uint64, b.Data.Count)
+		err = binary.Read(buf, binary.LittleEndian, b.Data.Offset)
 		if err != nil {
 			return b.BlankBlock(), err
 		}
@@ -109,10 +110,11 @@ func New(file *os.File, version uint16, startAdress int64) (*Block, error) {
 
 	// iterate over all fields and extract if bit is set
 	var flagsArray [3]int = [3]int{Time, Angle, Distance}
-	copy := [3]*[]float64{&b.Data.TimeValues, &b.Data.AngleValues, &b.Data.DistanceValues}
-	for index, field := range copy {
+	values := [3]*[]float64{&b.Data.TimeValues, &b.Data.AngleValues, &b.Data.DistanceValues}
+	for index, field := range values {
 		if blocks.IsBitSet(int(b.Data.Flags), flagsArray[index]) {
-			err = binary.Read(buf, binary.LittleEndian, &field)
+			*field = make([]float64, b.Data.Count)
+			err = binary.Read(buf, binary.LittleEndian, *field)
 			if err != nil {
 				return b.BlankBlock(), err
 			}
@@ -122,7 +124,7 @@ func New(file *os.File, version uint16, startAdress int64) (*Block, error) {
 	return &b, nil
 }
 
-func (b *Block) Concatenate(file *os.File) (*DT.Block, error) {
+func (b *Block) Concatenate(file io.ReadSeeker) (*DT.Block, error) {
 	samples := make([]byte, 0)
 	for i := 0; i < int(b.Data.Count)-1; i++ {
 		dt, err := DT.New(file, b.Link.Data[i])
@@ -151,6 +153,22 @@ func (b *Block) DataSectionLength(variableOffsetIndex int) uint64 {
 	return b.Data.EqualLength / 16
 }
 
+// MasterValues returns the master value of the first record of each data
+// block in the sync domain given by the flag (Time, Angle or Distance). It
+// returns 'nil' if the list doesn't store values for this domain.
+func (b *Block) MasterValues(flag int) []float64 {
+	switch flag {
+	case Time:
+		return b.Data.TimeValues
+	case Angle:
+		return b.Data.AngleValues
+	case Distance:
+		return b.Data.DistanceValues
+	default:
+		return nil
+	}
+}
+
 func (b *Block) DataBlockType() string {
 	return string(b.Header.ID[:])
 }
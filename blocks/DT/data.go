@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -15,7 +14,7 @@ type Block struct {
 	Data   []byte
 }
 
-func New(file *os.File, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
 	var b Block
 
 	// Seek to the start address
@@ -4,7 +4,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -72,7 +71,24 @@ const (
 	DataLengthSize        = 8
 )
 
-func New(file *os.File, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
+	b, err := Info(file, startAddress)
+	if err != nil {
+		return b, err
+	}
+
+	buf := make([]byte, b.Data.DataLenght)
+	if _, err := io.ReadFull(file, buf); err != nil {
+		return b.BlankBlock(), fmt.Errorf("error reading header: %w", err)
+	}
+	b.Data.Data = buf
+
+	return b, nil
+}
+
+// Info reads the header and the fixed data fields of the DZBLOCK, without
+// loading the compressed data. The file is left at the start of dz_data.
+func Info(file io.ReadSeeker, startAddress int64) (*Block, error) {
 	var b Block
 
 	// Seek to the start address
@@ -109,12 +125,6 @@ func New(file *os.File, startAddress int64) (*Block, error) {
 		DataLenght:    binary.LittleEndian.Uint64(buf[DataLengthOffset : DataLengthOffset+DataLengthSize]),
 	}
 
-	buf = make([]byte, b.Data.DataLenght)
-	if _, err := io.ReadFull(file, buf); err != nil {
-		return b.BlankBlock(), fmt.Errorf("error reading header: %w", err)
-	}
-	b.Data.Data = buf
-
 	return &b, nil
 }
 
@@ -4,7 +4,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 	"github.com/LincolnG4/GoMDF/blocks/MD"
@@ -50,6 +49,22 @@ type Event struct {
 	Block    *Block
 	Previous *Block
 	Cause    string
+
+	//event type, see TypeMap
+	Type string
+
+	//POINT, BEGIN or END of a range
+	RangeType string
+
+	//sync domain of the position: TIME, ANGLE, DISTANCE or INDEX
+	SyncType string
+
+	//position of the event in its sync domain: SyncBaseValue * SyncFactor,
+	//in seconds, radians, meters or as record index
+	Position float64
+
+	//name of the group of the event (version 4.2)
+	GroupName string
 }
 
 const (
@@ -65,6 +80,66 @@ const (
 	USER
 )
 
+var CauseMap map[uint8]string = map[uint8]string{
+	OTHER:  "OTHER",
+	ERROR:  "ERROR",
+	TOOL:   "TOOL",
+	SCRIPT: "SCRIPT",
+	USER:   "USER",
+}
+
+// Event types
+const (
+	//recording period, a range of events
+	Recording uint8 = iota
+	//recording was interrupted
+	RecordingInterrupt
+	//data acquisition was interrupted
+	AcquisitionInterrupt
+	//start of recording was triggered
+	StartRecordingTrigger
+	//stop of recording was triggered
+	StopRecordingTrigger
+	//generic trigger
+	Trigger
+	//marker set by the user or a tool
+	Marker
+)
+
+var TypeMap map[uint8]string = map[uint8]string{
+	Recording:             "RECORDING",
+	RecordingInterrupt:    "RECORDING_INTERRUPT",
+	AcquisitionInterrupt:  "ACQUISITION_INTERRUPT",
+	StartRecordingTrigger: "START_RECORDING_TRIGGER",
+	StopRecordingTrigger:  "STOP_RECORDING_TRIGGER",
+	Trigger:               "TRIGGER",
+	Marker:                "MARKER",
+}
+
+// Range types
+const (
+	//event defines a point
+	Point uint8 = iota
+	//event is the beginning of a range
+	BeginRange
+	//event is the end of a range, linked to its beginning
+	EndRange
+)
+
+var RangeTypeMap map[uint8]string = map[uint8]string{
+	Point:      "POINT",
+	BeginRange: "BEGIN",
+	EndRange:   "END",
+}
+
+// decode returns the name of `v` in `m`, or UNKNOWN
+func decode(m map[uint8]string, v uint8) string {
+	if s, ok := m[v]; ok {
+		return s
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", v)
+}
+
 func (b *Block) getID() [4]byte {
 	return b.Header.ID
 }
@@ -75,7 +150,7 @@ func (b *Block) getLinkCount() uint64 {
 
 // Creates a new Block struct and initializes it by reading data from
 // the provided file.
-func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, version uint16, startAddress int64) (*Block, error) {
 	var b Block
 	blockID := blocks.EvID
 
@@ -100,8 +175,18 @@ func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
 		return b.BlankBlock(), fmt.Errorf("error reading link section: %w", err)
 	}
 
+	// The number of scope and attachment links is stored in the data section
+	if err := b.readData(file); err != nil {
+		return b.BlankBlock(), fmt.Errorf("error reading data section: %w", err)
+	}
+
 	// Process the link block
 	linkFields := extractLinkFields(linkBytes)
+	scopeEnd := 5 + int(b.Data.ScopeCount)
+	attachmentEnd := scopeEnd + int(b.Data.AttachmentCount)
+	if len(linkFields) < attachmentEnd {
+		return b.BlankBlock(), fmt.Errorf("expected %d links, got %d", attachmentEnd, len(linkFields))
+	}
 
 	// Assign extracted data to Link
 	b.Link = Link{
@@ -114,19 +199,15 @@ func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
 
 	// Handle Scope and Attachment references
 	if b.Data.ScopeCount > 0 {
-		b.Link.Scope = linkFields[5 : 5+b.Data.ScopeCount]
+		b.Link.Scope = linkFields[5:scopeEnd]
 	}
 	if b.Data.AttachmentCount > 0 {
-		b.Link.ATReference = linkFields[5+b.Data.ScopeCount : 5+b.Data.ScopeCount+uint32(b.Data.AttachmentCount)]
+		b.Link.ATReference = linkFields[scopeEnd:attachmentEnd]
 	}
 
 	// Handle version-specific fields
-	if b.Data.Flags == 1 && version >= blocks.Version420 {
-		if extraField := blocks.ReadInt64FromBinary(file); err == nil {
-			b.Link.TxGroupName = extraField
-		} else {
-			return b.BlankBlock(), fmt.Errorf("error reading TxGroupName: %w", err)
-		}
+	if version >= blocks.Version420 && len(linkFields) > attachmentEnd {
+		b.Link.TxGroupName = linkFields[attachmentEnd]
 	}
 
 	return &b, nil
@@ -142,7 +223,7 @@ func extractLinkFields(linkBytes []byte) []int64 {
 	return linkFields
 }
 
-func (b *Block) readHeader(file *os.File) error {
+func (b *Block) readHeader(file io.ReadSeeker) error {
 	blockSize := blocks.HeaderSize
 	buf := blocks.LoadBuffer(file, blockSize)
 	err := binary.Read(buf, binary.LittleEndian, &b.Header)
@@ -152,7 +233,7 @@ func (b *Block) readHeader(file *os.File) error {
 	return nil
 }
 
-func (b *Block) readLink(file *os.File) ([]byte, error) {
+func (b *Block) readLink(file io.ReadSeeker) ([]byte, error) {
 	linkCount := b.getLinkCount()
 	blockSize := blocks.CalculateLinkSize(linkCount)
 	buffEach := make([]byte, blockSize)
@@ -162,7 +243,7 @@ func (b *Block) readLink(file *os.File) ([]byte, error) {
 	return buffEach, nil
 }
 
-func (b *Block) readData(file *os.File) error {
+func (b *Block) readData(file io.ReadSeeker) error {
 	blockSize := blocks.CalculateDataSize(b.Header.Length, b.Header.LinkCount)
 	buf := blocks.LoadBuffer(file, blockSize)
 	// Create a buffer based on block size
@@ -172,7 +253,7 @@ func (b *Block) readData(file *os.File) error {
 	return nil
 }
 
-func (b *Block) Load(mf4File *os.File) *Event {
+func (b *Block) Load(mf4File io.ReadSeeker) *Event {
 	var n, c string
 	var err error
 
@@ -187,13 +268,40 @@ func (b *Block) Load(mf4File *os.File) *Event {
 		c = MD.New(mf4File, b.Link.MdComment)
 	}
 
+	var g string
+	if b.IsGroupNameValid() && b.Link.TxGroupName != 0 {
+		g, _ = TX.GetText(mf4File, b.Link.TxGroupName)
+	}
+
 	return &Event{
-		Name:    n,
-		Comment: c,
-		Block:   b,
+		Name:      n,
+		Comment:   c,
+		Block:     b,
+		Cause:     decode(CauseMap, b.Data.Cause),
+		Type:      decode(TypeMap, b.Data.Type),
+		RangeType: decode(RangeTypeMap, b.Data.RangeType),
+		SyncType:  decode(blocks.SyncTypeMap, b.Data.SyncType),
+		Position:  b.Position(),
+		GroupName: g,
 	}
 }
 
+// Position returns the position of the event in its sync domain
+func (b *Block) Position() float64 {
+	return float64(b.Data.SyncBaseValue) * b.Data.SyncFactor
+}
+
+// IsPostProcessing checks the "post processing" flag (bit 0): the event was
+// generated after the measurement
+func (b *Block) IsPostProcessing() bool {
+	return blocks.IsBitSet(int(b.Data.Flags), 0)
+}
+
+// IsGroupNameValid checks the "group name present" flag (bit 1)
+func (b *Block) IsGroupNameValid() bool {
+	return blocks.IsBitSet(int(b.Data.Flags), 1)
+}
+
 func (b *Block) BlankBlock() *Block {
 	return &Block{
 		Header: blocks.Header{
@@ -5,7 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
+	"time"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 	"github.com/LincolnG4/GoMDF/blocks/TX"
@@ -30,7 +30,7 @@ type Data struct {
 	Reserved     [3]byte
 }
 
-func New(file *os.File, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
 	var b Block
 
 	// Seek to the start address
@@ -88,7 +88,7 @@ func (b *Block) BlankBlock() *Block {
 	}
 }
 
-func (b *Block) GetChangeLog(file *os.File) string {
+func (b *Block) GetChangeLog(file io.ReadSeeker) string {
 	t, err := TX.GetText(file, b.GetMdComment())
 	if err != nil {
 		return ""
@@ -105,6 +105,13 @@ func (b *Block) GetTimeNs() int64 {
 	return int64(b.Data.TimeNS)
 }
 
+// Time returns the time of the change, in the time zone of the change when
+// the time offsets are valid. FHBLOCKs have no time class, their time is
+// taken from the local PC.
+func (b *Block) Time() time.Time {
+	return blocks.Time(b.Data.TimeNS, b.Data.TZOffsetMin, b.Data.DSTOffsetMin, b.Data.TimeFlags, blocks.LocalPCTimeClass)
+}
+
 func (b *Block) GetTimeFlag() uint8 {
 	return b.Data.TimeFlags
 }
@@ -5,7 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
+	"time"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -43,7 +43,7 @@ const blockID string = blocks.HdID
 //
 // The HDBLOCK always begins at file position 64. It contains general information about the
 // contents of the measured data file and is the root for the block hierarchy.
-func New(file *os.File, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
 	var b Block
 
 	// Initialize the Header
@@ -98,3 +98,9 @@ func (b *Block) BlankBlock() *Block {
 	}
 
 }
+
+// StartTime returns the start time of the measurement, in the time zone of
+// the recording when the time offsets are valid, see blocks.Time
+func (b *Block) StartTime() time.Time {
+	return blocks.Time(b.Data.StartTimeNs, b.Data.TZOffsetMin, b.Data.DSTOffsetMin, b.Data.TimeFlags, b.Data.TimeClass)
+}
@@ -3,7 +3,7 @@ package HL
 import (
 	"encoding/binary"
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -35,7 +35,7 @@ type Data struct {
 	Reserved [5]byte
 }
 
-func New(file *os.File, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, startAddress int64) (*Block, error) {
 	var b Block
 	var err error
 
@@ -4,7 +4,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 )
 
 type Block struct {
@@ -18,7 +17,7 @@ type Block struct {
 	CustomUnfinalizedFlag uint16
 }
 
-func New(file *os.File, startAdress int64) *Block {
+func New(file io.ReadSeeker, startAdress int64) *Block {
 	var b Block
 
 	_, errs := file.Seek(startAdress, 0)
@@ -4,7 +4,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 	"github.com/LincolnG4/GoMDF/blocks/TX"
@@ -15,7 +14,7 @@ type Block struct {
 	Data   []byte
 }
 
-func New(file *os.File, startAdress int64) string {
+func New(file io.ReadSeeker, startAdress int64) string {
 	if startAdress == 0 {
 		return ""
 	}
@@ -0,0 +1,554 @@
+package MD
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Schema is the XML schema of the comment of a block
+type Schema interface {
+	// fallback stores a comment that is not XML as its TX
+	fallback(text string)
+
+	// normalize trims the whitespaces around text values
+	normalize()
+}
+
+// preparer is implemented by the schemas with fields copied from elements
+// when read, to copy them back before the comment is encoded
+type preparer interface {
+	prepare()
+}
+
+// Parse decodes the XML comment `s` into `v`. Plain text comments, from a TX
+// block, and comments that can't be decoded are stored as the TX of `v`.
+func Parse(s string, v Schema) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "<") || xml.Unmarshal([]byte(s), v) != nil {
+		v.fallback(s)
+		return
+	}
+	v.normalize()
+}
+
+// Element is a named value of common properties (<e>)
+type Element struct {
+	Name        string `xml:"name,attr"`
+	Description string `xml:"desc,attr,omitempty"`
+	Unit        string `xml:"unit,attr,omitempty"`
+	Type        string `xml:"type,attr,omitempty"`
+	ReadOnly    bool   `xml:"ro,attr,omitempty"`
+	Value       string `xml:",chardata"`
+}
+
+// Tree is a named group of properties (<tree>)
+type Tree struct {
+	Name         string        `xml:"name,attr,omitempty"`
+	Description  string        `xml:"desc,attr,omitempty"`
+	Elements     []Element     `xml:"e"`
+	Trees        []Tree        `xml:"tree"`
+	Lists        []List        `xml:"list"`
+	ElementLists []ElementList `xml:"elist"`
+}
+
+// List is a named list of property trees (<list>)
+type List struct {
+	Name        string `xml:"name,attr"`
+	Description string `xml:"desc,attr,omitempty"`
+	Trees       []Tree `xml:"tree"`
+}
+
+// ElementList is a named list of values of the same type (<elist>)
+type ElementList struct {
+	Name   string   `xml:"name,attr"`
+	Type   string   `xml:"type,attr,omitempty"`
+	Values []string `xml:"eli"`
+}
+
+// CommonProperties holds the generic properties of a comment
+// (<common_properties>), organized as a tree
+type CommonProperties = Tree
+
+// Value returns the value of the element `name` of the properties, or an
+// empty string
+func (t *Tree) Value(name string) string {
+	for _, e := range t.Elements {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// Tree returns the sub tree `name` of the properties, or 'nil'
+func (t *Tree) Tree(name string) *Tree {
+	for i := range t.Trees {
+		if t.Trees[i].Name == name {
+			return &t.Trees[i]
+		}
+	}
+	return nil
+}
+
+// Set sets the value of the element `name`, adding it if needed
+func (t *Tree) Set(name, value string) {
+	for i := range t.Elements {
+		if t.Elements[i].Name == name {
+			t.Elements[i].Value = value
+			return
+		}
+	}
+	t.Elements = append(t.Elements, Element{Name: name, Value: value})
+}
+
+func (t *Tree) normalize() {
+	for i := range t.Elements {
+		t.Elements[i].Value = strings.TrimSpace(t.Elements[i].Value)
+	}
+	for i := range t.Trees {
+		t.Trees[i].normalize()
+	}
+	for i := range t.Lists {
+		for j := range t.Lists[i].Trees {
+			t.Lists[i].Trees[j].normalize()
+		}
+	}
+	for i := range t.ElementLists {
+		for j, v := range t.ElementLists[i].Values {
+			t.ElementLists[i].Values[j] = strings.TrimSpace(v)
+		}
+	}
+}
+
+// Names are the alternative names of a channel, channel group or source
+// (<names>)
+type Names struct {
+	Name        string `xml:"name,omitempty"`
+	Display     string `xml:"display,omitempty"`
+	Vendor      string `xml:"vendor,omitempty"`
+	Description string `xml:"description,omitempty"`
+}
+
+func (n *Names) normalize() {
+	n.Name = strings.TrimSpace(n.Name)
+	n.Display = strings.TrimSpace(n.Display)
+	n.Vendor = strings.TrimSpace(n.Vendor)
+	n.Description = strings.TrimSpace(n.Description)
+}
+
+// Constant is a named constant of the header, usable in formulas (<const>)
+type Constant struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Constants are the constants of the header (<constants>)
+type Constants struct {
+	Values []Constant `xml:"const"`
+}
+
+// Comment is the generic comment of blocks without specific schema, for
+// instance DGcomment, EVcomment or ATcomment
+type Comment struct {
+	XMLName    xml.Name
+	TX         string            `xml:"TX"`
+	Properties *CommonProperties `xml:"common_properties,omitempty"`
+}
+
+func (c *Comment) fallback(text string) {
+	c.TX = text
+}
+
+func (c *Comment) normalize() {
+	c.TX = strings.TrimSpace(c.TX)
+	if c.Properties != nil {
+		c.Properties.normalize()
+	}
+}
+
+// HDComment is the comment of the header block (<HDcomment>)
+type HDComment struct {
+	XMLName    xml.Name          `xml:"HDcomment"`
+	TX         string            `xml:"TX"`
+	TimeSource string            `xml:"time_source,omitempty"`
+	Constants  *Constants        `xml:"constants,omitempty"`
+	Properties *CommonProperties `xml:"common_properties,omitempty"`
+}
+
+func (c *HDComment) fallback(text string) {
+	c.TX = text
+}
+
+func (c *HDComment) normalize() {
+	c.TX = strings.TrimSpace(c.TX)
+	c.TimeSource = strings.TrimSpace(c.TimeSource)
+	if c.Properties != nil {
+		c.Properties.normalize()
+	}
+}
+
+// Property returns the common property `name`, or an empty string
+func (c *HDComment) Property(name string) string {
+	if c.Properties == nil {
+		return ""
+	}
+	return c.Properties.Value(name)
+}
+
+// Constant returns the value of the constant `name`, or an empty string
+func (c *HDComment) Constant(name string) string {
+	if c.Constants == nil {
+		return ""
+	}
+	for _, v := range c.Constants.Values {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// Author returns the author of the measurement
+func (c *HDComment) Author() string {
+	return c.Property("author")
+}
+
+// Department returns the department of the author
+func (c *HDComment) Department() string {
+	return c.Property("department")
+}
+
+// Project returns the project of the measurement
+func (c *HDComment) Project() string {
+	return c.Property("project")
+}
+
+// Subject returns the subject of the measurement, for instance the vehicle
+func (c *HDComment) Subject() string {
+	return c.Property("subject")
+}
+
+// CNComment is the comment of a channel block (<CNcomment>)
+type CNComment struct {
+	XMLName      xml.Name          `xml:"CNcomment"`
+	TX           string            `xml:"TX"`
+	Names        *Names            `xml:"names,omitempty"`
+	LinkerName   string            `xml:"linker_name,omitempty"`
+	Address      string            `xml:"address,omitempty"`
+	AxisMonotony string            `xml:"axis_monotony,omitempty"`
+	Raster       string            `xml:"raster,omitempty"`
+	Formula      string            `xml:"formula,omitempty"`
+	Properties   *CommonProperties `xml:"common_properties,omitempty"`
+
+	//display name of the channel, from Names. It is written to the
+	//<names><display> element when the comment is encoded.
+	DisplayName string `xml:"-"`
+}
+
+func (c *CNComment) fallback(text string) {
+	c.TX = text
+}
+
+func (c *CNComment) prepare() {
+	if c.Names == nil {
+		if c.DisplayName == "" {
+			return
+		}
+		c.Names = &Names{}
+	}
+	c.Names.Display = c.DisplayName
+	if *c.Names == (Names{}) {
+		c.Names = nil
+	}
+}
+
+func (c *CNComment) normalize() {
+	c.TX = strings.TrimSpace(c.TX)
+	c.LinkerName = strings.TrimSpace(c.LinkerName)
+	c.Address = strings.TrimSpace(c.Address)
+	c.AxisMonotony = strings.TrimSpace(c.AxisMonotony)
+	c.Raster = strings.TrimSpace(c.Raster)
+	c.Formula = strings.TrimSpace(c.Formula)
+	if c.Names != nil {
+		c.Names.normalize()
+		c.DisplayName = c.Names.Display
+	}
+	if c.Properties != nil {
+		c.Properties.normalize()
+	}
+}
+
+// CGComment is the comment of a channel group block (<CGcomment>)
+type CGComment struct {
+	XMLName    xml.Name          `xml:"CGcomment"`
+	TX         string            `xml:"TX"`
+	Names      *Names            `xml:"names,omitempty"`
+	Properties *CommonProperties `xml:"common_properties,omitempty"`
+}
+
+func (c *CGComment) fallback(text string) {
+	c.TX = text
+}
+
+func (c *CGComment) normalize() {
+	c.TX = strings.TrimSpace(c.TX)
+	if c.Names != nil {
+		c.Names.normalize()
+	}
+	if c.Properties != nil {
+		c.Properties.normalize()
+	}
+}
+
+// SIComment is the comment of a source information block, describing for
+// instance the bus and protocol of a bus logger (<SIcomment>)
+type SIComment struct {
+	XMLName    xml.Name          `xml:"SIcomment"`
+	TX         string            `xml:"TX"`
+	Names      *Names            `xml:"names,omitempty"`
+	Path       *SourceName       `xml:"path,omitempty"`
+	Bus        *SourceName       `xml:"bus,omitempty"`
+	Protocol   *SourceName       `xml:"protocol,omitempty"`
+	Properties *CommonProperties `xml:"common_properties,omitempty"`
+
+	//number of the bus channel, written by some loggers outside of the ASAM
+	//schema
+	Channel string `xml:"channel,omitempty"`
+}
+
+func (c *SIComment) fallback(text string) {
+	c.TX = text
+}
+
+func (c *SIComment) normalize() {
+	c.TX = strings.TrimSpace(c.TX)
+	c.Channel = strings.TrimSpace(c.Channel)
+	for _, n := range []*SourceName{c.Path, c.Bus, c.Protocol} {
+		if n != nil {
+			n.normalize()
+		}
+	}
+	if c.Names != nil {
+		c.Names.normalize()
+	}
+	if c.Properties != nil {
+		c.Properties.normalize()
+	}
+}
+
+// SourceName is the path, bus or protocol of a source, given either as
+// alternative names or as plain text
+type SourceName struct {
+	Names
+	Text string `xml:",chardata"`
+}
+
+func (n *SourceName) normalize() {
+	n.Names.normalize()
+	n.Text = strings.TrimSpace(n.Text)
+}
+
+// String returns the name, or the plain text. It returns an empty string for
+// a 'nil' name.
+func (n *SourceName) String() string {
+	if n == nil {
+		return ""
+	}
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.Text
+}
+
+// FHComment is the comment of a file history block, describing a change of
+// the file and the tool that made it (<FHcomment>)
+type FHComment struct {
+	XMLName     xml.Name          `xml:"FHcomment"`
+	TX          string            `xml:"TX"`
+	ToolID      string            `xml:"tool_id"`
+	ToolVendor  string            `xml:"tool_vendor"`
+	ToolVersion string            `xml:"tool_version"`
+	UserName    string            `xml:"user_name,omitempty"`
+	Properties  *CommonProperties `xml:"common_properties,omitempty"`
+}
+
+func (c *FHComment) fallback(text string) {
+	c.TX = text
+}
+
+func (c *FHComment) normalize() {
+	c.TX = strings.TrimSpace(c.TX)
+	c.ToolID = strings.TrimSpace(c.ToolID)
+	c.ToolVendor = strings.TrimSpace(c.ToolVendor)
+	c.ToolVersion = strings.TrimSpace(c.ToolVersion)
+	c.UserName = strings.TrimSpace(c.UserName)
+	if c.Properties != nil {
+		c.Properties.normalize()
+	}
+}
+
+// Encode returns the XML comment `v` as the data of an MDBLOCK: zero
+// terminated and padded to 8 bytes
+func Encode(v Schema) ([]byte, error) {
+	if p, ok := v.(preparer); ok {
+		p.prepare()
+	}
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return blockData(data), nil
+}
+
+// Patch returns the XML comment `original` updated with the changes of `v`,
+// as the data of an MDBLOCK. Only the child elements of the root whose value
+// changed are encoded again; the other elements, the attributes, namespaces
+// and elements of the comment unknown to the schema, like vendor data in
+// <PR>, are kept as they are. Comments that are not XML are encoded from `v`.
+func Patch(original string, v Schema) ([]byte, error) {
+	src := strings.TrimSpace(original)
+	old := reflect.New(reflect.TypeOf(v).Elem()).Interface().(Schema)
+	if !strings.HasPrefix(src, "<") || xml.Unmarshal([]byte(src), old) != nil {
+		return Encode(v)
+	}
+	old.normalize()
+	if p, ok := v.(preparer); ok {
+		p.prepare()
+	}
+
+	before, err := xml.Marshal(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	kept, end, err := children([]byte(src))
+	if err != nil {
+		return Encode(v)
+	}
+	oldElements, _, err := children(before)
+	if err != nil {
+		return nil, err
+	}
+	newElements, _, err := children(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// replacement of each element of the original comment, by position
+	replaced := make(map[int]string)
+	var appended strings.Builder
+	for _, name := range elementNames(oldElements, newElements) {
+		encoded := elementText(after, newElements, name)
+		if encoded == elementText(before, oldElements, name) {
+			continue
+		}
+
+		first := true
+		for i, c := range kept {
+			if c.name != name {
+				continue
+			}
+			if first {
+				replaced[i] = encoded
+				first = false
+			} else {
+				replaced[i] = ""
+			}
+		}
+		if first {
+			appended.WriteString(encoded)
+		}
+	}
+
+	var b strings.Builder
+	pos := 0
+	for i, c := range kept {
+		if r, ok := replaced[i]; ok {
+			b.WriteString(src[pos:c.start])
+			b.WriteString(r)
+			pos = c.end
+		}
+	}
+	b.WriteString(src[pos:end])
+	b.WriteString(appended.String())
+	b.WriteString(src[end:])
+	return blockData([]byte(b.String())), nil
+}
+
+// element is a child element of the root of an XML comment, at [start, end)
+type element struct {
+	name       string
+	start, end int
+}
+
+// children returns the child elements of the root of `data`, and the offset
+// of the end tag of the root
+func children(data []byte) ([]element, int, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	elements := make([]element, 0)
+	depth := 0
+	for {
+		offset := int(d.InputOffset())
+		tok, err := d.RawToken()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				elements = append(elements, element{name: t.Name.Local, start: offset})
+			}
+		case xml.EndElement:
+			depth--
+			switch depth {
+			case 0:
+				return elements, offset, nil
+			case 1:
+				elements[len(elements)-1].end = int(d.InputOffset())
+			}
+		}
+	}
+}
+
+// elementNames returns the names of the elements, in order and without
+// duplicates
+func elementNames(lists ...[]element) []string {
+	names := make([]string, 0)
+	for _, elements := range lists {
+		for _, e := range elements {
+			if !slices.Contains(names, e.name) {
+				names = append(names, e.name)
+			}
+		}
+	}
+	return names
+}
+
+// elementText returns the text of the elements `name` of `data`
+func elementText(data []byte, elements []element, name string) string {
+	var b strings.Builder
+	for _, e := range elements {
+		if e.name == name {
+			b.Write(data[e.start:e.end])
+		}
+	}
+	return b.String()
+}
+
+// blockData terminates the XML with a zero byte and pads it to 8 bytes
+func blockData(data []byte) []byte {
+	data = append(data, 0)
+	for len(data)%8 != 0 {
+		data = append(data, 0)
+	}
+	return data
+}
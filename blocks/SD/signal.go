@@ -1,7 +1,7 @@
 package SD
 
 import (
-	"os"
+	"io"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -11,7 +11,7 @@ type Block struct {
 	Data   []byte
 }
 
-func New(file *os.File, startAdress int64) *Block {
+func New(file io.ReadSeeker, startAdress int64) *Block {
 	var b Block
 	var err error
 
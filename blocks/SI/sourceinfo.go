@@ -5,9 +5,11 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
+	"strconv"
+	"strings"
 
 	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/MD"
 	"github.com/LincolnG4/GoMDF/blocks/TX"
 )
 
@@ -38,6 +40,43 @@ type Data struct {
 	Reserved [5]byte
 }
 
+// SourceType is the classification of a source
+type SourceType uint8
+
+const (
+	OtherSource SourceType = iota
+	ECUSource
+	BusSource
+	IOSource
+	ToolSource
+	UserSource
+)
+
+// String returns the name of the source type, for instance "ECU"
+func (t SourceType) String() string {
+	return blocks.SourceTypeMap[uint8(t)]
+}
+
+// BusType is the classification of the bus of a source
+type BusType uint8
+
+const (
+	NoBus BusType = iota
+	OtherBus
+	CAN
+	LIN
+	MOST
+	FlexRay
+	KLine
+	Ethernet
+	USB
+)
+
+// String returns the name of the bus type, for instance "CAN"
+func (t BusType) String() string {
+	return blocks.BusTypeMap[uint8(t)]
+}
+
 // SourceInfo describes the source of an acquisition mode or of a signal
 type SourceInfo struct {
 	Name    string
@@ -46,9 +85,64 @@ type SourceInfo struct {
 	Type    string
 	BusType string
 	Flag    string
+
+	//classification of the source and of its bus. The bus is NoBus for I/O,
+	//tool and user sources.
+	SourceType SourceType
+	Bus        BusType
+
+	//the source is simulated
+	Simulated bool
+}
+
+// Meta returns the parsed comment of the source, with for instance its bus
+// and protocol
+func (s SourceInfo) Meta() *MD.SIComment {
+	meta := &MD.SIComment{}
+	MD.Parse(s.Comment, meta)
+	return meta
+}
+
+// BusChannel returns the number of the bus channel of the source, for
+// instance 2 for "CAN2". It is read from the <channel> of the comment or its
+// "channel" property, else from the trailing digits of the bus name, the
+// source name or the path.
+func (s SourceInfo) BusChannel() (int, bool) {
+	meta := s.Meta()
+	candidates := []string{meta.Channel}
+	if meta.Properties != nil {
+		candidates = append(candidates, meta.Properties.Value("channel"))
+	}
+	for _, v := range candidates {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n, true
+		}
+	}
+
+	for _, v := range []string{meta.Bus.String(), s.Name, s.Path} {
+		if n, ok := trailingNumber(v); ok {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// trailingNumber returns the number at the end of `s`, for instance 1 for
+// "CAN 1"
+func trailingNumber(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	if i == len(s) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[i:])
+	return n, err == nil
 }
 
-func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
+func New(file io.ReadSeeker, version uint16, startAddress int64) (*Block, error) {
 	var b Block
 
 	// Seek to the start address
@@ -96,7 +190,7 @@ func New(file *os.File, version uint16, startAddress int64) (*Block, error) {
 
 // GetPath returns human readable string containing additional
 // information about the source
-func (b *Block) Path(file *os.File) string {
+func (b *Block) Path(file io.ReadSeeker) string {
 	if b.Link.TxPath == 0 {
 		return ""
 	}
@@ -109,7 +203,7 @@ func (b *Block) Path(file *os.File) string {
 	return t
 }
 
-func (b *Block) Name(file *os.File) string {
+func (b *Block) Name(file io.ReadSeeker) string {
 	if b.Link.TxName == 0 {
 		return ""
 	}
@@ -122,8 +216,8 @@ func (b *Block) Name(file *os.File) string {
 	return t
 }
 
-func (b *Block) Comment(file *os.File) string {
-	if b.Link.TxName == 0 {
+func (b *Block) Comment(file io.ReadSeeker) string {
+	if b.Link.MdComment == 0 {
 		return ""
 	}
 
@@ -135,7 +229,7 @@ func (b *Block) Comment(file *os.File) string {
 	return t
 }
 
-func Get(file *os.File, version uint16, address int64) SourceInfo {
+func Get(file io.ReadSeeker, version uint16, address int64) SourceInfo {
 	b, err := New(file, version, address)
 	if err != nil {
 		return SourceInfo{
@@ -154,7 +248,25 @@ func Get(file *os.File, version uint16, address int64) SourceInfo {
 		Type:    b.Type(),
 		BusType: b.BusType(),
 		Flag:    b.Flag(),
+
+		SourceType: SourceType(b.Data.Type),
+		Bus:        b.Bus(),
+		Simulated:  b.IsSimulated(),
+	}
+}
+
+// Bus returns the type of the bus of the source, NoBus for I/O, tool and user
+// sources
+func (b *Block) Bus() BusType {
+	if SourceType(b.Data.Type) >= IOSource {
+		return NoBus
 	}
+	return BusType(b.Data.BusType)
+}
+
+// IsSimulated returns `true` if the source is simulated
+func (b *Block) IsSimulated() bool {
+	return b.Data.Flags&1 != 0
 }
 
 func (b *Block) getDataType() uint8 {
@@ -2,7 +2,7 @@ package SR
 
 import (
 	"encoding/binary"
-	"os"
+	"io"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -36,7 +36,7 @@ type Data struct {
 	Reserved [6]byte
 }
 
-func New(file *os.File, version uint16, startAdress int64) (*Block, error) {
+func New(file io.ReadSeeker, version uint16, startAdress int64) (*Block, error) {
 	var b Block
 	var err error
 
@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 )
@@ -19,7 +18,7 @@ type Block struct {
 	Data   Data
 }
 
-func GetText(file *os.File, startAdress int64) (string, error) {
+func GetText(file io.ReadSeeker, startAdress int64) (string, error) {
 	var blockSize uint64 = blocks.HeaderSize
 	var b Block
 
@@ -84,6 +84,49 @@ const (
 	RemoteMaster string = "REMOTE_MASTER"
 )
 
+const (
+	NoSyncDomain       string = "NONE"
+	TimeSyncDomain     string = "TIME"
+	AngleSyncDomain    string = "ANGLE"
+	DistanceSyncDomain string = "DISTANCE"
+	IndexSyncDomain    string = "INDEX"
+)
+
+var SyncTypeMap map[uint8]string = map[uint8]string{
+	0: NoSyncDomain,
+	1: TimeSyncDomain,
+	2: AngleSyncDomain,
+	3: DistanceSyncDomain,
+	4: IndexSyncDomain,
+}
+
+// Time flags of HDBLOCK and FHBLOCK
+const (
+	// timestamp is the local time of the recording, without time zone
+	LocalTimeFlag uint8 = 1 << 0
+
+	// time zone and daylight saving offsets are valid, timestamp is UTC
+	TimeOffsetsValidFlag uint8 = 1 << 1
+)
+
+// Time classes of HDBLOCK
+const (
+	// local PC reference time (default)
+	LocalPCTimeClass uint8 = 0
+
+	// external time source
+	ExternalTimeClass uint8 = 10
+
+	// external absolute synchronized time, for instance GPS or PTP
+	ExternalAbsoluteTimeClass uint8 = 16
+)
+
+var TimeClassMap map[uint8]string = map[uint8]string{
+	LocalPCTimeClass:          "LOCAL_PC",
+	ExternalTimeClass:         "EXTERNAL",
+	ExternalAbsoluteTimeClass: "EXTERNAL_ABSOLUTE",
+}
+
 const (
 	LinkSize    uint64 = 8
 	HeaderSize  uint64 = 24
@@ -5,7 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
+	"time"
 )
 
 type Header struct {
@@ -17,12 +17,12 @@ type Header struct {
 
 type LinkType map[string]int64
 
-func NewBuffer(file *os.File, startAdress int64, BLOCK_SIZE int) *bytes.Buffer {
+func NewBuffer(file io.ReadSeeker, startAdress int64, BLOCK_SIZE int) *bytes.Buffer {
 	bytesValue := seekBinaryByAddress(file, startAdress, BLOCK_SIZE)
 	return bytes.NewBuffer(bytesValue)
 }
 
-func seekBinaryByAddress(file *os.File, address int64, block_size int) []byte {
+func seekBinaryByAddress(file io.ReadSeeker, address int64, block_size int) []byte {
 	buf := make([]byte, block_size)
 	_, errs := file.Seek(int64(address), 0)
 	if errs != nil {
@@ -41,7 +41,7 @@ func seekBinaryByAddress(file *os.File, address int64, block_size int) []byte {
 	return buf
 }
 
-func GetText(file *os.File, startAdress int64, bufSize []byte, decode bool) []byte {
+func GetText(file io.ReadSeeker, startAdress int64, bufSize []byte, decode bool) []byte {
 	if startAdress == 0 {
 		return []byte{}
 	}
@@ -73,7 +73,7 @@ func SplitIdToArray(inputString string) [4]byte {
 	return byteArray
 }
 
-func GetHeader(file *os.File, startAddress int64, blockID string) (Header, error) {
+func GetHeader(file io.ReadSeeker, startAddress int64, blockID string) (Header, error) {
 	head := Header{}
 
 	// Seek to the start address
@@ -96,7 +96,7 @@ func GetHeader(file *os.File, startAddress int64, blockID string) (Header, error
 	return head, nil
 }
 
-func GetLength(file *os.File, startAddress int64) (uint64, error) {
+func GetLength(file io.ReadSeeker, startAddress int64) (uint64, error) {
 	head := Header{}
 
 	// Seek to the start address
@@ -115,7 +115,7 @@ func GetLength(file *os.File, startAddress int64) (uint64, error) {
 	return head.Length - HeaderSize, nil
 }
 
-func GetHeaderID(file *os.File, startAddress int64) (string, error) {
+func GetHeaderID(file io.ReadSeeker, startAddress int64) (string, error) {
 	head := Header{}
 
 	// Seek to the start address
@@ -134,7 +134,7 @@ func GetHeaderID(file *os.File, startAddress int64) (string, error) {
 	return string(head.ID[:]), nil
 }
 
-func GetBlockType(file *os.File, startAddress int64) (Header, error) {
+func GetBlockType(file io.ReadSeeker, startAddress int64) (Header, error) {
 	head := Header{}
 
 	// Seek to the start address
@@ -161,7 +161,7 @@ func CalculateDataSize(length uint64, linkCount uint64) uint64 {
 }
 
 // Create a buffer based on blocksize
-func LoadBuffer(file *os.File, blockSize uint64) *bytes.Buffer {
+func LoadBuffer(file io.ReadSeeker, blockSize uint64) *bytes.Buffer {
 	buf := make([]byte, blockSize)
 
 	_, err := file.Read(buf)
@@ -174,7 +174,7 @@ func LoadBuffer(file *os.File, blockSize uint64) *bytes.Buffer {
 	return bytes.NewBuffer(buf)
 }
 
-func ReadInt64FromBinary(file *os.File) int64 {
+func ReadInt64FromBinary(file io.ReadSeeker) int64 {
 	var value int64
 	if err := binary.Read(file, binary.LittleEndian, &value); err != nil {
 		fmt.Println("error reading binary data:", err)
@@ -182,7 +182,7 @@ func ReadInt64FromBinary(file *os.File) int64 {
 	return value
 }
 
-func ReadAllFromBinary(file *os.File) int64 {
+func ReadAllFromBinary(file io.ReadSeeker) int64 {
 	var value int64
 	if err := binary.Read(file, binary.LittleEndian, &value); err != nil {
 		fmt.Println("error reading binary data:", err)
@@ -216,3 +216,37 @@ func BinarySearch(vvKeys []float64, c float64) int {
 	// Handle cases where c is outside the range of vvKeys
 	return -1
 }
+
+// LocalTimeZone is the zone of the times recorded as the wall clock of an
+// unknown time zone. It has no offset, so the wall clock is kept as is.
+var LocalTimeZone = time.FixedZone("LOCAL", 0)
+
+// IsLocalTime reports whether a timestamp with the time flags `flags` and the
+// time class `class` is the wall clock of an unknown time zone. Times of an
+// external absolute source, for instance GPS or PTP, are always UTC.
+func IsLocalTime(flags, class uint8) bool {
+	return flags&LocalTimeFlag != 0 && class != ExternalAbsoluteTimeClass
+}
+
+// Time returns the timestamp `ns` of an HDBLOCK or FHBLOCK, in nanoseconds
+// since 1970-01-01, as a time.Time. Local times, see IsLocalTime, are returned
+// as is in LocalTimeZone. Otherwise the timestamp is UTC, returned in a fixed
+// zone of `tzMin` + `dstMin` minutes when the offsets are valid.
+func Time(ns uint64, tzMin, dstMin int16, flags, class uint8) time.Time {
+	t := time.Unix(0, int64(ns))
+	if IsLocalTime(flags, class) {
+		return t.In(LocalTimeZone)
+	}
+	if flags&TimeOffsetsValidFlag == 0 {
+		return t.UTC()
+	}
+
+	offset := (int(tzMin) + int(dstMin)) * 60
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+	}
+	abs := max(offset, -offset)
+	name := fmt.Sprintf("UTC%c%02d:%02d", sign, abs/3600, abs%3600/60)
+	return t.In(time.FixedZone(name, offset))
+}
@@ -0,0 +1,223 @@
+package mf4_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixture builds small MDF files block by block, so tests can cover layouts
+// that are not present in the sample files.
+type fixture struct {
+	buf []byte
+}
+
+type hdData struct {
+	StartTimeNs   uint64
+	TZOffsetMin   int16
+	DSTOffsetMin  int16
+	TimeFlags     uint8
+	TimeClass     uint8
+	Flags         uint8
+	Reserved      uint8
+	StartAngleRad float64
+	StartDistM    float64
+}
+
+type cgData struct {
+	RecordId      uint64
+	CycleCount    uint64
+	Flags         uint16
+	PathSeparator uint16
+	Reserved      [4]byte
+	DataBytes     uint32
+	InvalBytes    uint32
+}
+
+type cnData struct {
+	Type            uint8
+	SyncType        uint8
+	DataType        uint8
+	BitOffset       uint8
+	ByteOffset      uint32
+	BitCount        uint32
+	Flags           uint32
+	InvalBitPos     uint32
+	Precision       uint8
+	Reserved        uint8
+	AttachmentCount uint16
+	ValRangeMin     float64
+	ValRangeMax     float64
+	LimitMin        float64
+	LimitMax        float64
+	LimitExtMin     float64
+	LimitExtMax     float64
+}
+
+const hdAddress int64 = 64
+
+// newFixture writes the IDBLOCK and an empty HDBLOCK at address 64
+func newFixture(version uint16) *fixture {
+	f := &fixture{}
+	id := make([]byte, 64)
+	copy(id[0:], "MDF     ")
+	copy(id[8:], []byte{'4', '.', byte('0' + version/10%10), byte('0' + version%10), ' ', ' ', ' ', ' '})
+	copy(id[16:], "fixture ")
+	binary.LittleEndian.PutUint16(id[28:], version)
+	f.buf = append(f.buf, id...)
+	f.block("##HD", make([]int64, 6), encode(hdData{}))
+	return f
+}
+
+// block appends a block aligned to 8 bytes and returns its address
+func (f *fixture) block(id string, links []int64, data []byte) int64 {
+	for len(f.buf)%8 != 0 {
+		f.buf = append(f.buf, 0)
+	}
+	addr := int64(len(f.buf))
+
+	length := 24 + 8*len(links) + len(data)
+	h := make([]byte, 24)
+	copy(h, id)
+	binary.LittleEndian.PutUint64(h[8:], uint64(length))
+	binary.LittleEndian.PutUint64(h[16:], uint64(len(links)))
+	f.buf = append(f.buf, h...)
+	for _, l := range links {
+		f.buf = binary.LittleEndian.AppendUint64(f.buf, uint64(l))
+	}
+	f.buf = append(f.buf, data...)
+	return addr
+}
+
+// link sets the link `index` of the block at `addr`
+func (f *fixture) link(addr int64, index int, value int64) {
+	binary.LittleEndian.PutUint64(f.buf[addr+24+int64(index)*8:], uint64(value))
+}
+
+// header overwrites the data section of the HDBLOCK
+func (f *fixture) header(d hdData) {
+	copy(f.buf[hdAddress+24+6*8:], encode(d))
+}
+
+func (f *fixture) text(s string) int64 {
+	return f.block("##TX", nil, append([]byte(s), 0))
+}
+
+func (f *fixture) metadata(s string) int64 {
+	return f.block("##MD", nil, append([]byte(s), 0))
+}
+
+// linear appends a CCBLOCK with linear conversion `y = p2*x + p1`
+func (f *fixture) linear(p1, p2 float64) int64 {
+	var d bytes.Buffer
+	d.Write([]byte{1, 0})
+	binary.Write(&d, binary.LittleEndian, uint16(0))
+	binary.Write(&d, binary.LittleEndian, uint16(0))
+	binary.Write(&d, binary.LittleEndian, uint16(2))
+	binary.Write(&d, binary.LittleEndian, []float64{0, 0, p1, p2})
+	return f.block("##CC", make([]int64, 4), d.Bytes())
+}
+
+// table appends a CCBLOCK of type `ccType` with the values `vals` and the
+// references `refs` to texts or conversions
+func (f *fixture) table(ccType uint8, vals []float64, refs ...int64) int64 {
+	var d bytes.Buffer
+	d.Write([]byte{ccType, 0})
+	binary.Write(&d, binary.LittleEndian, uint16(0))
+	binary.Write(&d, binary.LittleEndian, uint16(len(refs)))
+	binary.Write(&d, binary.LittleEndian, uint16(len(vals)))
+	binary.Write(&d, binary.LittleEndian, []float64{0, 0})
+	binary.Write(&d, binary.LittleEndian, vals)
+	return f.block("##CC", append(make([]int64, 4), refs...), d.Bytes())
+}
+
+// zipped appends a DZBLOCK holding `data` of a DTBLOCK compressed with deflate
+func (f *fixture) zipped(data []byte) int64 {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+
+	var d bytes.Buffer
+	d.WriteString("DT")
+	d.Write([]byte{0, 0})
+	binary.Write(&d, binary.LittleEndian, uint32(0))
+	binary.Write(&d, binary.LittleEndian, uint64(len(data)))
+	binary.Write(&d, binary.LittleEndian, uint64(z.Len()))
+	d.Write(z.Bytes())
+	return f.block("##DZ", nil, d.Bytes())
+}
+
+// dataList appends a DLBLOCK referencing the data blocks `addrs`, whose data
+// sections start at `offsets`
+func (f *fixture) dataList(offsets []uint64, addrs ...int64) int64 {
+	var d bytes.Buffer
+	d.Write([]byte{0, 0, 0, 0})
+	binary.Write(&d, binary.LittleEndian, uint32(len(addrs)))
+	binary.Write(&d, binary.LittleEndian, offsets)
+	return f.block("##DL", append([]int64{0}, addrs...), d.Bytes())
+}
+
+// headerList appends a HLBLOCK pointing to the DLBLOCK at `dl`
+func (f *fixture) headerList(dl int64) int64 {
+	return f.block("##HL", []int64{dl}, make([]byte, 8))
+}
+
+// dataGroup appends a sorted DGBLOCK holding the channel group `cg` and its
+// records
+func (f *fixture) dataGroup(cg int64, records []byte) int64 {
+	return f.block("##DG", []int64{0, cg, f.block("##DT", nil, records), 0}, make([]byte, 8))
+}
+
+// channel appends a CNBLOCK named `name`
+func (f *fixture) channel(name string, d cnData) int64 {
+	links := make([]int64, 8)
+	links[2] = f.text(name)
+	return f.block("##CN", links, encode(d))
+}
+
+// chain links the blocks through the link `index`, returning the first one
+func (f *fixture) chain(index int, addrs ...int64) int64 {
+	for i := 0; i < len(addrs)-1; i++ {
+		f.link(addrs[i], index, addrs[i+1])
+	}
+	if len(addrs) == 0 {
+		return 0
+	}
+	return addrs[0]
+}
+
+// open writes the fixture to a temporary file and opens it
+func (f *fixture) open(t testing.TB) *os.File {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "fixture.mf4")
+	if err := os.WriteFile(p, f.buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func encode(v any) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, v)
+	return b.Bytes()
+}
+
+func float64Records(rows ...[]float64) []byte {
+	var b []byte
+	for _, r := range rows {
+		for _, v := range r {
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+		}
+	}
+	return b
+}
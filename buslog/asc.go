@@ -0,0 +1,284 @@
+package buslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ascDateLayouts are the layouts of the date line of ASC files, the first
+// one being written
+var ascDateLayouts = []string{
+	"Mon Jan 02 03:04:05.000 pm 2006",
+	"Mon Jan _2 03:04:05.000 pm 2006",
+	"Mon Jan _2 03:04:05 pm 2006",
+	"Mon Jan _2 15:04:05.000 2006",
+	"Mon Jan _2 15:04:05 2006",
+}
+
+// WriteASC writes the CAN frames as a Vector ASC log with hexadecimal
+// identifiers and timestamps relative to `start`. Overload frames are
+// skipped, ASC has none.
+func WriteASC(w io.Writer, start time.Time, frames []CANFrame) error {
+	bw := bufio.NewWriter(w)
+	date := start.Format(ascDateLayouts[0])
+	fmt.Fprintf(bw, "date %s\n", date)
+	fmt.Fprintln(bw, "base hex  timestamps absolute")
+	fmt.Fprintln(bw, "internal events logged")
+	fmt.Fprintf(bw, "Begin Triggerblock %s\n", date)
+	fmt.Fprintf(bw, "%11.6f Start of measurement\n", 0.0)
+
+	for i := range frames {
+		f := &frames[i]
+		if f.Type == OverloadFrame {
+			continue
+		}
+
+		id := strings.ToUpper(strconv.FormatUint(uint64(f.ID), 16))
+		if f.Extended {
+			id += "x"
+		}
+
+		fmt.Fprintf(bw, "%11.6f ", f.Timestamp)
+		switch {
+		case f.Type == ErrorFrame:
+			fmt.Fprintf(bw, "%d  ErrorFrame\n", f.BusChannel)
+		case f.FD:
+			fmt.Fprintf(bw, "CANFD %3d %-4s %8s %d %d %x %2d %s\n", f.BusChannel, f.Direction, id,
+				boolDigit(f.BitRateSwitch), boolDigit(f.ErrorStateIndicator), f.DLC, len(f.Data), hexBytes(f.Data))
+		case f.Type == RemoteFrame:
+			fmt.Fprintf(bw, "%d  %-15s %-4s r %x\n", f.BusChannel, id, f.Direction, f.DLC)
+		default:
+			fmt.Fprintf(bw, "%d  %-15s %-4s d %x %s\n", f.BusChannel, id, f.Direction, f.DLC, hexBytes(f.Data))
+		}
+	}
+
+	fmt.Fprintln(bw, "End TriggerBlock")
+	return bw.Flush()
+}
+
+func boolDigit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// hexBytes returns the bytes as upper case hexadecimal numbers separated by
+// spaces
+func hexBytes(b []byte) string {
+	var s strings.Builder
+	for i, c := range b {
+		if i > 0 {
+			s.WriteByte(' ')
+		}
+		fmt.Fprintf(&s, "%02X", c)
+	}
+	return s.String()
+}
+
+// ReadASC reads the CAN and CAN FD frames of a Vector ASC log, and the start
+// of the measurement from its date line. Lines of other buses and events are
+// skipped.
+func ReadASC(r io.Reader) ([]CANFrame, time.Time, error) {
+	frames := make([]CANFrame, 0)
+	var start time.Time
+	base := 16
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "date":
+			start = parseASCDate(strings.Join(fields[1:], " "))
+			continue
+		case "base":
+			if len(fields) > 1 && fields[1] == "dec" {
+				base = 10
+			}
+			continue
+		}
+
+		timestamp, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || len(fields) < 3 {
+			continue
+		}
+		f, ok, err := parseASCFrame(fields[1:], base)
+		if err != nil {
+			return nil, start, fmt.Errorf("line %d: %w", n, err)
+		}
+		if !ok {
+			continue
+		}
+		f.Timestamp = timestamp
+		if !start.IsZero() {
+			f.Time = start.Add(seconds(timestamp))
+		}
+		frames = append(frames, f)
+	}
+	return frames, start, s.Err()
+}
+
+// parseASCDate returns the time of the date line, or zero for unknown formats
+func parseASCDate(s string) time.Time {
+	for _, layout := range ascDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseASCFrame parses the fields of a frame line after the timestamp, and
+// returns `false` for lines that aren't CAN frames
+func parseASCFrame(fields []string, base int) (CANFrame, bool, error) {
+	var f CANFrame
+	if fields[0] == "CANFD" {
+		return parseASCFDFrame(fields[1:], base)
+	}
+
+	channel, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return f, false, nil
+	}
+	f.BusChannel = channel
+
+	if fields[1] == "ErrorFrame" {
+		f.Type = ErrorFrame
+		return f, true, nil
+	}
+	if len(fields) < 4 {
+		return f, false, nil
+	}
+
+	if f.ID, f.Extended, err = parseASCID(fields[1], base); err != nil {
+		return f, false, nil
+	}
+	if f.Direction, err = parseDirection(fields[2]); err != nil {
+		return f, false, err
+	}
+
+	switch fields[3] {
+	case "r":
+		f.Type = RemoteFrame
+		if len(fields) > 4 {
+			if dlc, err := strconv.ParseUint(fields[4], base, 8); err == nil {
+				f.DLC = uint8(dlc)
+			}
+		}
+		f.DataLength = DLCToLength(f.DLC, false)
+		return f, true, nil
+	case "d":
+	default:
+		return f, false, fmt.Errorf("unknown frame type %q", fields[3])
+	}
+
+	if len(fields) < 5 {
+		return f, false, fmt.Errorf("missing DLC")
+	}
+	dlc, err := strconv.ParseUint(fields[4], base, 8)
+	if err != nil {
+		return f, false, fmt.Errorf("invalid DLC %q", fields[4])
+	}
+	f.DLC = uint8(dlc)
+	f.DataLength = DLCToLength(f.DLC, false)
+	f.Data, err = parseASCBytes(fields[5:], int(f.DataLength), base)
+	return f, true, err
+}
+
+// parseASCFDFrame parses the fields of a CANFD line: channel, direction,
+// identifier, optional symbolic name, bit rate switch, error state
+// indicator, DLC, data length and data
+func parseASCFDFrame(fields []string, base int) (CANFrame, bool, error) {
+	f := CANFrame{FD: true}
+	if len(fields) < 7 {
+		return f, false, fmt.Errorf("incomplete CANFD frame")
+	}
+
+	channel, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return f, false, fmt.Errorf("invalid channel %q", fields[0])
+	}
+	f.BusChannel = channel
+	if f.Direction, err = parseDirection(fields[1]); err != nil {
+		return f, false, err
+	}
+	if f.ID, f.Extended, err = parseASCID(fields[2], base); err != nil {
+		return f, false, err
+	}
+
+	fields = fields[3:]
+	if fields[0] != "0" && fields[0] != "1" {
+		// symbolic name
+		fields = fields[1:]
+	}
+	if len(fields) < 4 {
+		return f, false, fmt.Errorf("incomplete CANFD frame")
+	}
+	f.BitRateSwitch = fields[0] == "1"
+	f.ErrorStateIndicator = fields[1] == "1"
+	dlc, err := strconv.ParseUint(fields[2], base, 8)
+	if err != nil {
+		return f, false, fmt.Errorf("invalid DLC %q", fields[2])
+	}
+	length, err := strconv.ParseUint(fields[3], 10, 8)
+	if err != nil {
+		return f, false, fmt.Errorf("invalid data length %q", fields[3])
+	}
+	f.DLC, f.DataLength = uint8(dlc), uint8(length)
+
+	// remote frames have no data
+	if f.DataLength == 0 && f.DLC != 0 {
+		f.Type = RemoteFrame
+		f.DataLength = DLCToLength(f.DLC, true)
+		return f, true, nil
+	}
+	f.Data, err = parseASCBytes(fields[4:], int(f.DataLength), base)
+	return f, true, err
+}
+
+// parseASCID parses an identifier, with an "x" suffix for extended
+// identifiers
+func parseASCID(s string, base int) (uint32, bool, error) {
+	extended := strings.HasSuffix(s, "x") || strings.HasSuffix(s, "X")
+	id, err := strconv.ParseUint(strings.TrimRight(s, "xX"), base, 29)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid identifier %q", s)
+	}
+	return uint32(id), extended, nil
+}
+
+func parseDirection(s string) (Direction, error) {
+	switch s {
+	case "Rx":
+		return Rx, nil
+	case "Tx", "TxRq":
+		return Tx, nil
+	default:
+		return Rx, fmt.Errorf("invalid direction %q", s)
+	}
+}
+
+// parseASCBytes parses `n` data bytes written in `base`
+func parseASCBytes(fields []string, n, base int) ([]byte, error) {
+	if len(fields) < n {
+		return nil, fmt.Errorf("expected %d data bytes, got %d", n, len(fields))
+	}
+	data := make([]byte, n)
+	for i := range data {
+		b, err := strconv.ParseUint(fields[i], base, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data byte %q", fields[i])
+		}
+		data[i] = uint8(b)
+	}
+	return data, nil
+}
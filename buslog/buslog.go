@@ -0,0 +1,318 @@
+// Package buslog reads the frames of bus logging files, as described by the
+// ASAM MDF bus logging standard. Frames are stored as bus events: records of
+// channel groups flagged as bus events, whose signals are either members of a
+// structure channel (for instance CAN_DataFrame with CAN_DataFrame.ID,
+// CAN_DataFrame.DLC...) or plain channels named after the structure.
+package buslog
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// FrameType is the type of a frame logged from a bus
+type FrameType uint8
+
+const (
+	DataFrame FrameType = iota
+	RemoteFrame
+	ErrorFrame
+	OverloadFrame
+)
+
+// FrameTypeMap maps the frame types to the suffix of their structure names,
+// for instance CAN_DataFrame
+var FrameTypeMap = map[FrameType]string{
+	DataFrame:     "DataFrame",
+	RemoteFrame:   "RemoteFrame",
+	ErrorFrame:    "ErrorFrame",
+	OverloadFrame: "OverloadFrame",
+}
+
+// String returns the name of the frame type, for instance "DataFrame"
+func (t FrameType) String() string {
+	return FrameTypeMap[t]
+}
+
+// Direction is the direction of a frame, seen from the logger
+type Direction uint8
+
+const (
+	Rx Direction = iota
+	Tx
+)
+
+// String returns "Rx" or "Tx"
+func (d Direction) String() string {
+	if d == Tx {
+		return "Tx"
+	}
+	return "Rx"
+}
+
+// events are the bus events of one structure, for instance CAN_DataFrame, in
+// a channel group
+type events struct {
+	group *mf4.ChannelGroup
+
+	//name of the structure, for instance CAN_DataFrame
+	name string
+
+	//signals of the structure by member name, for instance ID
+	signals map[string]*mf4.Channel
+
+	//values of the signals, read on first use
+	values map[string][]interface{}
+}
+
+// busEvents returns the bus events of the file whose structure names start
+// with `bus`, for instance "CAN_"
+func busEvents(m *mf4.MF4, bus string) []*events {
+	r := make([]*events, 0)
+	for i := range m.ChannelGroup {
+		cg := &m.ChannelGroup[i]
+		if !cg.Block.IsBusEvent() {
+			continue
+		}
+
+		byName := make(map[string]*events)
+		add := func(structure, member string, cn *mf4.Channel) {
+			e, ok := byName[structure]
+			if !ok {
+				e = &events{
+					group:   cg,
+					name:    structure,
+					signals: make(map[string]*mf4.Channel),
+					values:  make(map[string][]interface{}),
+				}
+				byName[structure] = e
+				r = append(r, e)
+			}
+			e.signals[member] = cn
+		}
+
+		for _, cn := range channelsByName(cg) {
+			if !strings.HasPrefix(cn.Name, bus) || cn.IsMaster() {
+				continue
+			}
+
+			if members := cn.Components(); members != nil {
+				for _, member := range members {
+					add(cn.Name, memberName(member.Name), member)
+				}
+				continue
+			}
+
+			// plain bus events: CAN_DataFrame.ID
+			if structure, member, ok := strings.Cut(cn.Name, "."); ok {
+				add(structure, member, cn)
+			}
+		}
+	}
+	return r
+}
+
+// memberName returns the name of a member of a structure without the name of
+// the structure, for instance ID for CAN_DataFrame.ID
+func memberName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// channelsByName returns the channels of the channel group sorted by name
+func channelsByName(cg *mf4.ChannelGroup) []*mf4.Channel {
+	r := make([]*mf4.Channel, 0, len(cg.Channels))
+	for _, cn := range cg.Channels {
+		r = append(r, cn)
+	}
+	slices.SortFunc(r, func(a, b *mf4.Channel) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return r
+}
+
+// frameType returns the type of the frames of the structure, from the suffix
+// of its name
+func (e *events) frameType() (FrameType, bool) {
+	return eventType(e, FrameTypeMap)
+}
+
+// eventType returns the type of the events of the structure whose name ends
+// with "_" and the name of the type, for instance LIN_WakeUp
+func eventType[T comparable](e *events, names map[T]string) (T, bool) {
+	for t, suffix := range names {
+		if strings.HasSuffix(e.name, "_"+suffix) {
+			return t, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// len returns the number of events
+func (e *events) len() int {
+	return int(e.group.Block.Data.CycleCount)
+}
+
+// timestamps returns the master value of each event, in seconds from the
+// start of the measurement, and its absolute time when the master is a time
+// master
+func (e *events) timestamps() ([]float64, []time.Time, error) {
+	for _, cn := range e.signals {
+		values, err := cn.MasterValues()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		times, err := cn.AbsoluteTimes()
+		if err != nil {
+			times = make([]time.Time, len(values))
+		}
+		return values, times, nil
+	}
+	return nil, nil, fmt.Errorf("%s has no signals", e.name)
+}
+
+// absoluteTime returns the absolute time of a frame written in log files:
+// its time, or its timestamp from the epoch when the file has no time master
+func absoluteTime(t time.Time, timestamp float64) time.Time {
+	if !t.IsZero() {
+		return t
+	}
+	return time.Unix(0, 0).Add(seconds(timestamp))
+}
+
+// seconds returns the duration of `s` seconds, rounded to the nanosecond
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * 1e9))
+}
+
+// read returns the raw values of the signal `member`, or 'nil' if the
+// structure doesn't have it
+func (e *events) read(member string) ([]interface{}, error) {
+	if v, ok := e.values[member]; ok {
+		return v, nil
+	}
+
+	cn, ok := e.signals[member]
+	if !ok {
+		return nil, nil
+	}
+	v, err := cn.RawSample()
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", e.name, member, err)
+	}
+	if len(v) < e.len() {
+		return nil, fmt.Errorf("%s.%s: expected %d values, got %d", e.name, member, e.len(), len(v))
+	}
+	e.values[member] = v
+	return v, nil
+}
+
+// uint returns the value `i` of the signal `member`, or `def` if the structure
+// doesn't have the signal
+func (e *events) uint(member string, i int, def uint64) (uint64, error) {
+	v, err := e.read(member)
+	if err != nil || v == nil {
+		return def, err
+	}
+	return toUint(v[i])
+}
+
+// bytes returns the value `i` of the byte array signal `member`, or 'nil' if
+// the structure doesn't have the signal
+func (e *events) bytes(member string, i int) ([]byte, error) {
+	v, err := e.read(member)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	switch b := v[i].(type) {
+	case []byte:
+		return b, nil
+	case string:
+		// byte arrays are read as hexadecimal strings
+		return hex.DecodeString(b)
+	default:
+		return nil, fmt.Errorf("%s.%s: unexpected value %T", e.name, member, v[i])
+	}
+}
+
+// float returns the value `i` of the signal `member` as a float, or `def` if
+// the structure doesn't have the signal
+func (e *events) float(member string, i int, def float64) (float64, error) {
+	v, err := e.read(member)
+	if err != nil || v == nil {
+		return def, err
+	}
+	switch n := v[i].(type) {
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	default:
+		u, err := toUint(n)
+		return float64(u), err
+	}
+}
+
+// address returns the value `i` of the signal `member` holding a MAC address,
+// stored as a byte array or as an integer, or 'nil' if the structure doesn't
+// have the signal
+func (e *events) address(member string, i int) (net.HardwareAddr, error) {
+	v, err := e.read(member)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	switch v[i].(type) {
+	case []byte, string:
+		b, err := e.bytes(member, i)
+		return net.HardwareAddr(b), err
+	default:
+		u, err := toUint(v[i])
+		if err != nil {
+			return nil, err
+		}
+		// the first byte of the address is the most significant one
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], u)
+		return net.HardwareAddr(b[2:]), nil
+	}
+}
+
+// toUint returns the integer raw value `v`
+func toUint(v interface{}) (uint64, error) {
+	switch n := v.(type) {
+	case uint8:
+		return uint64(n), nil
+	case uint16:
+		return uint64(n), nil
+	case uint32:
+		return uint64(n), nil
+	case uint64:
+		return n, nil
+	case int8:
+		return uint64(n), nil
+	case int16:
+		return uint64(n), nil
+	case int32:
+		return uint64(n), nil
+	case int64:
+		return uint64(n), nil
+	case float32:
+		return uint64(n), nil
+	case float64:
+		return uint64(n), nil
+	default:
+		return 0, fmt.Errorf("unexpected value %T", v)
+	}
+}
@@ -0,0 +1,155 @@
+package buslog
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// CANFrame is a frame logged from a CAN or CAN FD bus
+type CANFrame struct {
+	Type FrameType
+
+	//time of the frame, in seconds from the start of the measurement, and
+	//absolute time. The absolute time is zero without a time master.
+	Timestamp float64
+	Time      time.Time
+
+	//number of the bus channel of the logger, from the BusChannel signal or
+	//from the source of the channel group
+	BusChannel int
+
+	//identifier, without the extended flag
+	ID       uint32
+	Extended bool
+
+	Direction Direction
+
+	//data length code and number of data bytes
+	DLC        uint8
+	DataLength uint8
+	Data       []byte
+
+	//CAN FD flags: extended data length, bit rate switch and error state
+	//indicator
+	FD                  bool
+	BitRateSwitch       bool
+	ErrorStateIndicator bool
+
+	//for error frames, the type of error (1 bit error, 2 form error, 3 bit
+	//stuffing error, 4 CRC error, 5 acknowledgment error, 0 unknown) and the
+	//bit position of the error in the frame
+	ErrorType   uint8
+	BitPosition uint16
+}
+
+// extendedIDFlag is the bit of the identifier set by some loggers for
+// extended identifiers, instead of an IDE signal
+const extendedIDFlag = 1 << 31
+
+// canFDLengths are the data lengths of the CAN FD data length codes above 8
+var canFDLengths = [...]uint8{12, 16, 20, 24, 32, 48, 64}
+
+// DLCToLength returns the number of data bytes for the data length code
+// `dlc`
+func DLCToLength(dlc uint8, fd bool) uint8 {
+	if dlc <= 8 {
+		return dlc
+	}
+	if !fd {
+		return 8
+	}
+	return canFDLengths[min(int(dlc)-9, len(canFDLengths)-1)]
+}
+
+// ReadCAN returns the CAN and CAN FD frames of the file, data, remote, error
+// and overload frames, sorted by timestamp
+func ReadCAN(m *mf4.MF4) ([]CANFrame, error) {
+	frames := make([]CANFrame, 0)
+	for _, e := range busEvents(m, "CAN_") {
+		t, ok := e.frameType()
+		if !ok {
+			continue
+		}
+
+		f, err := readCAN(e, t)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, f...)
+	}
+
+	slices.SortStableFunc(frames, func(a, b CANFrame) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+	return frames, nil
+}
+
+// readCAN returns the frames of type `t` of the bus events
+func readCAN(e *events, t FrameType) ([]CANFrame, error) {
+	timestamps, times, err := e.timestamps()
+	if err != nil {
+		return nil, err
+	}
+
+	// bus channel of the source, for events without BusChannel signal
+	channel, _ := e.group.GetSourceInfo().BusChannel()
+	_, hasChannel := e.signals["BusChannel"]
+	_, hasLength := e.signals["DataLength"]
+
+	frames := make([]CANFrame, e.len())
+	for i := range frames {
+		f := &frames[i]
+		f.Type = t
+		f.Timestamp, f.Time = timestamps[i], times[i]
+
+		// the first error is kept, later reads return 0
+		u := func(member string) uint64 {
+			if err != nil {
+				return 0
+			}
+			var v uint64
+			v, err = e.uint(member, i, 0)
+			return v
+		}
+
+		f.BusChannel = channel
+		if hasChannel {
+			f.BusChannel = int(u("BusChannel"))
+		}
+
+		id := u("ID")
+		f.ID = uint32(id &^ extendedIDFlag)
+		f.Extended = u("IDE") != 0 || id&extendedIDFlag != 0
+		f.Direction = Direction(u("Dir"))
+		f.DLC = uint8(u("DLC"))
+		f.FD = u("EDL") != 0
+		f.BitRateSwitch = u("BRS") != 0
+		f.ErrorStateIndicator = u("ESI") != 0
+		f.ErrorType = uint8(u("ErrorType"))
+		f.BitPosition = uint16(u("BitPosition"))
+
+		f.DataLength = DLCToLength(f.DLC, f.FD)
+		if hasLength {
+			f.DataLength = uint8(u("DataLength"))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if t == RemoteFrame || t == OverloadFrame {
+			continue
+		}
+
+		f.Data, err = e.bytes("DataBytes", i)
+		if err != nil {
+			return nil, err
+		}
+		if len(f.Data) > int(f.DataLength) {
+			f.Data = f.Data[:f.DataLength]
+		}
+	}
+	return frames, nil
+}
@@ -0,0 +1,220 @@
+package buslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// candump flags of CAN FD frames and of the identifier of error frames
+const (
+	candumpBRS       = 0x01
+	candumpESI       = 0x02
+	candumpErrorFlag = 0x20000000
+)
+
+// WriteCandump writes the CAN frames in the log format of SocketCAN
+// `candump -L`, with interface canN for the bus channel N+1. Overload frames
+// are skipped.
+func WriteCandump(w io.Writer, frames []CANFrame) error {
+	bw := bufio.NewWriter(w)
+	for i := range frames {
+		f := &frames[i]
+		if f.Type == OverloadFrame {
+			continue
+		}
+
+		t := absoluteTime(f.Time, f.Timestamp)
+		fmt.Fprintf(bw, "(%d.%06d) can%d ", t.Unix(), t.Nanosecond()/1000, max(f.BusChannel-1, 0))
+		switch {
+		case f.Type == ErrorFrame:
+			fmt.Fprintf(bw, "%08X#%s\n", candumpErrorFlag, hexString(make([]byte, 8)))
+			continue
+		case f.Extended:
+			fmt.Fprintf(bw, "%08X", f.ID)
+		default:
+			fmt.Fprintf(bw, "%03X", f.ID)
+		}
+
+		switch {
+		case f.Type == RemoteFrame:
+			fmt.Fprintf(bw, "#R%s\n", remoteDLC(f))
+		case f.FD:
+			var flags int
+			if f.BitRateSwitch {
+				flags |= candumpBRS
+			}
+			if f.ErrorStateIndicator {
+				flags |= candumpESI
+			}
+			fmt.Fprintf(bw, "##%X%s\n", flags, hexString(f.Data))
+		default:
+			fmt.Fprintf(bw, "#%s\n", hexString(f.Data))
+		}
+	}
+	return bw.Flush()
+}
+
+// remoteDLC returns the DLC written after R, which candump leaves out for
+// remote frames without data
+func remoteDLC(f *CANFrame) string {
+	if f.DLC == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(f.DLC), 16)
+}
+
+// hexString returns the bytes as upper case hexadecimal numbers without
+// separators
+func hexString(b []byte) string {
+	return strings.ToUpper(fmt.Sprintf("%x", b))
+}
+
+// ReadCandump reads the frames of a SocketCAN `candump -L` log, and the time
+// of its first frame used as start of the measurement. The interface canN or
+// vcanN is read as bus channel N+1.
+func ReadCandump(r io.Reader) ([]CANFrame, time.Time, error) {
+	frames := make([]CANFrame, 0)
+	var start time.Time
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, start, fmt.Errorf("line %d: expected time, interface and frame", n)
+		}
+
+		t, err := parseCandumpTime(fields[0])
+		if err != nil {
+			return nil, start, fmt.Errorf("line %d: %w", n, err)
+		}
+		f, err := parseCandumpFrame(fields[2])
+		if err != nil {
+			return nil, start, fmt.Errorf("line %d: %w", n, err)
+		}
+		f.BusChannel = candumpChannel(fields[1])
+		if len(fields) > 3 && fields[3] == "T" {
+			f.Direction = Tx
+		}
+
+		if start.IsZero() {
+			start = t
+		}
+		f.Time = t
+		f.Timestamp = t.Sub(start).Seconds()
+		frames = append(frames, f)
+	}
+	return frames, start, s.Err()
+}
+
+// parseCandumpTime parses (<seconds>.<microseconds>)
+func parseCandumpTime(s string) (time.Time, error) {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	sec, frac, _ := strings.Cut(s[1:len(s)-1], ".")
+	secs, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	var nsec int64
+	if frac != "" {
+		if nsec, err = strconv.ParseInt((frac + "000000000")[:9], 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", s)
+		}
+	}
+	return time.Unix(secs, nsec), nil
+}
+
+// candumpChannel returns the bus channel of the interface, 0 for interfaces
+// without a number
+func candumpChannel(iface string) int {
+	digits := strings.TrimLeft(iface, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_-")
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0
+	}
+	return n + 1
+}
+
+// parseCandumpFrame parses <id>#<data>, <id>#R[<dlc>] and
+// <id>##<flags><data>
+func parseCandumpFrame(s string) (CANFrame, error) {
+	var f CANFrame
+	id, payload, ok := strings.Cut(s, "#")
+	if !ok {
+		return f, fmt.Errorf("invalid frame %q", s)
+	}
+	v, err := strconv.ParseUint(id, 16, 32)
+	if err != nil {
+		return f, fmt.Errorf("invalid identifier %q", id)
+	}
+	if v&candumpErrorFlag != 0 {
+		f.Type = ErrorFrame
+		return f, nil
+	}
+	f.ID, f.Extended = uint32(v)&0x1fffffff, len(id) > 3
+
+	switch {
+	case strings.HasPrefix(payload, "R"):
+		f.Type = RemoteFrame
+		if dlc := payload[1:]; dlc != "" {
+			d, err := strconv.ParseUint(dlc, 16, 8)
+			if err != nil {
+				return f, fmt.Errorf("invalid DLC %q", dlc)
+			}
+			f.DLC = uint8(d)
+		}
+		f.DataLength = DLCToLength(f.DLC, false)
+		return f, nil
+	case strings.HasPrefix(payload, "#"):
+		if len(payload) < 2 {
+			return f, fmt.Errorf("missing CAN FD flags in %q", s)
+		}
+		flags, err := strconv.ParseUint(payload[1:2], 16, 8)
+		if err != nil {
+			return f, fmt.Errorf("invalid CAN FD flags in %q", s)
+		}
+		f.FD = true
+		f.BitRateSwitch = flags&candumpBRS != 0
+		f.ErrorStateIndicator = flags&candumpESI != 0
+		payload = payload[2:]
+	}
+
+	// data bytes may be separated by dots
+	payload = strings.ReplaceAll(payload, ".", "")
+	if len(payload)%2 != 0 || len(payload) > 128 {
+		return f, fmt.Errorf("invalid data %q", payload)
+	}
+	f.Data = make([]byte, len(payload)/2)
+	for i := range f.Data {
+		b, err := strconv.ParseUint(payload[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return f, fmt.Errorf("invalid data %q", payload)
+		}
+		f.Data[i] = uint8(b)
+	}
+	f.DataLength = uint8(len(f.Data))
+	f.DLC = lengthToDLC(f.DataLength)
+	return f, nil
+}
+
+// lengthToDLC returns the data length code of `n` data bytes, rounded up to
+// the next CAN FD length
+func lengthToDLC(n uint8) uint8 {
+	if n <= 8 {
+		return n
+	}
+	for i, l := range canFDLengths {
+		if n <= l {
+			return uint8(9 + i)
+		}
+	}
+	return 15
+}
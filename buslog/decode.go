@@ -0,0 +1,351 @@
+package buslog
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+	"github.com/LincolnG4/GoMDF/blocks/SI"
+	"github.com/LincolnG4/GoMDF/dbc"
+	"github.com/LincolnG4/GoMDF/ldf"
+)
+
+// SignalGroup holds signals decoded from the frames of one message on one bus
+// channel and sampled at the same timestamps: the signals that aren't
+// multiplexed, or the signals of one multiplexer value
+type SignalGroup struct {
+	//bus of the frames, CAN or LIN
+	Bus SI.BusType
+
+	Message    *dbc.Message
+	BusChannel int
+
+	//multiplexer value selecting the signals
+	Multiplexed    bool
+	MultiplexValue uint64
+
+	//timestamps of the frames, in seconds from the start of the measurement
+	Timestamps []float64
+
+	Signals []*dbc.Signal
+
+	//physical values of each signal, one per timestamp
+	Values [][]float64
+
+	//texts of the physical values of each signal, or 'nil'
+	ValueTexts []map[float64]string
+}
+
+// Name returns the name of the group, for instance CAN1.EEC1 or CAN1.EEC1.m3
+// for multiplexed signals
+func (g *SignalGroup) Name() string {
+	name := fmt.Sprintf("%s%d.%s", g.Bus, g.BusChannel, g.Message.Name)
+	if g.Multiplexed {
+		name += fmt.Sprintf(".m%d", g.MultiplexValue)
+	}
+	return name
+}
+
+// Signal returns the timestamps and values of the signal `name`, or 'nil'
+func (g *SignalGroup) Signal(name string) ([]float64, []float64) {
+	for i, s := range g.Signals {
+		if s.Name == name {
+			return g.Timestamps, g.Values[i]
+		}
+	}
+	return nil, nil
+}
+
+// groupKey identifies a SignalGroup
+type groupKey struct {
+	message     *dbc.Message
+	busChannel  int
+	mux         uint64
+	multiplexed bool
+}
+
+// decoder decodes the frames of messages into signal groups
+type decoder struct {
+	bus    SI.BusType
+	groups []*SignalGroup
+	byKey  map[groupKey]*SignalGroup
+
+	//LIN signals of the messages built from LDF frames
+	lin map[*dbc.Signal]ldf.FrameSignal
+}
+
+func newDecoder(bus SI.BusType) *decoder {
+	return &decoder{bus: bus, groups: make([]*SignalGroup, 0), byKey: make(map[groupKey]*SignalGroup)}
+}
+
+// add decodes the data of a frame of the message `m`, received at `timestamp`
+func (d *decoder) add(m *dbc.Message, busChannel int, timestamp float64, data []byte) {
+	k := groupKey{message: m, busChannel: busChannel}
+	if signals := plainSignals(m); len(signals) > 0 {
+		d.addGroup(k, timestamp, data, signals)
+	}
+
+	if m.Multiplexer == nil {
+		return
+	}
+	mux, ok := m.Multiplexer.Raw(data)
+	if !ok {
+		return
+	}
+	if signals := multiplexedSignals(m, mux); len(signals) > 0 {
+		k.multiplexed, k.mux = true, mux
+		d.addGroup(k, timestamp, data, signals)
+	}
+}
+
+// addGroup decodes the `signals` of the group `k`
+func (d *decoder) addGroup(k groupKey, timestamp float64, data []byte, signals []*dbc.Signal) {
+	g, ok := d.byKey[k]
+	if !ok {
+		g = &SignalGroup{
+			Bus:            d.bus,
+			Message:        k.message,
+			BusChannel:     k.busChannel,
+			Multiplexed:    k.multiplexed,
+			MultiplexValue: k.mux,
+			Signals:        signals,
+			Values:         make([][]float64, len(signals)),
+			ValueTexts:     make([]map[float64]string, len(signals)),
+		}
+		for i, s := range signals {
+			g.ValueTexts[i] = d.valueTexts(s)
+		}
+		d.byKey[k] = g
+		d.groups = append(d.groups, g)
+	}
+
+	g.Timestamps = append(g.Timestamps, timestamp)
+	for i, s := range g.Signals {
+		v, ok := d.decode(s, data)
+		if !ok {
+			v = math.NaN()
+		}
+		g.Values[i] = append(g.Values[i], v)
+	}
+}
+
+// decode returns the physical value of the signal `s` in `data`. LIN signals
+// are decoded with the physical range of their raw value.
+func (d *decoder) decode(s *dbc.Signal, data []byte) (float64, bool) {
+	if ls, ok := d.lin[s]; ok {
+		return ls.Decode(data)
+	}
+	return s.Decode(data)
+}
+
+// valueTexts returns the texts of the physical values of the signal `s`
+func (d *decoder) valueTexts(s *dbc.Signal) map[float64]string {
+	if ls, ok := d.lin[s]; ok {
+		if ls.Encoding == nil {
+			return nil
+		}
+		return ls.Encoding.ValueTexts()
+	}
+	return s.PhysicalValueTable()
+}
+
+// DecodeCAN decodes the signals of the data frames described in `db`. Groups
+// are in the order of their first frame, frames of unknown messages are
+// skipped and signals beyond the data of a frame are decoded as NaN.
+func DecodeCAN(frames []CANFrame, db *dbc.Database) []*SignalGroup {
+	d := newDecoder(SI.CAN)
+	for i := range frames {
+		f := &frames[i]
+		if f.Type != DataFrame {
+			continue
+		}
+		if m := db.Message(f.ID, f.Extended); m != nil {
+			d.add(m, f.BusChannel, f.Timestamp, f.Data)
+		}
+	}
+	return d.groups
+}
+
+// DecodeLIN decodes the signals of the LIN frames described in `ld`, like
+// DecodeCAN. Raw values are converted with the physical range of the
+// encoding they are in, see ldf.FrameSignal.Decode; the messages of the
+// groups, from ldf.Frame.Message, only describe the signals.
+func DecodeLIN(frames []LINFrame, ld *ldf.File) []*SignalGroup {
+	d := newDecoder(SI.LIN)
+	d.lin = make(map[*dbc.Signal]ldf.FrameSignal)
+	messages := make(map[uint8]*dbc.Message)
+	for i := range frames {
+		f := &frames[i]
+		if f.Type != LINFrameEvent {
+			continue
+		}
+
+		m, ok := messages[f.ID]
+		if !ok {
+			if fr := ld.Frame(f.ID); fr != nil {
+				m = fr.Message()
+				d.linSignals(fr, m)
+			}
+			messages[f.ID] = m
+		}
+		if m != nil {
+			d.add(m, f.BusChannel, f.Timestamp, f.Data)
+		}
+	}
+	return d.groups
+}
+
+// linSignals maps the signals of the message `m` built from the frame `fr` to
+// the LIN signals of the frame
+func (d *decoder) linSignals(fr *ldf.Frame, m *dbc.Message) {
+	for _, s := range m.Signals {
+		for _, ls := range fr.Signals {
+			if ls.Name == s.Name {
+				d.lin[s] = ls
+				break
+			}
+		}
+	}
+}
+
+// plainSignals returns the signals of the message that aren't multiplexed
+func plainSignals(m *dbc.Message) []*dbc.Signal {
+	r := make([]*dbc.Signal, 0, len(m.Signals))
+	for _, s := range m.Signals {
+		if !s.Multiplexed {
+			r = append(r, s)
+		}
+	}
+	return r
+}
+
+// multiplexedSignals returns the signals of the message selected by the
+// multiplexer value `mux`
+func multiplexedSignals(m *dbc.Message, mux uint64) []*dbc.Signal {
+	r := make([]*dbc.Signal, 0)
+	for _, s := range m.Signals {
+		if s.Multiplexed && s.MultiplexValue == mux {
+			r = append(r, s)
+		}
+	}
+	return r
+}
+
+// ExportSignals writes the signal groups to a new MF4 file at `path`, one
+// channel group per signal group named after it. Value tables are written as
+// value to text conversions.
+func ExportSignals(path string, start time.Time, groups []*SignalGroup) error {
+	w, err := mf4.NewWriter(path)
+	if err != nil {
+		return err
+	}
+	w.StartTime = start
+
+	for _, g := range groups {
+		channels := make([]mf4.WriterChannel, len(g.Signals))
+		for i, s := range g.Signals {
+			texts := s.PhysicalValueTable()
+			if i < len(g.ValueTexts) {
+				texts = g.ValueTexts[i]
+			}
+			channels[i] = mf4.WriterChannel{
+				Name:       s.Name,
+				Unit:       s.Unit,
+				Comment:    s.Comment,
+				Values:     g.Values[i],
+				ValueTexts: texts,
+			}
+		}
+		if err := w.AddGroup(g.Name(), g.Timestamps, channels...); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// DecodeFile decodes the CAN signals of `m` described in `db` to a new MF4
+// file at `path`, and returns it opened so its signals are read with the
+// Channel API. The caller closes its File.
+func DecodeFile(m *mf4.MF4, db *dbc.Database, path string) (*mf4.MF4, error) {
+	frames, err := ReadCAN(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := ExportSignals(path, m.StartTime(), DecodeCAN(frames, db)); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := mf4.ReadFile(f, &mf4.ReadOptions{})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// FindDBC returns the database attached to the CAN data frames of `m`, or
+// else the first attachment of the file with a .dbc extension
+func FindDBC(m *mf4.MF4) (*dbc.Database, error) {
+	at, err := findAttachment(m, "CAN_DataFrame", ".dbc")
+	if err != nil {
+		return nil, err
+	}
+	return dbc.ParseAttachment(at)
+}
+
+// FindLDF returns the LIN description file attached to the LIN frames of `m`,
+// or else the first attachment of the file with a .ldf extension
+func FindLDF(m *mf4.MF4) (*ldf.File, error) {
+	at, err := findAttachment(m, "LIN_Frame", ".ldf")
+	if err != nil {
+		return nil, err
+	}
+	return ldf.ParseAttachment(at)
+}
+
+// findAttachment returns the first attachment with the extension `ext` of the
+// structure channels `structure`, or else of the file
+func findAttachment(m *mf4.MF4, structure, ext string) (AT.AttFile, error) {
+	for i := range m.ChannelGroup {
+		cn, ok := m.ChannelGroup[i].Channels[structure]
+		if !ok {
+			continue
+		}
+		attachments, err := cn.Attachments()
+		if err != nil {
+			return AT.AttFile{}, err
+		}
+		if at, ok := withExtension(attachments, ext); ok {
+			return at, nil
+		}
+	}
+
+	attachments, err := m.GetAttachments()
+	if err != nil {
+		return AT.AttFile{}, err
+	}
+	if at, ok := withExtension(attachments, ext); ok {
+		return at, nil
+	}
+	return AT.AttFile{}, fmt.Errorf("no %s attachment", strings.ToUpper(ext[1:]))
+}
+
+// withExtension returns the first attachment with the extension `ext`
+func withExtension(attachments []AT.AttFile, ext string) (AT.AttFile, bool) {
+	for _, at := range attachments {
+		if strings.EqualFold(filepath.Ext(at.Name), ext) {
+			return at, true
+		}
+	}
+	return AT.AttFile{}, false
+}
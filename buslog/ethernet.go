@@ -0,0 +1,130 @@
+package buslog
+
+import (
+	"cmp"
+	"encoding/binary"
+	"net"
+	"slices"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// EthernetFrame is a frame logged from an Ethernet bus
+type EthernetFrame struct {
+	//time of the frame, in seconds from the start of the measurement, and
+	//absolute time. The absolute time is zero without a time master.
+	Timestamp float64
+	Time      time.Time
+
+	//number of the bus channel of the logger, from the BusChannel signal or
+	//from the source of the channel group
+	BusChannel int
+	Direction  Direction
+
+	//MAC header, 'nil' addresses if the data holds the whole frame
+	Destination net.HardwareAddr
+	Source      net.HardwareAddr
+	EtherType   uint16
+
+	//payload after the MAC header, or whole frame without addresses
+	Data []byte
+
+	//frame check sequence, 0 when unknown
+	CRC uint32
+}
+
+// Bytes returns the frame from its MAC header, without frame check sequence
+func (f *EthernetFrame) Bytes() []byte {
+	if f.Destination == nil && f.Source == nil {
+		return f.Data
+	}
+
+	r := make([]byte, 0, 14+len(f.Data))
+	r = append(r, padAddress(f.Destination)...)
+	r = append(r, padAddress(f.Source)...)
+	r = binary.BigEndian.AppendUint16(r, f.EtherType)
+	return append(r, f.Data...)
+}
+
+// padAddress returns the 6 bytes of the MAC address `a`
+func padAddress(a net.HardwareAddr) []byte {
+	r := make([]byte, 6)
+	copy(r, a)
+	return r
+}
+
+// ReadEthernet returns the Ethernet frames of the file, sorted by timestamp
+func ReadEthernet(m *mf4.MF4) ([]EthernetFrame, error) {
+	frames := make([]EthernetFrame, 0)
+	for _, e := range busEvents(m, "ETH_") {
+		if e.name != "ETH_Frame" {
+			continue
+		}
+
+		f, err := readEthernet(e)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, f...)
+	}
+
+	slices.SortStableFunc(frames, func(a, b EthernetFrame) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+	return frames, nil
+}
+
+// readEthernet returns the frames of the bus events
+func readEthernet(e *events) ([]EthernetFrame, error) {
+	timestamps, times, err := e.timestamps()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, _ := e.group.GetSourceInfo().BusChannel()
+	_, hasChannel := e.signals["BusChannel"]
+	_, hasLength := e.signals["DataLength"]
+
+	frames := make([]EthernetFrame, e.len())
+	for i := range frames {
+		f := &frames[i]
+		f.Timestamp, f.Time = timestamps[i], times[i]
+
+		// the first error is kept, later reads return 0
+		u := func(member string) uint64 {
+			if err != nil {
+				return 0
+			}
+			var v uint64
+			v, err = e.uint(member, i, 0)
+			return v
+		}
+
+		f.BusChannel = channel
+		if hasChannel {
+			f.BusChannel = int(u("BusChannel"))
+		}
+		f.Direction = Direction(u("Dir"))
+		f.EtherType = uint16(u("EtherType"))
+		f.CRC = uint32(u("CRC"))
+		length := int(u("DataLength"))
+		if err != nil {
+			return nil, err
+		}
+
+		if f.Destination, err = e.address("Destination", i); err != nil {
+			return nil, err
+		}
+		if f.Source, err = e.address("Source", i); err != nil {
+			return nil, err
+		}
+		if f.Data, err = e.bytes("DataBytes", i); err != nil {
+			return nil, err
+		}
+		if hasLength && len(f.Data) > length {
+			f.Data = f.Data[:length]
+		}
+	}
+	return frames, nil
+}
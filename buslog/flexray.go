@@ -0,0 +1,168 @@
+package buslog
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// FlexRayEventType is the type of a frame logged from a FlexRay bus
+type FlexRayEventType uint8
+
+const (
+	FlexRayFrameEvent FlexRayEventType = iota
+	FlexRayNullFrame
+	FlexRayErrorFrame
+)
+
+// FlexRayEventTypeMap maps the FlexRay frame types to the suffix of their
+// structure names, for instance FLX_NullFrame
+var FlexRayEventTypeMap = map[FlexRayEventType]string{
+	FlexRayFrameEvent: "Frame",
+	FlexRayNullFrame:  "NullFrame",
+	FlexRayErrorFrame: "ErrorFrame",
+}
+
+// String returns the name of the frame type, for instance "NullFrame"
+func (t FlexRayEventType) String() string {
+	return FlexRayEventTypeMap[t]
+}
+
+// FlexRayChannel is the channel of a FlexRay cluster a frame was sent on
+type FlexRayChannel uint8
+
+const (
+	FlexRayChannelA FlexRayChannel = iota
+	FlexRayChannelB
+)
+
+// String returns "A" or "B"
+func (c FlexRayChannel) String() string {
+	if c == FlexRayChannelB {
+		return "B"
+	}
+	return "A"
+}
+
+// FlexRayFrame is a frame logged from a FlexRay bus
+type FlexRayFrame struct {
+	Type FlexRayEventType
+
+	//time of the frame, in seconds from the start of the measurement, and
+	//absolute time. The absolute time is zero without a time master.
+	Timestamp float64
+	Time      time.Time
+
+	//number of the bus channel of the logger, from the BusChannel signal or
+	//from the source of the channel group
+	BusChannel int
+
+	//channel of the cluster, slot and communication cycle of the frame
+	Channel   FlexRayChannel
+	SlotID    uint16
+	Cycle     uint8
+	Direction Direction
+
+	//payload length in 2 byte words and data. Null frames have no data.
+	PayloadLength uint8
+	Data          []byte
+
+	HeaderCRC uint16
+	FrameCRC  uint32
+
+	//header indicators: sync frame, startup frame, payload preamble
+	Sync            bool
+	Startup         bool
+	PayloadPreamble bool
+}
+
+// ReadFlexRay returns the FlexRay frames, null frames and error frames of the
+// file, sorted by timestamp
+func ReadFlexRay(m *mf4.MF4) ([]FlexRayFrame, error) {
+	frames := make([]FlexRayFrame, 0)
+	for _, e := range busEvents(m, "FLX_") {
+		t, ok := eventType(e, FlexRayEventTypeMap)
+		if !ok {
+			continue
+		}
+
+		f, err := readFlexRay(e, t)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, f...)
+	}
+
+	slices.SortStableFunc(frames, func(a, b FlexRayFrame) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+	return frames, nil
+}
+
+// readFlexRay returns the frames of type `t`
+func readFlexRay(e *events, t FlexRayEventType) ([]FlexRayFrame, error) {
+	timestamps, times, err := e.timestamps()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, _ := e.group.GetSourceInfo().BusChannel()
+	_, hasChannel := e.signals["BusChannel"]
+	_, hasLength := e.signals["DataLength"]
+	_, hasPayload := e.signals["PayloadLength"]
+
+	frames := make([]FlexRayFrame, e.len())
+	for i := range frames {
+		f := &frames[i]
+		f.Type = t
+		f.Timestamp, f.Time = timestamps[i], times[i]
+
+		// the first error is kept, later reads return 0
+		u := func(member string) uint64 {
+			if err != nil {
+				return 0
+			}
+			var v uint64
+			v, err = e.uint(member, i, 0)
+			return v
+		}
+
+		f.BusChannel = channel
+		if hasChannel {
+			f.BusChannel = int(u("BusChannel"))
+		}
+		f.Channel = FlexRayChannel(u("FlxChannel"))
+		f.SlotID = uint16(u("ID"))
+		f.Cycle = uint8(u("CycleCount"))
+		f.Direction = Direction(u("Dir"))
+		f.PayloadLength = uint8(u("PayloadLength"))
+		f.HeaderCRC = uint16(u("HeaderCRC"))
+		f.FrameCRC = uint32(u("FrameCRC"))
+		f.Sync = u("SyncBit") != 0
+		f.Startup = u("StartupBit") != 0
+		f.PayloadPreamble = u("PPI") != 0
+		dataLength := int(u("DataLength"))
+		if err != nil {
+			return nil, err
+		}
+
+		if t == FlexRayNullFrame {
+			continue
+		}
+		if f.Data, err = e.bytes("DataBytes", i); err != nil {
+			return nil, err
+		}
+		n := len(f.Data)
+		if hasLength {
+			n = dataLength
+		} else if hasPayload {
+			n = 2 * int(f.PayloadLength)
+		}
+		if len(f.Data) > n {
+			f.Data = f.Data[:n]
+		}
+	}
+	return frames, nil
+}
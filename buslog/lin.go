@@ -0,0 +1,207 @@
+package buslog
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// LINEventType is the type of an event logged from a LIN bus
+type LINEventType uint8
+
+const (
+	LINFrameEvent LINEventType = iota
+	LINChecksumError
+	LINReceiveError
+	LINSyncError
+	LINTransmissionError
+	LINWakeUp
+	LINSpike
+	LINLongDominant
+)
+
+// LINEventTypeMap maps the LIN event types to the suffix of their structure
+// names, for instance LIN_ChecksumError
+var LINEventTypeMap = map[LINEventType]string{
+	LINFrameEvent:        "Frame",
+	LINChecksumError:     "ChecksumError",
+	LINReceiveError:      "ReceiveError",
+	LINSyncError:         "SyncError",
+	LINTransmissionError: "TransmissionError",
+	LINWakeUp:            "WakeUp",
+	LINSpike:             "Spike",
+	LINLongDominant:      "LongDom",
+}
+
+// String returns the name of the event type, for instance "ChecksumError"
+func (t LINEventType) String() string {
+	return LINEventTypeMap[t]
+}
+
+// ChecksumModel is the checksum model of a LIN frame
+type ChecksumModel int8
+
+const (
+	UnknownChecksum ChecksumModel = iota - 1
+	ClassicChecksum
+	EnhancedChecksum
+)
+
+// LINFrame is a frame, or another event, logged from a LIN bus
+type LINFrame struct {
+	Type LINEventType
+
+	//time of the event, in seconds from the start of the measurement, and
+	//absolute time. The absolute time is zero without a time master.
+	Timestamp float64
+	Time      time.Time
+
+	//number of the bus channel of the logger, from the BusChannel signal or
+	//from the source of the channel group
+	BusChannel int
+
+	//frame identifier, without parity bits
+	ID        uint8
+	Direction Direction
+
+	//number of data bytes expected and received, and data. Data is shorter
+	//than DataLength for receive errors.
+	DataLength uint8
+	Data       []byte
+
+	Checksum      uint8
+	ChecksumModel ChecksumModel
+
+	//baud rate of the bus, 0 when unknown
+	BaudRate float64
+}
+
+// ReadLIN returns the LIN frames, checksum errors, wake-ups and other LIN
+// events of the file, sorted by timestamp
+func ReadLIN(m *mf4.MF4) ([]LINFrame, error) {
+	frames := make([]LINFrame, 0)
+	for _, e := range busEvents(m, "LIN_") {
+		t, ok := eventType(e, LINEventTypeMap)
+		if !ok {
+			continue
+		}
+
+		f, err := readLIN(e, t)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, f...)
+	}
+
+	slices.SortStableFunc(frames, func(a, b LINFrame) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+	return frames, nil
+}
+
+// readLIN returns the events of type `t`
+func readLIN(e *events, t LINEventType) ([]LINFrame, error) {
+	timestamps, times, err := e.timestamps()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, _ := e.group.GetSourceInfo().BusChannel()
+	_, hasChannel := e.signals["BusChannel"]
+	_, hasModel := e.signals["ChecksumModel"]
+
+	frames := make([]LINFrame, e.len())
+	for i := range frames {
+		f := &frames[i]
+		f.Type = t
+		f.Timestamp, f.Time = timestamps[i], times[i]
+
+		// the first error is kept, later reads return 0
+		u := func(member string) uint64 {
+			if err != nil {
+				return 0
+			}
+			var v uint64
+			v, err = e.uint(member, i, 0)
+			return v
+		}
+
+		f.BusChannel = channel
+		if hasChannel {
+			f.BusChannel = int(u("BusChannel"))
+		}
+		f.ID = uint8(u("ID")) & 0x3f
+		f.Direction = Direction(u("Dir"))
+		f.DataLength = uint8(u("DataLength"))
+		f.Checksum = uint8(u("Checksum"))
+		f.ChecksumModel = UnknownChecksum
+		if hasModel {
+			f.ChecksumModel = ChecksumModel(int8(u("ChecksumModel")))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.BaudRate, err = e.float("BaudRate", i, 0); err != nil {
+			return nil, err
+		}
+
+		if f.Data, err = e.bytes("DataBytes", i); err != nil {
+			return nil, err
+		}
+		// bytes received for receive errors, up to the data length otherwise
+		n := len(f.Data)
+		if _, ok := e.signals["ReceivedDataByteCount"]; ok {
+			n = int(u("ReceivedDataByteCount"))
+		} else if _, ok := e.signals["DataLength"]; ok {
+			n = int(f.DataLength)
+		}
+		if len(f.Data) > n {
+			f.Data = f.Data[:n]
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+// LINProtectedID returns the protected identifier of the frame identifier
+// `id`: the identifier with its two parity bits
+func LINProtectedID(id uint8) uint8 {
+	bit := func(n uint8) uint8 { return id >> n & 1 }
+	p0 := bit(0) ^ bit(1) ^ bit(2) ^ bit(4)
+	p1 := ^(bit(1) ^ bit(3) ^ bit(4) ^ bit(5)) & 1
+	return id&0x3f | p0<<6 | p1<<7
+}
+
+// LINChecksum returns the checksum of the data of the frame `id`. The
+// enhanced checksum includes the protected identifier, except for the
+// diagnostic frames 0x3c and 0x3d.
+func LINChecksum(id uint8, data []byte, enhanced bool) uint8 {
+	var sum uint16
+	if enhanced && id != 0x3c && id != 0x3d {
+		sum = uint16(LINProtectedID(id))
+	}
+	for _, b := range data {
+		sum += uint16(b)
+		if sum > 0xff {
+			sum -= 0xff
+		}
+	}
+	return ^uint8(sum)
+}
+
+// ValidChecksum reports whether the checksum of the frame matches its data.
+// Frames with an unknown checksum model match either model.
+func (f *LINFrame) ValidChecksum() bool {
+	switch f.ChecksumModel {
+	case ClassicChecksum:
+		return LINChecksum(f.ID, f.Data, false) == f.Checksum
+	case EnhancedChecksum:
+		return LINChecksum(f.ID, f.Data, true) == f.Checksum
+	default:
+		return LINChecksum(f.ID, f.Data, false) == f.Checksum || LINChecksum(f.ID, f.Data, true) == f.Checksum
+	}
+}
@@ -0,0 +1,124 @@
+package buslog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// linkTypeEthernet is the link type of Ethernet frames in pcap files
+const linkTypeEthernet = 1
+
+// maxSnapLength is the maximum frame length written in pcap files
+const maxSnapLength = 262144
+
+// WritePcap writes the Ethernet frames in the pcap format, with nanosecond
+// timestamps
+func WritePcap(w io.Writer, frames []EthernetFrame) error {
+	bw := bufio.NewWriter(w)
+
+	// magic number of nanosecond timestamps, version 2.4, time zone, accuracy,
+	// snapshot length and link type
+	header := binary.LittleEndian.AppendUint32(nil, 0xa1b23c4d)
+	header = binary.LittleEndian.AppendUint16(header, 2)
+	header = binary.LittleEndian.AppendUint16(header, 4)
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	header = binary.LittleEndian.AppendUint32(header, maxSnapLength)
+	header = binary.LittleEndian.AppendUint32(header, linkTypeEthernet)
+	bw.Write(header)
+
+	for i := range frames {
+		data := frames[i].Bytes()
+		t := absoluteTime(frames[i].Time, frames[i].Timestamp)
+		record := []uint32{uint32(t.Unix()), uint32(t.Nanosecond()), uint32(len(data)), uint32(len(data))}
+		binary.Write(bw, binary.LittleEndian, record)
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WritePcapNG writes the Ethernet frames in the pcapng format, with one
+// interface per bus channel named ETH<channel>, nanosecond timestamps and the
+// direction of the frames
+func WritePcapNG(w io.Writer, frames []EthernetFrame) error {
+	bw := bufio.NewWriter(w)
+
+	// section header: byte order magic, version 1.0, unknown section length
+	shb := binary.LittleEndian.AppendUint32(nil, 0x1a2b3c4d)
+	shb = binary.LittleEndian.AppendUint16(shb, 1)
+	shb = binary.LittleEndian.AppendUint16(shb, 0)
+	shb = binary.LittleEndian.AppendUint64(shb, math.MaxUint64)
+	writePcapNGBlock(bw, 0x0a0d0d0a, shb)
+
+	// interfaces, in the order of the bus channels
+	channels := make([]int, 0)
+	for i := range frames {
+		if !slices.Contains(channels, frames[i].BusChannel) {
+			channels = append(channels, frames[i].BusChannel)
+		}
+	}
+	slices.Sort(channels)
+	for _, c := range channels {
+		idb := binary.LittleEndian.AppendUint16(nil, linkTypeEthernet)
+		idb = binary.LittleEndian.AppendUint16(idb, 0)
+		idb = binary.LittleEndian.AppendUint32(idb, maxSnapLength)
+		// if_name, if_tsresol of nanoseconds, end of options
+		idb = appendPcapNGOption(idb, 2, []byte(fmt.Sprintf("ETH%d", c)))
+		idb = appendPcapNGOption(idb, 9, []byte{9})
+		idb = appendPcapNGOption(idb, 0, nil)
+		writePcapNGBlock(bw, 1, idb)
+	}
+
+	for i := range frames {
+		f := &frames[i]
+		data := f.Bytes()
+		ns := uint64(absoluteTime(f.Time, f.Timestamp).UnixNano())
+
+		epb := binary.LittleEndian.AppendUint32(nil, uint32(slices.Index(channels, f.BusChannel)))
+		epb = binary.LittleEndian.AppendUint32(epb, uint32(ns>>32))
+		epb = binary.LittleEndian.AppendUint32(epb, uint32(ns))
+		epb = binary.LittleEndian.AppendUint32(epb, uint32(len(data)))
+		epb = binary.LittleEndian.AppendUint32(epb, uint32(len(data)))
+		epb = append(epb, data...)
+		epb = append(epb, make([]byte, pad4(len(data)))...)
+
+		// epb_flags: inbound or outbound
+		direction := uint32(1)
+		if f.Direction == Tx {
+			direction = 2
+		}
+		epb = appendPcapNGOption(epb, 2, binary.LittleEndian.AppendUint32(nil, direction))
+		epb = appendPcapNGOption(epb, 0, nil)
+		writePcapNGBlock(bw, 6, epb)
+	}
+	return bw.Flush()
+}
+
+// writePcapNGBlock writes a pcapng block of type `t` with its body, aligned
+// to 4 bytes
+func writePcapNGBlock(w *bufio.Writer, t uint32, body []byte) {
+	length := uint32(12 + len(body))
+	binary.Write(w, binary.LittleEndian, []uint32{t, length})
+	w.Write(body)
+	binary.Write(w, binary.LittleEndian, length)
+}
+
+// appendPcapNGOption appends the option `code` with its value, padded to 4
+// bytes
+func appendPcapNGOption(b []byte, code uint16, value []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, code)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	return append(b, make([]byte, pad4(len(value)))...)
+}
+
+// pad4 returns the number of bytes padding `n` bytes to 4 bytes
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
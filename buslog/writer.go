@@ -0,0 +1,117 @@
+package buslog
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks/CG"
+	"github.com/LincolnG4/GoMDF/blocks/CN"
+	"github.com/LincolnG4/GoMDF/blocks/SI"
+)
+
+// canHeaderSize is the size of the part of the records shared by all CAN
+// frame types written by WriteCAN: timestamp, bus channel, identifier, flags,
+// DLC and data length
+const canHeaderSize = 16
+
+// canFlags are the bits of the flags byte of the records written by WriteCAN
+var canFlags = []string{"IDE", "Dir", "EDL", "BRS", "ESI"}
+
+// WriteCAN writes the CAN frames to a new MF4 bus logging file at `path`,
+// with one channel group of plain bus events per frame type. The timestamps
+// of the frames are relative to `start`.
+func WriteCAN(path string, start time.Time, frames []CANFrame) error {
+	w, err := mf4.NewWriter(path)
+	if err != nil {
+		return err
+	}
+	w.StartTime = start
+
+	for _, t := range []FrameType{DataFrame, RemoteFrame, ErrorFrame, OverloadFrame} {
+		g := canGroup(t, frames)
+		if len(g.Records) == 0 {
+			continue
+		}
+		if err := w.AddRecords(g); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// canGroup returns the channel group of the frames of type `t`
+func canGroup(t FrameType, frames []CANFrame) mf4.RecordGroup {
+	name := "CAN_" + t.String()
+	channel := func(member string, data CN.Data) mf4.RecordChannel {
+		return mf4.RecordChannel{Name: name + "." + member, Data: data}
+	}
+
+	channels := []mf4.RecordChannel{
+		{Name: "Timestamp", Unit: "s", Data: CN.Data{Type: CN.Master, SyncType: CN.TimeSync, DataType: CN.IEEE754FloatLE, BitCount: 64}},
+		channel("BusChannel", CN.Data{ByteOffset: 8, BitCount: 8}),
+		channel("ID", CN.Data{ByteOffset: 9, BitCount: 29}),
+	}
+	for i, flag := range canFlags {
+		channels = append(channels, channel(flag, CN.Data{ByteOffset: 13, BitOffset: uint8(i), BitCount: 1}))
+	}
+	channels = append(channels,
+		channel("DLC", CN.Data{ByteOffset: 14, BitCount: 8}),
+		channel("DataLength", CN.Data{ByteOffset: 15, BitCount: 8}),
+	)
+
+	size := canHeaderSize
+	switch t {
+	case DataFrame:
+		channels = append(channels, channel("DataBytes", CN.Data{DataType: CN.ByteArrayUnknown, ByteOffset: 16, BitCount: 64 * 8}))
+		size += 64
+	case ErrorFrame:
+		channels = append(channels,
+			channel("ErrorType", CN.Data{ByteOffset: 16, BitCount: 8}),
+			channel("BitPosition", CN.Data{ByteOffset: 17, BitCount: 16}),
+		)
+		size += 8
+	}
+
+	records := make([]byte, 0)
+	for i := range frames {
+		f := &frames[i]
+		if f.Type != t {
+			continue
+		}
+
+		r := binary.LittleEndian.AppendUint64(nil, math.Float64bits(f.Timestamp))
+		r = append(r, uint8(f.BusChannel))
+		r = binary.LittleEndian.AppendUint32(r, f.ID&^extendedIDFlag)
+		var flags uint8
+		for i, set := range []bool{f.Extended, f.Direction == Tx, f.FD, f.BitRateSwitch, f.ErrorStateIndicator} {
+			if set {
+				flags |= 1 << i
+			}
+		}
+		r = append(r, flags, f.DLC, f.DataLength)
+
+		switch t {
+		case DataFrame:
+			var data [64]byte
+			copy(data[:], f.Data)
+			r = append(r, data[:]...)
+		case ErrorFrame:
+			r = append(r, f.ErrorType)
+			r = binary.LittleEndian.AppendUint16(r, f.BitPosition)
+			r = append(r, make([]byte, 5)...)
+		}
+		records = append(records, r...)
+	}
+
+	return mf4.RecordGroup{
+		Name:       name,
+		Flags:      CG.BusEventFlag | CG.PlainBusEventFlag,
+		Source:     &SI.SourceInfo{Name: "CAN", SourceType: SI.BusSource, Bus: SI.CAN},
+		Channels:   channels,
+		RecordSize: uint32(size),
+		Records:    records,
+	}
+}
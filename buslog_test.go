@@ -0,0 +1,545 @@
+package mf4_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/buslog"
+)
+
+// canRecord returns a record of the CAN_DataFrame structure of canFixture
+func canRecord(t float64, channel uint8, id uint32, extended bool, dlc uint8, data []byte, flags uint8) []byte {
+	r := binary.LittleEndian.AppendUint64(nil, math.Float64bits(t))
+	r = append(r, channel)
+	if extended {
+		id |= 1 << 31
+	}
+	r = binary.LittleEndian.AppendUint32(r, id)
+	// the upper bits of the DLC byte are not part of the DLC
+	r = append(r, 0xa0|dlc, uint8(len(data)))
+	r = append(r, data...)
+	r = append(r, make([]byte, 8-len(data))...)
+	return append(r, flags)
+}
+
+// canFixture has CAN data frames stored as a structure with members, and
+// error and remote frames stored as plain bus events
+func canFixture(t testing.TB) *mf4.MF4 {
+	f := newFixture(410)
+	timestamp := cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64}
+
+	// CAN_DataFrame structure
+	members := f.chain(0,
+		f.channel("CAN_DataFrame.BusChannel", cnData{ByteOffset: 8, BitCount: 8}),
+		f.channel("CAN_DataFrame.ID", cnData{ByteOffset: 9, BitCount: 29}),
+		f.channel("CAN_DataFrame.IDE", cnData{ByteOffset: 12, BitOffset: 7, BitCount: 1}),
+		f.channel("CAN_DataFrame.DLC", cnData{ByteOffset: 13, BitCount: 4}),
+		f.channel("CAN_DataFrame.DataLength", cnData{ByteOffset: 14, BitCount: 8}),
+		f.channel("CAN_DataFrame.DataBytes", cnData{DataType: 10, ByteOffset: 15, BitCount: 64}),
+		f.channel("CAN_DataFrame.Dir", cnData{ByteOffset: 23, BitCount: 1}),
+		f.channel("CAN_DataFrame.EDL", cnData{ByteOffset: 23, BitOffset: 1, BitCount: 1}),
+	)
+	frame := f.channel("CAN_DataFrame", cnData{DataType: 10, ByteOffset: 8, BitCount: 128})
+	f.link(frame, 1, members)
+	cg := f.block("##CG", []int64{0, f.chain(0, f.channel("Timestamp", timestamp), frame), 0, 0, 0, 0},
+		encode(cgData{CycleCount: 3, Flags: 1 << 1, DataBytes: 24}))
+	data := f.dataGroup(cg, bytes.Join([][]byte{
+		canRecord(0, 1, 0x123, false, 8, []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}, 0),
+		canRecord(1, 1, 0x18fef100, true, 3, []byte{0xaa, 0xbb, 0xcc}, 1),
+		canRecord(2, 2, 0x7df, false, 2, []byte{0x02, 0x01}, 0),
+	}, nil))
+
+	// plain error frames, with the bus channel in the source
+	can2 := f.source("CAN2", "", "", siData{Type: 2, BusType: 2})
+	cg = f.block("##CG", []int64{0, f.chain(0,
+		f.channel("Timestamp", timestamp),
+		f.channel("CAN_ErrorFrame.ID", cnData{ByteOffset: 8, BitCount: 32}),
+		f.channel("CAN_ErrorFrame.ErrorType", cnData{ByteOffset: 12, BitCount: 8}),
+	), 0, can2, 0, 0}, encode(cgData{CycleCount: 1, Flags: 1<<1 | 1<<2, DataBytes: 16}))
+	record := binary.LittleEndian.AppendUint64(nil, math.Float64bits(0.5))
+	record = binary.LittleEndian.AppendUint32(record, 0x18fef100|1<<31)
+	errors := f.dataGroup(cg, append(record, 4, 0, 0, 0))
+
+	// plain remote frames
+	cg = f.block("##CG", []int64{0, f.chain(0,
+		f.channel("Timestamp", timestamp),
+		f.channel("CAN_RemoteFrame.ID", cnData{ByteOffset: 8, BitCount: 16}),
+		f.channel("CAN_RemoteFrame.DLC", cnData{ByteOffset: 10, BitCount: 8}),
+		f.channel("CAN_RemoteFrame.Dir", cnData{ByteOffset: 11, BitCount: 8}),
+	), 0, 0, 0, 0}, encode(cgData{CycleCount: 1, Flags: 1<<1 | 1<<2, DataBytes: 16}))
+	record = binary.LittleEndian.AppendUint64(nil, math.Float64bits(1.5))
+	remotes := f.dataGroup(cg, append(record, 0x21, 0x03, 8, 1, 0, 0, 0, 0))
+
+	f.link(hdAddress, 0, f.chain(0, data, errors, remotes))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestReadCAN(t *testing.T) {
+	m := canFixture(t)
+
+	frame := m.ChannelGroup[0].Channels["CAN_DataFrame"]
+	if n := len(frame.Components()); n != 8 {
+		t.Fatalf("expected 8 members of CAN_DataFrame, got %d", n)
+	}
+
+	frames, err := buslog.ReadCAN(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 5 {
+		t.Fatalf("expected 5 frames, got %d", len(frames))
+	}
+
+	types := []buslog.FrameType{buslog.DataFrame, buslog.ErrorFrame, buslog.DataFrame, buslog.RemoteFrame, buslog.DataFrame}
+	for i, f := range frames {
+		if f.Type != types[i] || f.Timestamp != float64(i)/2 {
+			t.Fatalf("frame %d: expected %s at %f, got %s at %f", i, types[i], float64(i)/2, f.Type, f.Timestamp)
+		}
+	}
+
+	first := frames[0]
+	if first.ID != 0x123 || first.Extended || first.DLC != 8 || first.BusChannel != 1 || first.Direction != buslog.Rx {
+		t.Fatalf("wrong first frame %+v", first)
+	}
+	if !bytes.Equal(first.Data, []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}) {
+		t.Fatalf("wrong data % x", first.Data)
+	}
+
+	extended := frames[2]
+	if extended.ID != 0x18fef100 || !extended.Extended || extended.DLC != 3 || extended.Direction != buslog.Tx || !bytes.Equal(extended.Data, []byte{0xaa, 0xbb, 0xcc}) {
+		t.Fatalf("wrong extended frame %+v", extended)
+	}
+	if frames[4].BusChannel != 2 || frames[4].ID != 0x7df || len(frames[4].Data) != 2 {
+		t.Fatalf("wrong last frame %+v", frames[4])
+	}
+
+	errorFrame := frames[1]
+	if errorFrame.ID != 0x18fef100 || !errorFrame.Extended || errorFrame.ErrorType != 4 || errorFrame.BusChannel != 2 {
+		t.Fatalf("wrong error frame %+v", errorFrame)
+	}
+	remote := frames[3]
+	if remote.ID != 0x321 || remote.DLC != 8 || remote.DataLength != 8 || remote.Data != nil || remote.Direction != buslog.Tx {
+		t.Fatalf("wrong remote frame %+v", remote)
+	}
+}
+
+func TestCompositionLoop(t *testing.T) {
+	f := newFixture(410)
+	id := f.channel("CAN_DataFrame.ID", cnData{ByteOffset: 8, BitCount: 29})
+	frame := f.channel("CAN_DataFrame", cnData{DataType: 10, ByteOffset: 8, BitCount: 64})
+	f.link(frame, 1, id)
+	// the member lists its own structure as next member
+	f.link(id, 0, frame)
+	cg := f.block("##CG", []int64{0, frame, 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 16}))
+	f.link(hdAddress, 0, f.dataGroup(cg, make([]byte, 16)))
+
+	if _, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{}); err == nil {
+		t.Fatal("expected an error for the composition loop")
+	}
+}
+
+func TestDLCToLength(t *testing.T) {
+	for dlc, expected := range map[uint8]uint8{0: 0, 8: 8, 9: 12, 13: 32, 15: 64} {
+		if n := buslog.DLCToLength(dlc, true); n != expected {
+			t.Fatalf("DLC %d: expected %d bytes, got %d", dlc, expected, n)
+		}
+	}
+	if n := buslog.DLCToLength(15, false); n != 8 {
+		t.Fatalf("expected 8 bytes for classic CAN, got %d", n)
+	}
+}
+
+// record returns a record with the little endian `values`
+func record(values ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+// plainEvents appends a data group of plain bus events with a timestamp and
+// the `channels`, and `records` of `size` bytes
+func (f *fixture) plainEvents(size uint32, channels []int64, records ...[]byte) int64 {
+	timestamp := f.channel("Timestamp", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	cg := f.block("##CG", []int64{0, f.chain(0, append([]int64{timestamp}, channels...)...), 0, 0, 0, 0},
+		encode(cgData{CycleCount: uint64(len(records)), Flags: 1<<1 | 1<<2, DataBytes: size}))
+	return f.dataGroup(cg, bytes.Join(records, nil))
+}
+
+// busFixture has plain LIN, FlexRay and Ethernet bus events
+func busFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	linFrame := func(ts float64, id, dir, length uint8, data []byte, checksum uint8, model int8) []byte {
+		return record(ts, uint8(1), id, dir, length, [8]byte(append(data, make([]byte, 8-len(data))...)), checksum, model, [2]byte{})
+	}
+	frames := f.plainEvents(24, []int64{
+		f.channel("LIN_Frame.BusChannel", cnData{ByteOffset: 8, BitCount: 8}),
+		f.channel("LIN_Frame.ID", cnData{ByteOffset: 9, BitCount: 8}),
+		f.channel("LIN_Frame.Dir", cnData{ByteOffset: 10, BitCount: 8}),
+		f.channel("LIN_Frame.DataLength", cnData{ByteOffset: 11, BitCount: 8}),
+		f.channel("LIN_Frame.DataBytes", cnData{DataType: 10, ByteOffset: 12, BitCount: 64}),
+		f.channel("LIN_Frame.Checksum", cnData{ByteOffset: 20, BitCount: 8}),
+		f.channel("LIN_Frame.ChecksumModel", cnData{DataType: 2, ByteOffset: 21, BitCount: 8}),
+	},
+		linFrame(0, 0x10, 0, 2, []byte{0x64, 0x02}, 0x49, 1),
+		linFrame(2, 0x11, 1, 1, []byte{0x05}, 0xfa, -1),
+	)
+	wakeUp := f.plainEvents(16, []int64{f.channel("LIN_WakeUp.BusChannel", cnData{ByteOffset: 8, BitCount: 8})},
+		record(1.0, uint8(1), [7]byte{}))
+	checksumError := f.plainEvents(24, []int64{
+		f.channel("LIN_ChecksumError.ID", cnData{ByteOffset: 8, BitCount: 8}),
+		f.channel("LIN_ChecksumError.Checksum", cnData{ByteOffset: 9, BitCount: 8}),
+		f.channel("LIN_ChecksumError.DataLength", cnData{ByteOffset: 10, BitCount: 8}),
+		f.channel("LIN_ChecksumError.DataBytes", cnData{DataType: 10, ByteOffset: 11, BitCount: 64}),
+	}, record(1.5, uint8(0x10), uint8(0), uint8(2), [8]byte{0x64, 0x03}, [5]byte{}))
+
+	flexRay := f.plainEvents(24, []int64{
+		f.channel("FLX_Frame.BusChannel", cnData{ByteOffset: 8, BitCount: 8}),
+		f.channel("FLX_Frame.FlxChannel", cnData{ByteOffset: 9, BitCount: 8}),
+		f.channel("FLX_Frame.ID", cnData{ByteOffset: 10, BitCount: 16}),
+		f.channel("FLX_Frame.CycleCount", cnData{ByteOffset: 12, BitCount: 8}),
+		f.channel("FLX_Frame.PayloadLength", cnData{ByteOffset: 13, BitCount: 8}),
+		f.channel("FLX_Frame.DataBytes", cnData{DataType: 10, ByteOffset: 14, BitCount: 48}),
+		f.channel("FLX_Frame.HeaderCRC", cnData{ByteOffset: 20, BitCount: 16}),
+		f.channel("FLX_Frame.SyncBit", cnData{ByteOffset: 22, BitCount: 1}),
+		f.channel("FLX_Frame.StartupBit", cnData{ByteOffset: 22, BitOffset: 1, BitCount: 1}),
+	}, record(0.25, uint8(1), uint8(1), uint16(5), uint8(3), uint8(2), [6]byte{1, 2, 3, 4, 0xee, 0xee}, uint16(0x123), uint8(1), uint8(0)))
+	nullFrames := f.plainEvents(16, []int64{
+		f.channel("FLX_NullFrame.ID", cnData{ByteOffset: 8, BitCount: 16}),
+		f.channel("FLX_NullFrame.CycleCount", cnData{ByteOffset: 10, BitCount: 8}),
+	}, record(0.75, uint16(7), uint8(4), [5]byte{}))
+
+	// destination as a byte array, source as an integer
+	ethernet := f.plainEvents(40, []int64{
+		f.channel("ETH_Frame.BusChannel", cnData{ByteOffset: 8, BitCount: 8}),
+		f.channel("ETH_Frame.Dir", cnData{ByteOffset: 9, BitCount: 8}),
+		f.channel("ETH_Frame.Destination", cnData{DataType: 10, ByteOffset: 10, BitCount: 48}),
+		f.channel("ETH_Frame.Source", cnData{ByteOffset: 16, BitCount: 48}),
+		f.channel("ETH_Frame.EtherType", cnData{ByteOffset: 22, BitCount: 16}),
+		f.channel("ETH_Frame.DataLength", cnData{ByteOffset: 24, BitCount: 16}),
+		f.channel("ETH_Frame.DataBytes", cnData{DataType: 10, ByteOffset: 26, BitCount: 64}),
+	}, record(0.5, uint8(2), uint8(1), [6]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		[6]byte{0x66, 0x55, 0x44, 0x33, 0x22, 0x11}, uint16(0x0800), uint16(5), [8]byte{'h', 'e', 'l', 'l', 'o'}, [6]byte{}))
+
+	f.link(hdAddress, 0, f.chain(0, frames, wakeUp, checksumError, flexRay, nullFrames, ethernet))
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestReadLIN(t *testing.T) {
+	frames, err := buslog.ReadLIN(busFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := []buslog.LINEventType{buslog.LINFrameEvent, buslog.LINWakeUp, buslog.LINChecksumError, buslog.LINFrameEvent}
+	if len(frames) != len(types) {
+		t.Fatalf("expected %d LIN events, got %d", len(types), len(frames))
+	}
+	for i, f := range frames {
+		if f.Type != types[i] {
+			t.Fatalf("event %d: expected %s, got %s", i, types[i], f.Type)
+		}
+	}
+
+	first := frames[0]
+	if first.ID != 0x10 || first.BusChannel != 1 || first.DataLength != 2 || !bytes.Equal(first.Data, []byte{0x64, 0x02}) || first.ChecksumModel != buslog.EnhancedChecksum || !first.ValidChecksum() {
+		t.Fatalf("wrong LIN frame %+v", first)
+	}
+	last := frames[3]
+	if last.Direction != buslog.Tx || last.ChecksumModel != buslog.UnknownChecksum || !last.ValidChecksum() {
+		t.Fatalf("wrong LIN frame %+v", last)
+	}
+	if e := frames[2]; e.ID != 0x10 || !bytes.Equal(e.Data, []byte{0x64, 0x03}) || e.ValidChecksum() {
+		t.Fatalf("wrong checksum error %+v", e)
+	}
+}
+
+func TestLINChecksum(t *testing.T) {
+	if id := buslog.LINProtectedID(0x10); id != 0x50 {
+		t.Fatalf("expected protected identifier 0x50, got %#x", id)
+	}
+	if id := buslog.LINProtectedID(0x3c); id != 0x3c {
+		t.Fatalf("expected protected identifier 0x3c, got %#x", id)
+	}
+	data := []byte{0x64, 0x02}
+	if c := buslog.LINChecksum(0x10, data, false); c != 0x99 {
+		t.Fatalf("expected classic checksum 0x99, got %#x", c)
+	}
+	if c := buslog.LINChecksum(0x10, data, true); c != 0x49 {
+		t.Fatalf("expected enhanced checksum 0x49, got %#x", c)
+	}
+	if buslog.LINChecksum(0x3c, data, true) != buslog.LINChecksum(0x3c, data, false) {
+		t.Fatal("expected a classic checksum for diagnostic frames")
+	}
+}
+
+func TestReadFlexRay(t *testing.T) {
+	frames, err := buslog.ReadFlexRay(busFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 FlexRay frames, got %d", len(frames))
+	}
+
+	f := frames[0]
+	if f.Type != buslog.FlexRayFrameEvent || f.Channel != buslog.FlexRayChannelB || f.SlotID != 5 || f.Cycle != 3 || f.HeaderCRC != 0x123 || !f.Sync || f.Startup {
+		t.Fatalf("wrong FlexRay frame %+v", f)
+	}
+	// the data is cut to the payload length
+	if !bytes.Equal(f.Data, []byte{1, 2, 3, 4}) {
+		t.Fatalf("wrong data % x", f.Data)
+	}
+	null := frames[1]
+	if null.Type != buslog.FlexRayNullFrame || null.SlotID != 7 || null.Cycle != 4 || null.Data != nil || null.Timestamp != 0.75 {
+		t.Fatalf("wrong null frame %+v", null)
+	}
+}
+
+func TestReadEthernet(t *testing.T) {
+	frames, err := buslog.ReadEthernet(busFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 {
+		t.Fatalf("expected 1 Ethernet frame, got %d", len(frames))
+	}
+
+	f := frames[0]
+	if f.BusChannel != 2 || f.Direction != buslog.Tx || f.EtherType != 0x0800 || string(f.Data) != "hello" {
+		t.Fatalf("wrong Ethernet frame %+v", f)
+	}
+	if f.Destination.String() != "ff:ff:ff:ff:ff:ff" || f.Source.String() != "11:22:33:44:55:66" {
+		t.Fatalf("wrong addresses %s %s", f.Destination, f.Source)
+	}
+	expected := append([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x08, 0x00}, "hello"...)
+	if !bytes.Equal(f.Bytes(), expected) {
+		t.Fatalf("wrong frame bytes % x", f.Bytes())
+	}
+}
+
+func TestWritePcap(t *testing.T) {
+	frames, err := buslog.ReadEthernet(busFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := frames[0].Bytes()
+
+	var pcap bytes.Buffer
+	if err := buslog.WritePcap(&pcap, frames); err != nil {
+		t.Fatal(err)
+	}
+	b := pcap.Bytes()
+	if len(b) != 24+16+len(data) || binary.LittleEndian.Uint32(b) != 0xa1b23c4d || binary.LittleEndian.Uint32(b[20:]) != 1 {
+		t.Fatalf("wrong pcap header % x", b[:24])
+	}
+	// the file has no time master: timestamps count from the epoch
+	if binary.LittleEndian.Uint32(b[24:]) != 0 || binary.LittleEndian.Uint32(b[28:]) != 500000000 || !bytes.Equal(b[40:], data) {
+		t.Fatalf("wrong pcap record % x", b[24:])
+	}
+
+	var pcapng bytes.Buffer
+	if err := buslog.WritePcapNG(&pcapng, frames); err != nil {
+		t.Fatal(err)
+	}
+	b = pcapng.Bytes()
+	types := make([]uint32, 0)
+	for len(b) > 0 {
+		length := binary.LittleEndian.Uint32(b[4:])
+		if length%4 != 0 || int(length) > len(b) || binary.LittleEndian.Uint32(b[length-4:]) != length {
+			t.Fatalf("wrong pcapng block length %d", length)
+		}
+		types = append(types, binary.LittleEndian.Uint32(b))
+		if binary.LittleEndian.Uint32(b) == 6 && !bytes.Equal(b[28:28+len(data)], data) {
+			t.Fatalf("wrong packet % x", b[28:])
+		}
+		b = b[length:]
+	}
+	if len(types) != 3 || types[0] != 0x0a0d0d0a || types[1] != 1 || types[2] != 6 {
+		t.Fatalf("wrong pcapng blocks %x", types)
+	}
+}
+
+// sameFrames fails if the frames differ in anything but their absolute time.
+// Error frames are compared by type, timestamp and bus channel only when
+// `errorIDs` is false, since text logs don't keep their identifier.
+func sameFrames(t *testing.T, expected, got []buslog.CANFrame, errorIDs bool) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d frames, got %d", len(expected), len(got))
+	}
+	for i, e := range expected {
+		g := got[i]
+		if e.Type != g.Type || math.Abs(e.Timestamp-g.Timestamp) > 1e-6 || e.BusChannel != g.BusChannel {
+			t.Fatalf("frame %d: expected %+v, got %+v", i, e, g)
+		}
+		if e.Type == buslog.ErrorFrame && !errorIDs {
+			continue
+		}
+		if e.ID != g.ID || e.Extended != g.Extended || e.Direction != g.Direction || e.DLC != g.DLC || e.DataLength != g.DataLength ||
+			!bytes.Equal(e.Data, g.Data) || e.FD != g.FD || e.BitRateSwitch != g.BitRateSwitch || e.ErrorStateIndicator != g.ErrorStateIndicator {
+			t.Fatalf("frame %d: expected %+v, got %+v", i, e, g)
+		}
+	}
+}
+
+// exportFrames returns the frames of canFixture and a CAN FD frame
+func exportFrames(t *testing.T) []buslog.CANFrame {
+	frames, err := buslog.ReadCAN(canFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 12)
+	for i := range data {
+		data[i] = uint8(i)
+	}
+	return append(frames, buslog.CANFrame{Type: buslog.DataFrame, Timestamp: 2.25, BusChannel: 1, ID: 0x1abcdef0, Extended: true,
+		DLC: 9, DataLength: 12, Data: data, FD: true, BitRateSwitch: true, Direction: buslog.Tx})
+}
+
+func TestWriteCAN(t *testing.T) {
+	frames := exportFrames(t)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	path := filepath.Join(t.TempDir(), "can.mf4")
+	if err := buslog.WriteCAN(path, start, frames); err != nil {
+		t.Fatal(err)
+	}
+	m := reopen(t, path)
+	if len(m.ChannelGroup) != 3 {
+		t.Fatalf("expected data, remote and error frame groups, got %d", len(m.ChannelGroup))
+	}
+	got, err := buslog.ReadCAN(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameFrames(t, frames, got, true)
+	if !got[1].Time.Equal(start.Add(500 * time.Millisecond)) {
+		t.Fatalf("expected the absolute time of the frame, got %v", got[1].Time)
+	}
+}
+
+func TestASC(t *testing.T) {
+	frames := exportFrames(t)
+	start := time.Date(2024, 3, 1, 13, 4, 5, 0, time.Local)
+
+	// ASC has no overload frames
+	overload := buslog.CANFrame{Type: buslog.OverloadFrame, Timestamp: 2.5, BusChannel: 1}
+	var asc bytes.Buffer
+	if err := buslog.WriteASC(&asc, start, append(frames, overload)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(asc.String(), "date Fri Mar 01 01:04:05.000 pm 2024\nbase hex") {
+		t.Fatalf("wrong ASC header %q", asc.String())
+	}
+	if !strings.Contains(asc.String(), "   0.000000 1  123             Rx   d 8 11 22 33 44 55 66 77 88\n") {
+		t.Fatalf("wrong ASC frame in\n%s", asc.String())
+	}
+	got, gotStart, err := buslog.ReadASC(&asc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotStart.Equal(start) {
+		t.Fatalf("expected start %v, got %v", start, gotStart)
+	}
+	sameFrames(t, frames, got, false)
+	if !got[2].Time.Equal(start.Add(time.Second)) {
+		t.Fatalf("expected the absolute time of the frame, got %v", got[2].Time)
+	}
+
+	// decimal identifiers, DLCs and data bytes, CAN FD frames with symbolic
+	// names and events of other buses
+	got, _, err = buslog.ReadASC(strings.NewReader(`date Fri Mar 1 13:04:05 2024
+base dec  timestamps absolute
+Begin Triggerblock Fri Mar 1 13:04:05 2024
+   0.001000 1  291             Tx   d 2 10 255  Length = 0 BitCount = 0 ID = 291
+   0.002000 CANFD   2 Rx     4660x  Engine 1 0 10 16 0 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 0 0 0 0 0 0 0 0
+   0.003000 Li1 10 Rx 1 01
+   0.004000 1 Statistic: D 0 R 0 XD 0 XR 0 E 0 O 0 B 0.00%
+End TriggerBlock
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != 291 || got[0].Direction != buslog.Tx || !bytes.Equal(got[0].Data, []byte{10, 255}) {
+		t.Fatalf("wrong decimal frames %+v", got)
+	}
+	fd := got[1]
+	if fd.ID != 4660 || !fd.Extended || !fd.FD || !fd.BitRateSwitch || fd.DLC != 10 || len(fd.Data) != 16 || fd.Data[15] != 0x0f || fd.BusChannel != 2 {
+		t.Fatalf("wrong CAN FD frame %+v", fd)
+	}
+	if !fd.Time.Equal(time.Date(2024, 3, 1, 13, 4, 5, 2000000, time.Local)) {
+		t.Fatalf("wrong time %v", fd.Time)
+	}
+
+	if _, _, err := buslog.ReadASC(strings.NewReader("0.1 1 123 Rx d 8 01 02\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected an error for missing data bytes, got %v", err)
+	}
+}
+
+func TestCandump(t *testing.T) {
+	frames := exportFrames(t)
+
+	var log bytes.Buffer
+	if err := buslog.WriteCandump(&log, frames); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	expected := []string{
+		"(0.000000) can0 123#1122334455667788",
+		"(0.500000) can1 20000000#0000000000000000",
+		"(1.000000) can0 18FEF100#AABBCC",
+		"(1.500000) can0 321#R8",
+		"(2.000000) can1 7DF#0201",
+		"(2.250000) can0 1ABCDEF0##1000102030405060708090A0B",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("wrong candump log\n%s", log.String())
+	}
+
+	got, start, err := buslog.ReadCandump(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Unix(0, 0)) {
+		t.Fatalf("expected the time of the first frame, got %v", start)
+	}
+	// candump has no direction, and frames without bus channel are written
+	// to can0
+	for i := range frames {
+		frames[i].Direction = buslog.Rx
+		frames[i].BusChannel = max(frames[i].BusChannel, 1)
+	}
+	sameFrames(t, frames, got, false)
+
+	got, _, err = buslog.ReadCandump(strings.NewReader("(1700000000.250000) vcan3 7E8#02.10.03 R\n(1700000001.000000) vcan3 7E0#R\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].BusChannel != 4 || !bytes.Equal(got[0].Data, []byte{2, 0x10, 3}) || got[1].Type != buslog.RemoteFrame || got[1].Timestamp != 0.75 {
+		t.Fatalf("wrong frames %+v", got)
+	}
+	if !got[0].Time.Equal(time.Unix(1700000000, 250000000)) {
+		t.Fatalf("wrong time %v", got[0].Time)
+	}
+
+	if _, _, err := buslog.ReadCandump(strings.NewReader("(0.1) can0 12G#00\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected an error for an invalid identifier, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package mf4
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+)
+
+// CachePolicy defines which samples are kept in memory after a channel is
+// read.
+type CachePolicy uint8
+
+const (
+	// CachePhysical keeps the converted samples of every channel read. It is
+	// the default policy, unless ReadOptions.MemoryOptimized is set.
+	CachePhysical CachePolicy = iota
+
+	// CacheNone doesn't keep samples; every read decodes the data blocks again.
+	CacheNone
+
+	// CacheRaw keeps the raw samples of every channel read. Conversions are
+	// applied on each call to Sample.
+	CacheRaw
+
+	// CacheLRU keeps the converted samples of the most recently used channels
+	// and the decompressed data blocks most recently read, up to
+	// ReadOptions.CacheLimitBytes.
+	CacheLRU
+)
+
+// sampleCache holds the samples of a channel. Raw and physical samples are
+// kept apart, and slices are copied in and out of the cache, so neither
+// conversions nor callers can modify cached values. It is guarded by a mutex
+// since channels can be read from several goroutines.
+type sampleCache struct {
+	mu sync.Mutex
+
+	//raw samples, without conversion
+	raw []interface{}
+
+	//physical samples, with conversion applied
+	physical []interface{}
+}
+
+func newSampleCache() *sampleCache {
+	return &sampleCache{}
+}
+
+func (sc *sampleCache) getRaw() ([]interface{}, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.raw == nil {
+		return nil, false
+	}
+	return slices.Clone(sc.raw), true
+}
+
+func (sc *sampleCache) getPhysical() ([]interface{}, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.physical == nil {
+		return nil, false
+	}
+	return slices.Clone(sc.physical), true
+}
+
+func (sc *sampleCache) setRaw(sample []interface{}) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.raw = slices.Clone(sample)
+}
+
+func (sc *sampleCache) setPhysical(sample []interface{}) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.physical = slices.Clone(sample)
+}
+
+// dropPhysical removes the physical samples from the cache
+func (sc *sampleCache) dropPhysical() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.physical = nil
+}
+
+// lruCache tracks the physical samples and the data blocks cached with
+// CacheLRU, evicting the least recently used entries when the limit in bytes
+// is exceeded.
+type lruCache struct {
+	mu       sync.Mutex
+	limit    int64
+	size     int64
+	order    *list.List
+	elements map[interface{}]*list.Element
+}
+
+// lruEntry is an entry of the LRU list. `key` is the *sampleCache of a
+// channel or the blockKey of a data block; `evict` removes it from its cache.
+type lruEntry struct {
+	key   interface{}
+	size  int64
+	evict func()
+}
+
+// blockKey identifies a data block in the block cache of a data group
+type blockKey struct {
+	cache   *blockCache
+	address int64
+}
+
+func newLRUCache(limit int64) *lruCache {
+	return &lruCache{
+		limit:    limit,
+		order:    list.New(),
+		elements: make(map[interface{}]*list.Element),
+	}
+}
+
+// add registers `key` as the most recently used entry holding `size` bytes
+// and evicts older entries until the cache fits in the limit. `evict` must
+// not call the LRU cache.
+func (l *lruCache) add(key interface{}, size int64, evict func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.elements[key]; ok {
+		l.size -= e.Value.(*lruEntry).size
+		l.order.Remove(e)
+	}
+	l.elements[key] = l.order.PushFront(&lruEntry{key: key, size: size, evict: evict})
+	l.size += size
+
+	for l.size > l.limit && l.order.Len() > 0 {
+		oldest := l.order.Back()
+		entry := oldest.Value.(*lruEntry)
+		l.order.Remove(oldest)
+		delete(l.elements, entry.key)
+		l.size -= entry.size
+		entry.evict()
+	}
+}
+
+// touch marks `key` as the most recently used entry
+func (l *lruCache) touch(key interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.elements[key]; ok {
+		l.order.MoveToFront(e)
+	}
+}
+
+// cachePolicy returns the policy set in ReadOptions. MemoryOptimized files
+// with the default policy don't cache samples.
+func (m *MF4) cachePolicy() CachePolicy {
+	if m.ReadOptions == nil {
+		return CachePhysical
+	}
+
+	if m.ReadOptions.CachePolicy == CachePhysical && m.ReadOptions.MemoryOptimized {
+		return CacheNone
+	}
+	return m.ReadOptions.CachePolicy
+}
+
+// cachedDataBlock returns the decompressed data block stored at `addr` in the
+// data group `d`, if it is in cache
+func (m *MF4) cachedDataBlock(d *DataGroup, addr int64) ([]byte, bool) {
+	data, ok := d.cachedBlock(addr)
+	if ok && m.lru != nil {
+		m.lru.touch(blockKey{d.cache, addr})
+	}
+	return data, ok
+}
+
+// storeDataBlock caches the decompressed data block stored at `addr` in the
+// data group `d`. Only CacheLRU keeps data blocks, within its limit, since
+// the other policies would keep every block of the file in memory.
+func (m *MF4) storeDataBlock(d *DataGroup, addr int64, data []byte) {
+	if m.cachePolicy() != CacheLRU || d.cache == nil {
+		return
+	}
+
+	size := int64(len(data))
+	if size > m.lru.limit {
+		return
+	}
+	d.storeBlock(addr, data)
+	m.lru.add(blockKey{d.cache, addr}, size, func() { d.dropBlock(addr) })
+}
+
+// storeRaw caches raw samples according to the cache policy
+func (c *Channel) storeRaw(sample []interface{}) {
+	if c.mf4.cachePolicy() == CacheRaw {
+		c.cache.setRaw(sample)
+	}
+}
+
+// storePhysical caches physical samples according to the cache policy
+func (c *Channel) storePhysical(sample []interface{}) {
+	switch c.mf4.cachePolicy() {
+	case CachePhysical:
+		c.cache.setPhysical(sample)
+	case CacheLRU:
+		size := c.sampleSize(sample)
+		if size > c.mf4.lru.limit {
+			return
+		}
+		c.cache.setPhysical(sample)
+		c.mf4.lru.add(c.cache, size, c.cache.dropPhysical)
+	}
+}
+
+// sampleSize estimates the memory used by `sample` in bytes
+func (c *Channel) sampleSize(sample []interface{}) int64 {
+	// interface header plus the value itself
+	const interfaceSize = 16
+	return int64(len(sample)) * (interfaceSize + int64(c.block.SignalBytesRange()))
+}
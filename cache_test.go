@@ -0,0 +1,192 @@
+package mf4_test
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// convertedFixture has one channel group with a time master and a uint8
+// channel with linear conversion `y = 2x + 1`
+func convertedFixture(t *testing.T, opts *mf4.ReadOptions) *mf4.MF4 {
+	f := newFixture(410)
+
+	time := f.channel("time", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	value := f.channel("value", cnData{ByteOffset: 8, BitCount: 8})
+	f.link(value, 4, f.linear(1, 2))
+
+	cg := f.block("##CG", []int64{0, f.chain(0, time, value), 0, 0, 0, 0}, encode(cgData{CycleCount: 3, DataBytes: 9}))
+	records := []byte{}
+	for i, v := range []uint8{10, 20, 30} {
+		records = append(records, float64Records([]float64{float64(i)})...)
+		records = append(records, v)
+	}
+	dt := f.block("##DT", nil, records)
+	dg := f.block("##DG", []int64{0, cg, dt, 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func checkCachedSamples(t *testing.T, cn *mf4.Channel) {
+	t.Helper()
+	for i := 0; i < 2; i++ {
+		raw, err := cn.RawSample()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if raw[0] != uint8(10) || raw[2] != uint8(30) {
+			t.Fatalf("read %d: wrong raw samples %v", i, raw)
+		}
+
+		sample, err := cn.Sample()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sample[0] != float64(21) || sample[2] != float64(61) {
+			t.Fatalf("read %d: wrong physical samples %v", i, sample)
+		}
+
+		// callers own the returned slices
+		raw[0] = uint8(0)
+		sample[0] = float64(0)
+	}
+}
+
+func TestCachePolicies(t *testing.T) {
+	policies := map[string]*mf4.ReadOptions{
+		"default":         {},
+		"memoryOptimized": {MemoryOptimized: true},
+		"none":            {CachePolicy: mf4.CacheNone},
+		"raw":             {CachePolicy: mf4.CacheRaw},
+		"physical":        {CachePolicy: mf4.CachePhysical},
+		"lru":             {CachePolicy: mf4.CacheLRU, CacheLimitBytes: 1 << 20},
+		"lruEvicting":     {CachePolicy: mf4.CacheLRU, CacheLimitBytes: 80},
+	}
+
+	for name, opts := range policies {
+		t.Run(name, func(t *testing.T) {
+			m := convertedFixture(t, opts)
+			cg := m.ChannelGroup[0]
+
+			// physical samples are read first, so a cached physical sample
+			// must not leak into RawSample
+			if _, err := cg.Channels["value"].Sample(); err != nil {
+				t.Fatal(err)
+			}
+			checkCachedSamples(t, cg.Channels["value"])
+
+			if _, err := cg.Channels["time"].Sample(); err != nil {
+				t.Fatal(err)
+			}
+			checkCachedSamples(t, cg.Channels["value"])
+		})
+	}
+}
+
+func TestUnsortedRawSample(t *testing.T) {
+	f := newFixture(410)
+
+	t1 := f.channel("t1", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	value := f.channel("value", cnData{ByteOffset: 8, BitCount: 8})
+	f.link(value, 4, f.linear(1, 2))
+	cg1 := f.block("##CG", []int64{0, f.chain(0, t1, value), 0, 0, 0, 0}, encode(cgData{RecordId: 1, CycleCount: 2, DataBytes: 9}))
+
+	t2 := f.channel("t2", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	other := f.channel("other", cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+	cg2 := f.block("##CG", []int64{0, f.chain(0, t2, other), 0, 0, 0, 0}, encode(cgData{RecordId: 2, CycleCount: 1, DataBytes: 16}))
+
+	var records []byte
+	records = append(append(append(records, 1), float64Records([]float64{0})...), 10)
+	records = append(append(records, 2), float64Records([]float64{0.5, 7})...)
+	records = append(append(append(records, 1), float64Records([]float64{1})...), 30)
+	dt := f.block("##DT", nil, records)
+
+	dgData := make([]byte, 8)
+	dgData[0] = 1
+	dg := f.block("##DG", []int64{0, f.chain(0, cg1, cg2), dt, 0}, dgData)
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{CachePolicy: mf4.CacheNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cn := m.ChannelGroup[0].Channels["value"]
+	for i := 0; i < 2; i++ {
+		sample, err := cn.Sample()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sample) != 2 || sample[0] != float64(21) || sample[1] != float64(61) {
+			t.Fatalf("read %d: wrong physical samples %v", i, sample)
+		}
+
+		raw, err := cn.RawSample()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(raw) != 2 || raw[0] != uint8(10) || raw[1] != uint8(30) {
+			t.Fatalf("read %d: conversion applied to raw samples %v", i, raw)
+		}
+	}
+}
+
+func TestCacheDataBlocks(t *testing.T) {
+	policies := map[string]struct {
+		opts   *mf4.ReadOptions
+		cached bool
+	}{
+		"physical":    {&mf4.ReadOptions{CachePolicy: mf4.CachePhysical}, false},
+		"raw":         {&mf4.ReadOptions{CachePolicy: mf4.CacheRaw}, false},
+		"lru":         {&mf4.ReadOptions{CachePolicy: mf4.CacheLRU, CacheLimitBytes: 1 << 20}, true},
+		"lruTooSmall": {&mf4.ReadOptions{CachePolicy: mf4.CacheLRU, CacheLimitBytes: 16}, false},
+	}
+
+	for name, p := range policies {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(410)
+			time := f.channel("time", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+			value := f.channel("value", cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+			cg := f.block("##CG", []int64{0, f.chain(0, time, value), 0, 0, 0, 0}, encode(cgData{CycleCount: 3, DataBytes: 16}))
+			dz := f.zipped(float64Records([]float64{0, 10}, []float64{1, 20}, []float64{2, 30}))
+			f.link(hdAddress, 0, f.block("##DG", []int64{0, cg, dz, 0}, make([]byte, 8)))
+
+			file := f.open(t)
+			m, err := mf4.ReadFile(file, p.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.ChannelGroup[0].Channels["time"].Sample(); err != nil {
+				t.Fatal(err)
+			}
+
+			// corrupt the compressed data, so only a cached block can be read
+			end := dz + int64(binary.LittleEndian.Uint64(f.buf[dz+8:]))
+			clear(f.buf[dz+48 : end])
+			if err := os.WriteFile(file.Name(), f.buf, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			sample, err := m.ChannelGroup[0].Channels["value"].Sample()
+			if !p.cached {
+				if err == nil {
+					t.Fatal("expected error for data block not in cache")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sample) != 3 || sample[2] != 30.0 {
+				t.Fatalf("wrong samples %v", sample)
+			}
+		})
+	}
+}
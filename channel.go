@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"os"
 
 	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/AT"
 	"github.com/LincolnG4/GoMDF/blocks/CC"
 	"github.com/LincolnG4/GoMDF/blocks/CG"
 	"github.com/LincolnG4/GoMDF/blocks/CN"
@@ -23,9 +23,34 @@ type ChannelGroup struct {
 	Block       *CG.Block
 	Channels    map[string]*Channel
 	DataGroup   *DG.Block
-	SourceInfo  SI.SourceInfo
-	Comment     string
 	IsVLSDBlock bool
+
+	//acquisition source and data group comment. Empty when
+	//ReadOptions.LazyMetadata is true, see GetSourceInfo and GetComment
+	SourceInfo SI.SourceInfo
+	Comment    string
+
+	//pointer to the master channel shared by all channels of the group
+	master *Channel
+
+	//source information and comment, resolved on first access
+	meta *groupMeta
+
+	//address of the CGBLOCK
+	address int64
+
+	//pointer to mf4 file
+	mf4 *MF4
+}
+
+// Master returns the master channel of the group. For groups with a remote
+// master, it is the master channel of the referenced channel group. It returns
+// 'nil' if the group has no master.
+func (cg *ChannelGroup) Master() *Channel {
+	if cg.master == nil || cg.master.block == nil {
+		return nil
+	}
+	return cg.master
 }
 
 type Channel struct {
@@ -33,7 +58,8 @@ type Channel struct {
 	Name string
 
 	//conversion formula to convert the raw values to physical values with a
-	//physical unit
+	//physical unit. Empty when ReadOptions.LazyMetadata is true, see
+	//GetConversion
 	Conversion CC.Conversion
 
 	//channel type
@@ -55,20 +81,22 @@ type Channel struct {
 	//channel group's index
 	ChannelGroupIndex int
 
-	//unsorted channels mapped
-	isUnsorted bool
+	//pointer to the channel group struct holding this channel
+	group *ChannelGroup
 
-	//describes the source of an acquisition mode or of a signal
+	//describes the source of an acquisition mode or of a signal. Empty when
+	//ReadOptions.LazyMetadata is true, see GetSourceInfo
 	SourceInfo SI.SourceInfo
 
-	//additional information about the channel. Can be 'nil'
+	//additional information about the channel. Can be 'nil'. Empty when
+	//ReadOptions.LazyMetadata is true, see GetComment
 	Comment string
 
-	//Samples are cached in memory if file was set with MemoryOptimized is true
-	CachedSamples []interface{}
+	//conversion, source information and comment, resolved on first access
+	meta *channelMeta
 
-	//Conversion applied
-	isConverted bool
+	//samples cache, shared by all copies of the channel
+	cache *sampleCache
 
 	//pointer to mf4 file
 	mf4 *MF4
@@ -76,11 +104,30 @@ type Channel struct {
 	//pointer to the CNBLOCK
 	block *CN.Block
 
-	channelReader *ChannelReader
+	//address of the CNBLOCK
+	address int64
+
+	//members of the structure, for a channel with a composition of
+	//channels
+	components []*Channel
+}
+
+// Components returns the members of the structure of the channel, for
+// instance the ID, DLC and data bytes of a CAN_DataFrame channel of a bus
+// logging file. It returns 'nil' for channels that are not structures.
+func (c *Channel) Components() []*Channel {
+	return c.components
+}
 
-	startAddress int64
+// Attachments returns the attachments referenced by the channel (MDF 4.1),
+// for instance the DBC describing the frames of a bus channel. The stream of
+// a synchronization channel is returned by SyncReference.
+func (c *Channel) Attachments() ([]AT.AttFile, error) {
+	return c.mf4.loadAttachments(c.block.Link.AtReference)
 }
 
+// ChannelReader holds the state to read one data block of a channel. A new
+// reader is created for each read, so it is never shared between goroutines.
 type ChannelReader struct {
 	//Byte order conversion (LittleEndian/BigEndian)
 	ByteOrder binary.ByteOrder
@@ -99,10 +146,19 @@ type ChannelReader struct {
 
 	//Offset in the row
 	StartOffset int64
+
+	//reader with its own offset over the MF4 file
+	file io.ReadSeeker
+
+	//channel being read
+	channel *Channel
+
+	//MeasureBuffer already holds the data block
+	loaded bool
 }
 
-func (cn *ChannelReader) loadBuffer(f *os.File) error {
-	length, err := blocks.GetLength(f, cn.DataAddress)
+func (cn *ChannelReader) loadBuffer() error {
+	length, err := blocks.GetLength(cn.file, cn.DataAddress)
 	if err != nil {
 		return err
 	}
@@ -114,7 +170,7 @@ func (cn *ChannelReader) loadBuffer(f *os.File) error {
 	return nil
 }
 
-func readBlockFromFile(f *os.File, dataAddress int64, buf []byte) error {
+func readBlockFromFile(f io.ReadSeeker, dataAddress int64, buf []byte) error {
 	var err error
 	if _, err = f.Seek(dataAddress+int64(blocks.HeaderSize), io.SeekStart); err != nil {
 		return err
@@ -127,34 +183,22 @@ func readBlockFromFile(f *os.File, dataAddress int64, buf []byte) error {
 	return nil
 }
 
-func (cn *ChannelReader) readBlockToMemory(f *os.File) error {
-	err := cn.loadBuffer(f)
+func (cn *ChannelReader) readBlockToMemory() error {
+	err := cn.loadBuffer()
 	if err != nil {
 		return err
 	}
 
-	return readBlockFromFile(f, cn.DataAddress, cn.MeasureBuffer)
-}
-
-func (cn *ChannelReader) readDatablock(f *os.File, pos int64) (interface{}, error) {
-	//check if end of datablock
-	if pos+int64(cn.SizeMeasureRow) > int64(len(cn.MeasureBuffer)) {
-		length, err := blocks.GetLength(f, cn.DataAddress)
-		if err != nil {
-			return nil, err
-		}
-
-		buf := make([]byte, length)
-		err = readBlockFromFile(f, cn.DataAddress, buf)
-		if err != nil {
-			return nil, err
-		}
-		cn.MeasureBuffer = append(cn.MeasureBuffer, buf...)
+	err = readBlockFromFile(cn.file, cn.DataAddress, cn.MeasureBuffer)
+	if err != nil {
+		return err
 	}
-	return parseSignalMeasure(cn.MeasureBuffer[pos:pos+int64(cn.SizeMeasureRow)], cn.ByteOrder, cn.DataType)
+	cn.loaded = true
+	return nil
 }
 
-func (c *Channel) loadChannelReader(addr int64) *ChannelReader {
+// newChannelReader creates the reader state for the data block at `addr`
+func (c *Channel) newChannelReader(file io.ReadSeeker, addr int64) *ChannelReader {
 	size := c.block.SignalBytesRange()
 	return &ChannelReader{
 		ByteOrder:      c.block.ByteOrder(),
@@ -163,17 +207,25 @@ func (c *Channel) loadChannelReader(addr int64) *ChannelReader {
 		DataAddress:    addr,
 		StartOffset:    int64(c.block.Data.ByteOffset),
 		RowSize:        int64(c.ChannelGroup.Data.DataBytes),
-		MeasureBuffer:  c.DataGroup.CachedDataGroup,
+		file:           file,
+		channel:        c,
 	}
 }
 
-func (c *Channel) readDataList(measure *[]interface{}) error {
-	dtl, err := DL.New(c.mf4.File, c.mf4.MdfVersion(), c.channelReader.DataAddress)
+// next returns a reader for the data block at `addr`, sharing the file
+// reader and the channel
+func (cn *ChannelReader) next(addr int64) *ChannelReader {
+	return cn.channel.newChannelReader(cn.file, addr)
+}
+
+func (cn *ChannelReader) readDataList(measure *[]interface{}) error {
+	version := cn.channel.mf4.MdfVersion()
+	dtl, err := DL.New(cn.file, version, cn.DataAddress)
 	if err != nil {
 		return err
 	}
 
-	id, err := blocks.GetHeaderID(c.mf4.File, dtl.Link.Data[0])
+	id, err := blocks.GetHeaderID(cn.file, dtl.Link.Data[0])
 	if err != nil {
 		return err
 	}
@@ -181,19 +233,14 @@ func (c *Channel) readDataList(measure *[]interface{}) error {
 	target := len(dtl.Link.Data)
 	i := 0
 	for i < target {
-		c.channelReader = c.newChannelReader(dtl.Link.Data[i])
-		if err != nil {
-			return err
-		}
-
-		err = c.extractSample(id, measure)
+		err = cn.next(dtl.Link.Data[i]).extractSample(id, measure)
 		if err != nil {
 			return err
 		}
 		i++
 
 		if i == target && dtl.Next() != 0 {
-			dtl, err = DL.New(c.mf4.File, c.mf4.MdfVersion(), dtl.Next())
+			dtl, err = DL.New(cn.file, version, dtl.Next())
 			if err != nil {
 				return err
 			}
@@ -205,45 +252,11 @@ func (c *Channel) readDataList(measure *[]interface{}) error {
 	return nil
 }
 
-func (c *Channel) newChannelReader(addr int64) *ChannelReader {
-	return c.loadChannelReader(addr)
-}
-
-// readMeasure return extract sample measure from DTBlock//DLBlock with fixed
-// lenght
-func (c *Channel) readDT(measure *[]interface{}) error {
-	var err error
-
-	if c.DataGroup.CachedDataGroup == nil {
-		err = c.channelReader.readBlockToMemory(c.mf4.File)
-		if err != nil {
-			return err
-		}
-	}
-
-	pos := c.channelReader.StartOffset
-	for i := uint64(0); i < c.ChannelGroup.Data.CycleCount; i++ {
-		if pos >= int64(len(c.channelReader.MeasureBuffer)) {
-			return nil
-		}
-
-		value, err := c.channelReader.readDatablock(c.mf4.File, pos)
-		if err != nil {
-			return err
-		}
-
-		*measure = append(*measure, value)
-		pos += c.channelReader.RowSize
-	}
-
-	return nil
-}
-
 // readMeasureFromSDBlock return extract sample measure from SDBlock or a list of SDBlocks
-func (c *Channel) readSdBlock(measure *[]interface{}) error {
+func (cn *ChannelReader) readSdBlock(measure *[]interface{}) error {
 	var err error
-	if c.DataGroup.CachedDataGroup == nil {
-		err = c.channelReader.readBlockToMemory(c.mf4.File)
+	if !cn.loaded {
+		err = cn.readBlockToMemory()
 		if err != nil {
 			return err
 		}
@@ -255,128 +268,223 @@ func (c *Channel) readSdBlock(measure *[]interface{}) error {
 		value  interface{}
 	)
 
-	length = binary.LittleEndian.Uint32(c.channelReader.MeasureBuffer[pos : pos+4])
-	pos += 4
+	for pos+4 <= int64(len(cn.MeasureBuffer)) {
+		length = binary.LittleEndian.Uint32(cn.MeasureBuffer[pos : pos+4])
+		pos += 4
 
-	value, err = parseSignalMeasure(c.channelReader.MeasureBuffer[pos:pos+int64(length)], c.channelReader.ByteOrder, c.channelReader.DataType)
-	if err != nil {
-		return err
-	}
+		if pos+int64(length) > int64(len(cn.MeasureBuffer)) {
+			return fmt.Errorf("signal data exceeds the block length")
+		}
+
+		value, err = parseSignalMeasure(cn.MeasureBuffer[pos:pos+int64(length)], cn.ByteOrder, cn.DataType)
+		if err != nil {
+			return err
+		}
+		pos += int64(length)
 
-	*measure = append(*measure, value)
+		*measure = append(*measure, value)
+	}
 	return nil
 }
 
 // extractSample returns a array with sample extracted from datablock based on
-// header id
-func (c *Channel) extractSample(id string, measure *[]interface{}) error {
-	if c.block.IsVLSD() {
-		return c.readVLSDSample(id, measure)
+// header id. Fixed length samples are read through the block index of the
+// data group, see RawSampleRange.
+func (cn *ChannelReader) extractSample(id string, measure *[]interface{}) error {
+	if !cn.channel.block.IsVLSD() {
+		return fmt.Errorf("channel %s: not a variable length channel", cn.channel.Name)
 	}
-	return c.readFixedLenghtSample(id, measure)
+	return cn.readVLSDSample(id, measure)
 }
 
-func (c *Channel) readDataZipped(measure *[]interface{}) error {
+func (cn *ChannelReader) readDataZipped(measure *[]interface{}) error {
 	var (
 		dz  *DZ.Block
 		err error
 	)
 
-	dz, err = DZ.New(c.mf4.File, c.channelReader.DataAddress)
+	dz, err = DZ.New(cn.file, cn.DataAddress)
 	if err != nil {
 		return err
 	}
 
-	c.DataGroup.CachedDataGroup, err = dz.Read()
-	if err != nil {
-		return err
+	data, ok := cn.channel.mf4.cachedDataBlock(cn.channel.DataGroup, cn.DataAddress)
+	if !ok {
+		data, err = dz.Read()
+		if err != nil {
+			return err
+		}
+		cn.channel.mf4.storeDataBlock(cn.channel.DataGroup, cn.DataAddress, data)
 	}
 
-	c.channelReader.MeasureBuffer = c.DataGroup.CachedDataGroup
-	return c.extractSample(dz.BlockTypeModified(), measure)
+	cn.MeasureBuffer = data
+	cn.loaded = true
+	return cn.extractSample(dz.BlockTypeModified(), measure)
 }
 
-func (c *Channel) readHeaderList(measure *[]interface{}) error {
+func (cn *ChannelReader) readHeaderList(measure *[]interface{}) error {
 	var (
 		hl  *HL.Block
 		err error
 	)
 
-	hl, err = HL.New(c.mf4.File, c.channelReader.DataAddress)
+	hl, err = HL.New(cn.file, cn.DataAddress)
 	if err != nil {
 		return err
 	}
 
 	id := blocks.DlID
-	c.channelReader = c.newChannelReader(hl.Link.DlFirst)
-
-	return c.extractSample(id, measure)
+	return cn.next(hl.Link.DlFirst).extractSample(id, measure)
 }
 
 // readVLSDSample extracts samples from channel type Variable Length Signal Data
 // (VLSD)
-func (c *Channel) readVLSDSample(id string, measure *[]interface{}) error {
+func (cn *ChannelReader) readVLSDSample(id string, measure *[]interface{}) error {
 	switch id {
-	case blocks.DtID:
-		fmt.Println(id)
-		return nil
 	case blocks.SdID:
-		return c.readSdBlock(measure)
+		return cn.readSdBlock(measure)
 	case blocks.DlID:
-		return c.readDataList(measure)
+		return cn.readDataList(measure)
 	case blocks.DzID:
-		fmt.Println(id)
-		return fmt.Errorf("package not ready to read this file")
+		return cn.readDataZipped(measure)
+	case blocks.HlID:
+		return cn.readHeaderList(measure)
 	case blocks.CgID:
-		return c.readSingleDataBlockVLSD()
+		return nil
 	default:
-		fmt.Println(id)
-		return fmt.Errorf("package not ready to read this file")
+		return fmt.Errorf("unsupported block %s", id)
+	}
+}
+
+// Sample returns a array with the measures of the channel applying conversion
+// block on it. The returned slice is owned by the caller; samples are cached
+// according to ReadOptions.CachePolicy.
+//
+// It is safe to call Sample concurrently, from several goroutines, on the
+// same or on different channels.
+func (c *Channel) Sample() ([]interface{}, error) {
+	if sample, ok := c.cache.getPhysical(); ok {
+		if c.mf4.lru != nil {
+			c.mf4.lru.touch(c.cache)
+		}
+		return sample, nil
+	}
+
+	// RawSample returns a copy, so the conversion never modifies the raw
+	// samples in cache
+	sample, err := c.RawSample()
+	if err != nil {
+		return nil, err
 	}
 
+	err = c.applyConversion(&sample)
+	if err != nil {
+		return nil, err
+	}
+
+	c.storePhysical(sample)
+	return sample, nil
 }
 
-// readFixedLenghtSample extracts samples from channel type Fixed Length Signal
-// Data
-func (c Channel) readFixedLenghtSample(blockID string, measure *[]interface{}) error {
-	switch blockID {
-	case blocks.DtID, blocks.DvID:
-		return c.readDT(measure)
-	case blocks.DlID:
-		return c.readDataList(measure)
-	case blocks.DzID:
-		return c.readDataZipped(measure)
-	case blocks.HlID:
-		return c.readHeaderList(measure)
+// valueLayout locates and decodes the value of a channel in a record
+type valueLayout struct {
+	//byte offset of the value in the record and number of bytes read
+	offset uint64
+	size   uint64
+
+	byteOrder binary.ByteOrder
+	dataType  interface{}
+
+	//integers that don't fill their bytes, for instance a 29 bit CAN id or a
+	//flag bit, are shifted by the bit offset and masked to the bit count
+	masked    bool
+	bitOffset uint8
+	bitCount  uint32
+}
+
+// valueLayout returns the layout of the channel value in the records of its
+// channel group
+func (c *Channel) valueLayout() valueLayout {
+	size := c.block.SignalBytesRange()
+	l := valueLayout{
+		offset:    uint64(c.block.Data.ByteOffset),
+		size:      uint64(size),
+		byteOrder: c.block.ByteOrder(),
+		dataType:  c.block.LoadDataType(int(size)),
+		bitOffset: c.block.Data.BitOffset,
+		bitCount:  c.block.Data.BitCount,
+	}
+
+	switch c.block.DataType() {
+	case CN.UnsignedIntegerLE, CN.UnsignedIntegerBE, CN.SignedIntegerLE, CN.SignedIntegerBE:
 	default:
-		fmt.Println(blockID)
-		return fmt.Errorf("package not ready to read this file")
+		return l
 	}
+	if l.bitOffset == 0 && l.bitCount == uint32(binary.Size(l.dataType))*8 {
+		return l
+	}
+
+	// integer type holding the bit count
+	width := 8
+	for width < 64 && uint32(width) < l.bitCount {
+		width *= 2
+	}
+	l.masked = true
+	l.size = (uint64(l.bitOffset) + uint64(l.bitCount) + 7) / 8
+	l.dataType = c.block.LoadDataType(width / 8)
+	return l
 }
 
-// Sample returns a array with the measures of the channel applying conversion
-// block on it
-func (c *Channel) Sample() ([]interface{}, error) {
-	var sample []interface{}
-	var err error
+// decode returns the value in `record`
+func (l valueLayout) decode(record []byte) (interface{}, error) {
+	data := record[l.offset : l.offset+l.size]
+	if !l.masked {
+		return parseSignalMeasure(data, l.byteOrder, l.dataType)
+	}
+	if uint32(l.bitOffset)+l.bitCount > 64 {
+		return nil, fmt.Errorf("unsupported integer of %d bits at bit offset %d", l.bitCount, l.bitOffset)
+	}
 
-	if c.CachedSamples != nil {
-		if !c.isConverted {
-			c.applyConversion(&c.CachedSamples)
+	var v uint64
+	if l.byteOrder == binary.LittleEndian {
+		for i := len(data) - 1; i >= 0; i-- {
+			v = v<<8 | uint64(data[i])
+		}
+	} else {
+		for _, b := range data {
+			v = v<<8 | uint64(b)
 		}
-		return c.CachedSamples, nil
+	}
+	v >>= l.bitOffset
+	if l.bitCount < 64 {
+		v &= 1<<l.bitCount - 1
 	}
 
-	sample, err = c.RawSample()
-	if err != nil {
-		return nil, err
+	switch l.dataType.(type) {
+	case uint8:
+		return uint8(v), nil
+	case uint16:
+		return uint16(v), nil
+	case uint32:
+		return uint32(v), nil
+	case uint64:
+		return v, nil
 	}
 
-	c.applyConversion(&sample)
-	if !c.mf4.IsMemoryOptimized() {
-		c.CachedSamples = sample
+	// sign extension
+	if l.bitCount > 0 && l.bitCount < 64 && v&(1<<(l.bitCount-1)) != 0 {
+		v |= ^uint64(0) << l.bitCount
+	}
+	switch l.dataType.(type) {
+	case int8:
+		return int8(v), nil
+	case int16:
+		return int16(v), nil
+	case int32:
+		return int32(v), nil
+	default:
+		return int64(v), nil
 	}
-	return sample, nil
 }
 
 func parseSignalMeasure(data []byte, byteOrder binary.ByteOrder, dataType interface{}) (interface{}, error) {
@@ -434,62 +542,63 @@ func parseSignalMeasure(data []byte, byteOrder binary.ByteOrder, dataType interf
 	}
 }
 
-func (c *Channel) LoadDataAdress() {
+// dataAddress returns the address of the data block holding the channel
+// values
+func (c *Channel) dataAddress() int64 {
 	if c.block.Link.Data != 0 {
-		c.startAddress = c.block.Link.Data
-	} else {
-		c.startAddress = c.DataGroup.DataAddress()
+		return c.block.Link.Data
 	}
+	return c.DataGroup.DataAddress()
 }
 
 // RawSample returns a array with the measures of the channel not applying
 // conversion block on it
 func (c *Channel) RawSample() ([]interface{}, error) {
-	c.LoadDataAdress()
+	if c.block.IsVirtualMaster() {
+		return c.virtualMasterSample(), nil
+	}
 
-	if c.block.Link.Data != 0 {
-		c.startAddress = c.block.Link.Data
-	} else {
-		c.startAddress = c.DataGroup.DataAddress()
+	if sample, ok := c.cache.getRaw(); ok {
+		return sample, nil
+	}
+
+	if !c.block.IsVLSD() || !c.DataGroup.block.IsSorted() {
+		measure, err := c.RawSampleRange(0, c.ChannelGroup.Data.CycleCount)
+		if err != nil {
+			return nil, err
+		}
+
+		c.storeRaw(measure)
+		return measure, nil
 	}
 
-	id, err := blocks.GetHeaderID(c.mf4.File, c.startAddress)
+	file := c.mf4.reader()
+	addr := c.dataAddress()
+
+	id, err := blocks.GetHeaderID(file, addr)
 	if err != nil {
 		return nil, err
 	}
 
-	c.channelReader = c.newChannelReader(c.startAddress)
-
 	measure := make([]interface{}, 0, c.ChannelGroup.Data.CycleCount)
-	err = c.extractSample(id, &measure)
+	err = c.newChannelReader(file, addr).extractSample(id, &measure)
 	if err != nil {
 		return nil, err
 	}
 
+	c.storeRaw(measure)
 	return measure, err
 }
 
-func (c *Channel) loadDataBlockAddressDataList(cnReader *ChannelReader, i int) (int64, error) {
-	dtl, err := DL.New(c.mf4.File, c.mf4.MdfVersion(), cnReader.DataAddress)
-	if err != nil {
-		return -1, err
+// virtualMasterSample returns the zero-based record indexes used as raw
+// values by virtual master channels
+func (c *Channel) virtualMasterSample() []interface{} {
+	n := c.ChannelGroup.Data.CycleCount
+	measure := make([]interface{}, n)
+	for i := uint64(0); i < n; i++ {
+		measure[i] = i
 	}
-	return dtl.Link.Data[i], nil
-}
-
-// readSingleDataBlock returns measure from DTBlock
-// func (c *Channel) readSingleDataBlock() ([]interface{}, error) {
-// 	return c.readSingleDT()
-// }
-
-// readSingleDataBlock returns measure from DTBlock
-func (c *Channel) readSingleDataBlockVLSD() error {
-	return nil
-}
-
-// readDataList returns measure from DLBlock
-func (c *Channel) readDL(addr int64) ([]interface{}, error) {
-	return c.readDL(addr)
+	return measure
 }
 
 // signalValueAddress returns the offset from the signal in the DTBlock
@@ -502,16 +611,30 @@ func (c *Channel) datablockAddress(dataAddress int64) int64 {
 	return dataAddress
 }
 
-func (c *Channel) applyConversion(sample *[]interface{}) {
-	if c.Conversion == nil {
-		return
+func (c *Channel) applyConversion(sample *[]interface{}) error {
+	conversion, err := c.GetConversion()
+	if err != nil {
+		return err
 	}
 
-	c.Conversion.Apply(sample)
-	c.isConverted = true
+	if conversion == nil {
+		return nil
+	}
+
+	// only text conversions apply to string and byte array channels
+	if !c.block.IsNumeric() {
+		switch conversion.(type) {
+		case *CC.TextValue, *CC.TextText:
+		default:
+			return nil
+		}
+	}
+
+	conversion.Apply(sample)
+	return nil
 }
 
-func (c *Channel) readInvalidationBit(file *os.File) (bool, error) {
+func (c *Channel) readInvalidationBit(file io.ReadSeeker) (bool, error) {
 	address := c.getInvalidationBitStart()
 
 	if _, err := file.Seek(address, io.SeekCurrent); err != nil {
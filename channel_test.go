@@ -1,7 +1,9 @@
 package mf4_test
 
 import (
+	"fmt"
 	"os"
+	"sync"
 	"testing"
 
 	mf4 "github.com/LincolnG4/GoMDF"
@@ -57,3 +59,95 @@ func TestDataList(t *testing.T) {
 		}
 	}
 }
+
+func TestConcurrentChannelReads(t *testing.T) {
+	for _, path := range []string{"./samples/ASAP2_Demo_V171.mf4", "./samples/Discrete_deflate.mf4", "./samples/sample_compressed.mf4"} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		// sequential read as reference, printed so NaN values compare equal
+		reference, _ := mf4.ReadFile(file, &mf4.ReadOptions{MemoryOptimized: true})
+		expected := make([]string, len(reference.Channels))
+		for i := range reference.Channels {
+			sample, err := reference.Channels[i].Sample()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected[i] = fmt.Sprint(sample)
+		}
+
+		m, _ := mf4.ReadFile(file, &mf4.ReadOptions{})
+		var wg sync.WaitGroup
+		for i := range m.Channels {
+			for k := 0; k < 2; k++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					result, err := m.Channels[i].Sample()
+					if err != nil {
+						t.Errorf("%s: %v", m.Channels[i].Name, err)
+						return
+					}
+					if fmt.Sprint(result) != expected[i] {
+						t.Errorf("%s: concurrent read differs from sequential read", m.Channels[i].Name)
+					}
+				}(i)
+			}
+		}
+		wg.Wait()
+	}
+}
+
+func TestReadChannelsConcurrently(t *testing.T) {
+	file, _ := os.Open("./samples/ASAP2_Demo_V171.mf4")
+	defer file.Close()
+	m, _ := mf4.ReadFile(file, &mf4.ReadOptions{})
+
+	names := []string{"ASAM.M.SCALAR.SBYTE.IDENTICAL.DISCRETE", "ASAM.M.SCALAR.UBYTE.HYPERBOLIC", "ASAM.M.SCALAR.FLOAT64.IDENTICAL"}
+	result, err := m.ReadChannels(names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range names {
+		if len(result[name]) == 0 {
+			t.Fatalf("channel %s not read", name)
+		}
+	}
+	if len(result[names[0]]) != len(DataListTestCase.Sample) {
+		t.Fatalf("expected %d samples, got %d", len(DataListTestCase.Sample), len(result[names[0]]))
+	}
+
+	if _, err := m.ReadChannels("missing"); err == nil {
+		t.Fatal("expected error for missing channel")
+	}
+}
+
+func TestBitFieldChannels(t *testing.T) {
+	f := newFixture(410)
+	cn := f.chain(0,
+		// signed 12 bits after a 4 bit nibble, little endian
+		f.channel("signed", cnData{DataType: 2, BitOffset: 4, BitCount: 12}),
+		// unsigned 10 bits, big endian
+		f.channel("motorola", cnData{DataType: 1, ByteOffset: 2, BitOffset: 2, BitCount: 10}),
+	)
+	cg := f.block("##CG", []int64{0, cn, 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 4}))
+	// -3 << 4 | 0x5, and 0x2a5 << 2 | 0x3
+	dg := f.block("##DG", []int64{0, cg, f.block("##DT", nil, []byte{0xd5, 0xff, 0x0a, 0x97}), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]interface{}{"signed": int16(-3), "motorola": uint16(0x2a5)} {
+		sample, err := m.ChannelGroup[0].Channels[name].Sample()
+		if err != nil || len(sample) != 1 || sample[0] != expected {
+			t.Fatalf("channel %s: expected %v, got %v %v", name, expected, sample, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package mf4_test
+
+import (
+	"fmt"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// textTableFixture has a value to text channel "gear", whose default converts
+// values with `y = 2x`, a value range to text channel "level" and a value to
+// text channel "state" without default
+func textTableFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	gearCC := f.table(7, []float64{1, 2}, f.text("one"), f.text("two"), f.linear(0, 2))
+	gear := f.channel("gear", cnData{DataType: 0, BitCount: 8})
+	f.link(gear, 4, gearCC)
+
+	levelCC := f.table(8, []float64{0, 9, 10, 19}, f.text("low"), f.text("high"), f.text("overflow"))
+	level := f.channel("level", cnData{DataType: 0, ByteOffset: 1, BitCount: 8})
+	f.link(level, 4, levelCC)
+
+	stateCC := f.table(7, []float64{0}, f.text("off"), 0)
+	state := f.channel("state", cnData{DataType: 0, ByteOffset: 2, BitCount: 8})
+	f.link(state, 4, stateCC)
+
+	cg := f.block("##CG", []int64{0, f.chain(0, gear, level, state), 0, 0, 0, 0}, encode(cgData{CycleCount: 3, DataBytes: 3}))
+	dt := f.block("##DT", nil, []byte{1, 5, 0, 2, 15, 1, 3, 30, 0})
+	dg := f.block("##DG", []int64{0, cg, dt, 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestTextTableConversions(t *testing.T) {
+	m := textTableFixture(t)
+
+	for name, expected := range map[string]string{
+		"gear":  "[one two 6]",
+		"level": "[low high overflow]",
+		"state": "[off 1 off]",
+	} {
+		sample, err := m.GetChannelSample(0, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(sample) != expected {
+			t.Fatalf("%s: expected %s, got %v", name, expected, sample)
+		}
+	}
+}
+
+// stringFixture has two string channels: "name" with a linear conversion, and
+// "mode" with a text to text conversion
+func stringFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	name := f.channel("name", cnData{DataType: 7, BitCount: 16})
+	f.link(name, 4, f.linear(1, 2))
+
+	modeCC := f.table(10, nil, f.text("ab"), f.text("auto"), f.text("manual"))
+	mode := f.channel("mode", cnData{DataType: 7, ByteOffset: 2, BitCount: 16})
+	f.link(mode, 4, modeCC)
+
+	cg := f.block("##CG", []int64{0, f.chain(0, name, mode), 0, 0, 0, 0}, encode(cgData{CycleCount: 2, DataBytes: 4}))
+	dt := f.block("##DT", nil, []byte("abababcd"))
+	dg := f.block("##DG", []int64{0, cg, dt, 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestStringChannelConversions(t *testing.T) {
+	m := stringFixture(t)
+
+	for name, expected := range map[string]string{
+		"name": "[ab ab]",
+		"mode": "[auto manual]",
+	} {
+		sample, err := m.GetChannelSample(0, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(sample) != expected {
+			t.Fatalf("%s: expected %s, got %v", name, expected, sample)
+		}
+	}
+}
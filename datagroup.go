@@ -1,25 +1,81 @@
 package mf4
 
 import (
-	"os"
+	"io"
+	"sync"
 
 	"github.com/LincolnG4/GoMDF/blocks/DG"
 )
 
 type DataGroup struct {
-	block           *DG.Block
-	ChannelGroup    []*ChannelGroup
-	CachedDataGroup []byte
+	block        *DG.Block
+	ChannelGroup []*ChannelGroup
+
+	//decompressed data blocks, shared by the channels of the data group
+	cache *blockCache
+
+	//block index, built on first use
+	index *indexState
+
+	//records sorted by channel group, for unsorted data groups
+	sorted *sortState
+
+	//address of the DGBLOCK
+	address int64
 }
 
-func NewDataGroup(f *os.File, address int64) DataGroup {
+// blockCache keeps decompressed data blocks by address, see
+// MF4.storeDataBlock. It is guarded by a mutex since channels of the same
+// data group can be read concurrently.
+type blockCache struct {
+	mu     sync.RWMutex
+	blocks map[int64][]byte
+}
+
+func NewDataGroup(f io.ReadSeeker, address int64) DataGroup {
 	dataGroupBlock := DG.New(f, address)
 	return DataGroup{
 		block:        dataGroupBlock,
 		ChannelGroup: []*ChannelGroup{},
+		cache:        &blockCache{blocks: make(map[int64][]byte)},
+		index:        &indexState{},
+		sorted:       &sortState{},
+		address:      address,
 	}
 }
 
 func (d *DataGroup) DataAddress() int64 {
 	return d.block.Link.Data
 }
+
+// cachedBlock returns the decompressed data block stored at `addr`, if it was
+// already read
+func (d *DataGroup) cachedBlock(addr int64) ([]byte, bool) {
+	if d.cache == nil {
+		return nil, false
+	}
+
+	d.cache.mu.RLock()
+	defer d.cache.mu.RUnlock()
+	data, ok := d.cache.blocks[addr]
+	return data, ok
+}
+
+// storeBlock caches the decompressed data block stored at `addr`
+func (d *DataGroup) storeBlock(addr int64, data []byte) {
+	if d.cache == nil {
+		return
+	}
+
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	// limit the capacity so appending to the cached slice always copies it
+	d.cache.blocks[addr] = data[:len(data):len(data)]
+}
+
+// dropBlock removes the data block stored at `addr` from the cache
+func (d *DataGroup) dropBlock(addr int64) {
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	delete(d.cache.blocks, addr)
+}
@@ -0,0 +1,305 @@
+// Package dbc parses CAN databases in the DBC format and decodes the signals
+// of CAN frames: messages, signals with their scaling, multiplexed signals,
+// value tables, extended identifiers and J1939 parameter groups. Extended
+// multiplexing (SG_MUL_VAL_) is not supported.
+package dbc
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+)
+
+// Database is a CAN database
+type Database struct {
+	Version string
+
+	//comment of the database
+	Comment string
+
+	//messages, in the order of the file
+	Messages []*Message
+
+	//value tables defined with VAL_TABLE_, by name
+	ValueTables map[string]map[int64]string
+
+	//the database describes a J1939 network
+	J1939 bool
+
+	//messages by identifier and by J1939 parameter group number
+	byID  map[uint32]*Message
+	byPGN map[uint32]*Message
+}
+
+// Message is a CAN message, or J1939 parameter group
+type Message struct {
+	//identifier, without the extended flag
+	ID       uint32
+	Extended bool
+
+	Name string
+
+	//number of data bytes
+	Size uint32
+
+	Transmitter string
+	Comment     string
+	Signals     []*Signal
+
+	//the message is a J1939 parameter group, matched by its parameter group
+	//number regardless of the priority and source address of the frames
+	J1939 bool
+
+	//the signal selecting the multiplexed signals of a frame, or 'nil'
+	Multiplexer *Signal
+}
+
+// ValueType is the type of the raw value of a signal
+type ValueType uint8
+
+const (
+	Integer ValueType = iota
+	Float32
+	Float64
+)
+
+// Signal is a signal of a message
+type Signal struct {
+	Name string
+
+	//start bit and length in bits. The start bit of big endian (Motorola)
+	//signals is their most significant bit.
+	StartBit     uint32
+	Length       uint32
+	LittleEndian bool
+	Signed       bool
+	ValueType    ValueType
+
+	//physical value = raw value * Factor + Offset
+	Factor float64
+	Offset float64
+
+	Min  float64
+	Max  float64
+	Unit string
+
+	Receivers []string
+	Comment   string
+
+	//texts of raw values, from VAL_
+	ValueTable map[int64]string
+
+	//the signal is the multiplexer of its message
+	IsMultiplexer bool
+
+	//the signal is only in the frames whose multiplexer has MultiplexValue
+	Multiplexed    bool
+	MultiplexValue uint64
+}
+
+// ParseFile parses the DBC file at `path`
+func ParseFile(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// ParseAttachment parses a DBC embedded in, or referenced by, an attachment of
+// an MF4 file
+func ParseAttachment(at AT.AttFile) (*Database, error) {
+	r, err := at.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	db, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("attachment %s: %w", at.Name, err)
+	}
+	return db, nil
+}
+
+// Message returns the message of the frames with identifier `id`, or 'nil'.
+// J1939 messages match frames with the same parameter group number.
+func (db *Database) Message(id uint32, extended bool) *Message {
+	if m, ok := db.byID[key(id, extended)]; ok {
+		return m
+	}
+	if extended {
+		if m, ok := db.byPGN[PGN(id)]; ok {
+			return m
+		}
+	}
+	return nil
+}
+
+// MessageByName returns the message `name`, or 'nil'
+func (db *Database) MessageByName(name string) *Message {
+	for _, m := range db.Messages {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// index builds the lookup tables of the messages
+func (db *Database) index() {
+	db.byID = make(map[uint32]*Message)
+	db.byPGN = make(map[uint32]*Message)
+	for _, m := range db.Messages {
+		db.byID[key(m.ID, m.Extended)] = m
+		if m.Extended && (m.J1939 || db.J1939) {
+			m.J1939 = true
+			db.byPGN[m.PGN()] = m
+		}
+	}
+}
+
+// key returns the lookup key of an identifier, with the extended flag
+func key(id uint32, extended bool) uint32 {
+	if extended {
+		return id | extendedFlag
+	}
+	return id
+}
+
+// extendedFlag is the bit of message identifiers set in DBC files for
+// extended identifiers
+const extendedFlag = 1 << 31
+
+// PGN returns the J1939 parameter group number of the 29 bit identifier
+// `id`. The destination address of PDU1 groups is not part of the number.
+func PGN(id uint32) uint32 {
+	pf := (id >> 16) & 0xff
+	pgn := (id >> 8) & 0x3ff00
+	if pf >= 240 {
+		pgn |= (id >> 8) & 0xff
+	}
+	return pgn
+}
+
+// PGN returns the J1939 parameter group number of the message
+func (m *Message) PGN() uint32 {
+	return PGN(m.ID)
+}
+
+// Signal returns the signal `name` of the message, or 'nil'
+func (m *Message) Signal(name string) *Signal {
+	for _, s := range m.Signals {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Decode returns the physical values of the signals in the data of a frame
+// of the message. Multiplexed signals are only decoded when the multiplexer
+// selects them, and signals beyond the data are skipped.
+func (m *Message) Decode(data []byte) map[string]float64 {
+	r := make(map[string]float64, len(m.Signals))
+	var mux uint64
+	hasMux := false
+	if m.Multiplexer != nil {
+		mux, hasMux = m.Multiplexer.Raw(data)
+	}
+
+	for _, s := range m.Signals {
+		if s.Multiplexed && (!hasMux || mux != s.MultiplexValue) {
+			continue
+		}
+		if v, ok := s.Decode(data); ok {
+			r[s.Name] = v
+		}
+	}
+	return r
+}
+
+// Raw returns the raw bits of the signal in `data`, and `false` if the data is
+// too short
+func (s *Signal) Raw(data []byte) (uint64, bool) {
+	if s.Length == 0 || s.Length > 64 {
+		return 0, false
+	}
+
+	var v uint64
+	if s.LittleEndian {
+		if (s.StartBit+s.Length+7)/8 > uint32(len(data)) {
+			return 0, false
+		}
+		for i := uint32(0); i < s.Length; i++ {
+			pos := s.StartBit + i
+			v |= uint64(data[pos/8]>>(pos%8)&1) << i
+		}
+		return v, true
+	}
+
+	// big endian bits go from the most significant bit down each byte, then
+	// to the most significant bit of the next byte
+	pos := s.StartBit
+	for i := uint32(0); i < s.Length; i++ {
+		if pos/8 >= uint32(len(data)) {
+			return 0, false
+		}
+		v = v<<1 | uint64(data[pos/8]>>(pos%8)&1)
+		if pos%8 == 0 {
+			pos += 15
+		} else {
+			pos--
+		}
+	}
+	return v, true
+}
+
+// RawValue returns the raw value of the signal in `data`: the integer, sign
+// extended for signed signals, or the floating point number
+func (s *Signal) RawValue(data []byte) (float64, bool) {
+	v, ok := s.Raw(data)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case s.ValueType == Float32 && s.Length == 32:
+		return float64(math.Float32frombits(uint32(v))), true
+	case s.ValueType == Float64 && s.Length == 64:
+		return math.Float64frombits(v), true
+	case s.Signed && s.Length < 64 && v&(1<<(s.Length-1)) != 0:
+		return float64(int64(v | ^uint64(0)<<s.Length)), true
+	case s.Signed:
+		return float64(int64(v)), true
+	default:
+		return float64(v), true
+	}
+}
+
+// Decode returns the physical value of the signal in `data`, and `false` if
+// the data is too short
+func (s *Signal) Decode(data []byte) (float64, bool) {
+	v, ok := s.RawValue(data)
+	if !ok {
+		return 0, false
+	}
+	return v*s.Factor + s.Offset, true
+}
+
+// PhysicalValueTable returns the texts of the value table of the signal by
+// physical value, or 'nil' if the signal has no value table
+func (s *Signal) PhysicalValueTable() map[float64]string {
+	if len(s.ValueTable) == 0 {
+		return nil
+	}
+
+	r := make(map[float64]string, len(s.ValueTable))
+	for raw, text := range s.ValueTable {
+		r[float64(raw)*s.Factor+s.Offset] = text
+	}
+	return r
+}
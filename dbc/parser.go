@@ -0,0 +1,616 @@
+package dbc
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// token is a word, number, string or punctuation of a DBC file
+type token struct {
+	text string
+
+	//the token is a quoted string, without the quotes
+	quoted bool
+
+	line int
+}
+
+// lex splits a DBC file in tokens
+func lex(src string) ([]token, error) {
+	tokens := make([]token, 0, len(src)/4)
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '"':
+			start := line
+			var b strings.Builder
+			i++
+			for ; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				if src[i] == '\n' {
+					line++
+				}
+				b.WriteByte(src[i])
+			}
+			if i == len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", start)
+			}
+			i++
+			tokens = append(tokens, token{text: b.String(), quoted: true, line: start})
+		case isDigit(c) || (c == '-' || c == '+' || c == '.') && i+1 < len(src) && (isDigit(src[i+1]) || src[i+1] == '.'):
+			j := i + 1
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.') {
+				j++
+			}
+			// exponent
+			if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+				k := j + 1
+				if k < len(src) && (src[k] == '-' || src[k] == '+') {
+					k++
+				}
+				if k < len(src) && isDigit(src[k]) {
+					for j = k; j < len(src) && isDigit(src[j]); j++ {
+					}
+				}
+			}
+			tokens = append(tokens, token{text: src[i:j], line: line})
+			i = j
+		case isWord(c):
+			j := i + 1
+			for j < len(src) && (isWord(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			tokens = append(tokens, token{text: src[i:j], line: line})
+			i = j
+		default:
+			tokens = append(tokens, token{text: src[i : i+1], line: line})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parser reads the statements of a DBC file
+type parser struct {
+	tokens []token
+	pos    int
+	db     *Database
+
+	//messages by identifier with the extended flag, as written in the file
+	messages map[uint32]*Message
+
+	//attribute definitions of messages with their enumeration values
+	enums map[string][]string
+}
+
+// Parse parses a DBC database
+func Parse(r io.Reader) (*Database, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := lex(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		tokens:   tokens,
+		db:       &Database{Messages: make([]*Message, 0), ValueTables: make(map[string]map[int64]string)},
+		messages: make(map[uint32]*Message),
+		enums:    make(map[string][]string),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	p.db.index()
+	return p.db, nil
+}
+
+// independentSignals is the identifier of the pseudo message holding the
+// signals not assigned to a message
+const independentSignals = 0xc0000000
+
+// parse reads all the statements
+func (p *parser) parse() error {
+	var message *Message
+	for !p.done() {
+		t := p.next()
+		var err error
+		switch t.text {
+		case "VERSION":
+			p.db.Version = p.next().text
+		case "NS_":
+			// list of the keywords of the file, up to the bit timing section
+			for !p.done() && p.peek().text != "BS_" {
+				p.next()
+			}
+		case "BO_":
+			message, err = p.message()
+		case "SG_":
+			if message == nil {
+				return fmt.Errorf("line %d: signal outside of a message", t.line)
+			}
+			err = p.signal(message)
+		case "CM_":
+			err = p.comment()
+		case "VAL_TABLE_":
+			err = p.valueTable()
+		case "VAL_":
+			err = p.values()
+		case "BA_DEF_":
+			err = p.attributeDefinition()
+		case "BA_":
+			err = p.attribute()
+		case "SIG_VALTYPE_":
+			err = p.valueType()
+		default:
+			// statements without signals, like BU_ or BA_DEF_DEF_, end with
+			// the line or with a semicolon
+			p.skipStatement(t)
+		}
+		if err != nil {
+			return err
+		}
+		if t.text != "BO_" && t.text != "SG_" {
+			message = nil
+		}
+	}
+	return nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return t
+}
+
+// expect reads the punctuation or keyword `s`
+func (p *parser) expect(s string) error {
+	t := p.next()
+	if t.text != s || t.quoted {
+		return p.errorf(t, "expected %q, got %q", s, t.text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	if t.line == 0 && len(p.tokens) > 0 {
+		t.line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) uint() (uint64, error) {
+	t := p.next()
+	v, err := strconv.ParseUint(t.text, 10, 64)
+	if err != nil {
+		return 0, p.errorf(t, "expected an unsigned integer, got %q", t.text)
+	}
+	return v, nil
+}
+
+func (p *parser) int() (int64, error) {
+	t := p.next()
+	v, err := strconv.ParseInt(t.text, 10, 64)
+	if err != nil {
+		// large unsigned values of value tables
+		u, uerr := strconv.ParseUint(t.text, 10, 64)
+		if uerr != nil {
+			return 0, p.errorf(t, "expected an integer, got %q", t.text)
+		}
+		v = int64(u)
+	}
+	return v, nil
+}
+
+func (p *parser) float() (float64, error) {
+	t := p.next()
+	v, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return 0, p.errorf(t, "expected a number, got %q", t.text)
+	}
+	return v, nil
+}
+
+func (p *parser) string() (string, error) {
+	t := p.next()
+	if !t.quoted {
+		return "", p.errorf(t, "expected a string, got %q", t.text)
+	}
+	return t.text, nil
+}
+
+// skipStatement skips the statement starting with `t`, up to its semicolon
+// or the end of its line
+func (p *parser) skipStatement(t token) {
+	for !p.done() {
+		n := p.peek()
+		if n.line != t.line && !n.quoted && isKeyword(n.text) {
+			return
+		}
+		p.next()
+		if n.text == ";" && !n.quoted {
+			return
+		}
+	}
+}
+
+// isKeyword reports whether `s` starts a statement
+func isKeyword(s string) bool {
+	return strings.HasSuffix(s, "_") || s == "VERSION"
+}
+
+// skipTo skips the tokens up to the semicolon ending the statement
+func (p *parser) skipTo() {
+	for !p.done() {
+		if t := p.next(); t.text == ";" && !t.quoted {
+			return
+		}
+	}
+}
+
+// message reads BO_ <id> <name>: <size> <transmitter>
+func (p *parser) message() (*Message, error) {
+	id, err := p.uint()
+	if err != nil {
+		return nil, err
+	}
+	name := p.next()
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	size, err := p.uint()
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{
+		ID:       uint32(id) &^ extendedFlag,
+		Extended: id&extendedFlag != 0,
+		Name:     name.text,
+		Size:     uint32(size),
+		Signals:  make([]*Signal, 0),
+	}
+	if t := p.peek(); t.line == name.line && !t.quoted && !isKeyword(t.text) {
+		m.Transmitter = p.next().text
+	}
+
+	if id != independentSignals {
+		p.db.Messages = append(p.db.Messages, m)
+		p.messages[uint32(id)] = m
+	}
+	return m, nil
+}
+
+// signal reads SG_ <name> [M|m<n>] : <start>|<length>@<order><sign>
+// (<factor>,<offset>) [<min>|<max>] "<unit>" <receivers>
+func (p *parser) signal(m *Message) error {
+	name := p.next()
+	s := &Signal{Name: name.text}
+
+	if t := p.peek(); t.text != ":" {
+		p.next()
+		switch {
+		case t.text == "M":
+			s.IsMultiplexer = true
+			m.Multiplexer = s
+		case strings.HasPrefix(t.text, "m"):
+			// m<n>, or m<n>M for extended multiplexing
+			v, err := strconv.ParseUint(strings.TrimSuffix(t.text[1:], "M"), 10, 64)
+			if err != nil {
+				return p.errorf(t, "invalid multiplexer indicator %q", t.text)
+			}
+			s.Multiplexed = true
+			s.MultiplexValue = v
+		default:
+			return p.errorf(t, "invalid multiplexer indicator %q", t.text)
+		}
+	}
+
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	start, err := p.uint()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("|"); err != nil {
+		return err
+	}
+	length, err := p.uint()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("@"); err != nil {
+		return err
+	}
+	order := p.next()
+	sign := p.next()
+	if (order.text != "0" && order.text != "1") || (sign.text != "+" && sign.text != "-") {
+		return p.errorf(order, "invalid byte order and sign %s%s", order.text, sign.text)
+	}
+	s.StartBit, s.Length = uint32(start), uint32(length)
+	s.LittleEndian = order.text == "1"
+	s.Signed = sign.text == "-"
+
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	if s.Factor, err = p.float(); err != nil {
+		return err
+	}
+	if err := p.expect(","); err != nil {
+		return err
+	}
+	if s.Offset, err = p.float(); err != nil {
+		return err
+	}
+	if err := p.expect(")"); err != nil {
+		return err
+	}
+	if err := p.expect("["); err != nil {
+		return err
+	}
+	if s.Min, err = p.float(); err != nil {
+		return err
+	}
+	if err := p.expect("|"); err != nil {
+		return err
+	}
+	if s.Max, err = p.float(); err != nil {
+		return err
+	}
+	if err := p.expect("]"); err != nil {
+		return err
+	}
+	if s.Unit, err = p.string(); err != nil {
+		return err
+	}
+
+	for t := p.peek(); !p.done() && t.line == name.line && !t.quoted; t = p.peek() {
+		p.next()
+		if t.text != "," {
+			s.Receivers = append(s.Receivers, t.text)
+		}
+	}
+
+	m.Signals = append(m.Signals, s)
+	return nil
+}
+
+// signalOf reads the identifier of a message and the name of one of its
+// signals, and returns the signal or 'nil' if it isn't in the database
+func (p *parser) signalOf() (*Signal, error) {
+	id, err := p.uint()
+	if err != nil {
+		return nil, err
+	}
+	name := p.next()
+	m, ok := p.messages[uint32(id)]
+	if !ok {
+		return nil, nil
+	}
+	return m.Signal(name.text), nil
+}
+
+// comment reads CM_ [BU_ <node> | BO_ <id> | SG_ <id> <signal> | EV_ <var>]
+// "<comment>";
+func (p *parser) comment() error {
+	var target *string
+	switch p.peek().text {
+	case "BO_":
+		p.next()
+		id, err := p.uint()
+		if err != nil {
+			return err
+		}
+		if m, ok := p.messages[uint32(id)]; ok {
+			target = &m.Comment
+		}
+	case "SG_":
+		p.next()
+		s, err := p.signalOf()
+		if err != nil {
+			return err
+		}
+		if s != nil {
+			target = &s.Comment
+		}
+	case "BU_", "EV_":
+		p.next()
+		p.next()
+	default:
+		target = &p.db.Comment
+	}
+
+	text, err := p.string()
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		*target = text
+	}
+	return p.expect(";")
+}
+
+// table reads <value> "<text>" pairs up to the semicolon
+func (p *parser) table() (map[int64]string, error) {
+	r := make(map[int64]string)
+	for !p.done() && p.peek().text != ";" {
+		v, err := p.int()
+		if err != nil {
+			return nil, err
+		}
+		text, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		r[v] = text
+	}
+	return r, p.expect(";")
+}
+
+// valueTable reads VAL_TABLE_ <name> <value> "<text>" ... ;
+func (p *parser) valueTable() error {
+	name := p.next()
+	table, err := p.table()
+	if err != nil {
+		return err
+	}
+	p.db.ValueTables[name.text] = table
+	return nil
+}
+
+// values reads VAL_ <id> <signal> <value> "<text>" ... ;, or the values of an
+// environment variable, which are skipped
+func (p *parser) values() error {
+	if _, err := strconv.ParseUint(p.peek().text, 10, 64); err != nil {
+		p.skipTo()
+		return nil
+	}
+	s, err := p.signalOf()
+	if err != nil {
+		return err
+	}
+
+	// reference to a value table
+	if t := p.peek(); !t.quoted && t.text != ";" && !isNumber(t.text) {
+		p.next()
+		if s != nil {
+			s.ValueTable = p.db.ValueTables[t.text]
+		}
+		return p.expect(";")
+	}
+
+	table, err := p.table()
+	if err != nil {
+		return err
+	}
+	if s != nil {
+		s.ValueTable = table
+	}
+	return nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// attributeDefinition reads BA_DEF_ [BU_|BO_|SG_|EV_] "<name>" <type> ... ;
+// and keeps the values of enumerations, to read the attributes of messages
+func (p *parser) attributeDefinition() error {
+	if t := p.peek(); !t.quoted {
+		p.next()
+	}
+	name, err := p.string()
+	if err != nil {
+		return err
+	}
+	if p.peek().text == "ENUM" {
+		p.next()
+		values := make([]string, 0)
+		for !p.done() && p.peek().text != ";" {
+			if t := p.next(); t.quoted {
+				values = append(values, t.text)
+			}
+		}
+		p.enums[name] = values
+	}
+	p.skipTo()
+	return nil
+}
+
+// attribute reads BA_ "<name>" [BU_ <node> | BO_ <id> | SG_ <id> <signal> |
+// EV_ <var>] <value>; and keeps the ones describing J1939 networks: the
+// ProtocolType of the database and the VFrameFormat of messages
+func (p *parser) attribute() error {
+	name, err := p.string()
+	if err != nil {
+		return err
+	}
+
+	switch p.peek().text {
+	case "BO_":
+		p.next()
+		id, err := p.uint()
+		if err != nil {
+			return err
+		}
+		value := p.next()
+		if m, ok := p.messages[uint32(id)]; ok && name == "VFrameFormat" {
+			m.J1939 = p.enumValue(name, value) == "J1939PG"
+		}
+	case "BU_", "SG_", "EV_":
+	default:
+		value := p.next()
+		if name == "ProtocolType" {
+			p.db.J1939 = value.text == "J1939"
+		}
+	}
+	p.skipTo()
+	return nil
+}
+
+// enumValue returns the text of the value of the enumeration attribute
+// `name`, given by its index or its text
+func (p *parser) enumValue(name string, value token) string {
+	if value.quoted {
+		return value.text
+	}
+	i, err := strconv.Atoi(value.text)
+	if values := p.enums[name]; err == nil && i >= 0 && i < len(values) {
+		return values[i]
+	}
+	return value.text
+}
+
+// valueType reads SIG_VALTYPE_ <id> <signal> : <type>;
+func (p *parser) valueType() error {
+	s, err := p.signalOf()
+	if err != nil {
+		return err
+	}
+	if p.peek().text == ":" {
+		p.next()
+	}
+	v, err := p.uint()
+	if err != nil {
+		return err
+	}
+	if s != nil {
+		s.ValueType = ValueType(v)
+	}
+	return p.expect(";")
+}
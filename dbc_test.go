@@ -0,0 +1,227 @@
+package mf4_test
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/buslog"
+	"github.com/LincolnG4/GoMDF/dbc"
+)
+
+// vehicleDBC describes the data frames of canFixture: a multiplexed message,
+// a J1939 parameter group with another source address and an OBD request
+const vehicleDBC = `VERSION "1.2"
+
+NS_ :
+	NS_DESC_
+	CM_
+	BA_DEF_
+	VAL_
+	SIG_VALTYPE_
+
+BS_:
+
+BU_: ECU Dash
+
+VAL_TABLE_ Gears 0 "neutral" 1 "first" 2 "second" ;
+
+BO_ 291 Status: 8 ECU
+ SG_ Mode M : 0|8@1+ (1,0) [0|255] "" Dash
+ SG_ Speed m17 : 8|16@1+ (0.1,0) [0|6553.5] "km/h" Dash
+ SG_ Gear m34 : 8|8@1+ (1,0) [0|15] "" Dash
+ SG_ Temp : 39|8@0- (1,-40) [-40|215] "degC" Dash,ECU
+ SG_ Counter : 51|12@0+ (1,0) [0|4095] "" Dash
+
+BO_ 2566844926 CCVS: 8 Vector__XXX
+ SG_ WheelSpeed : 8|16@1+ (0.00390625,0) [0|250.996] "km/h" Vector__XXX
+ SG_ Brake : 24|2@1+ (1,0) [0|3] "" Vector__XXX
+
+BO_ 2015 Request: 2 Dash
+ SG_ Service : 8|8@1+ (1,0) [0|255] "" ECU
+ SG_ Level : 0|32@1- (1,0) [0|0] "" ECU
+
+CM_ "vehicle network";
+CM_ BU_ ECU "engine controller";
+CM_ BO_ 291 "status of the engine";
+CM_ SG_ 291 Temp "coolant
+temperature";
+BA_DEF_ BO_ "VFrameFormat" ENUM "StandardCAN","ExtendedCAN","reserved","J1939PG";
+BA_DEF_DEF_ "VFrameFormat" "StandardCAN";
+BA_ "VFrameFormat" BO_ 2566844926 3;
+VAL_ 291 Mode 17 "speed" 34 "gear" ;
+VAL_ 291 Gear Gears ;
+SIG_VALTYPE_ 2015 Level : 1;
+`
+
+func parseVehicleDBC(t *testing.T) *dbc.Database {
+	t.Helper()
+	db, err := dbc.Parse(strings.NewReader(vehicleDBC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestParseDBC(t *testing.T) {
+	db := parseVehicleDBC(t)
+	if db.Version != "1.2" || db.Comment != "vehicle network" || len(db.Messages) != 3 || db.J1939 {
+		t.Fatalf("wrong database %+v", db)
+	}
+	if db.ValueTables["Gears"][2] != "second" {
+		t.Fatalf("wrong value tables %v", db.ValueTables)
+	}
+
+	status := db.Message(0x123, false)
+	if status == nil || status.Name != "Status" || status.Size != 8 || status.Transmitter != "ECU" || status.Comment != "status of the engine" {
+		t.Fatalf("wrong message %+v", status)
+	}
+	if db.Message(0x123, true) != nil {
+		t.Fatal("expected no extended message 0x123")
+	}
+	if status.Multiplexer != status.Signal("Mode") || status.Multiplexer.ValueTable[17] != "speed" {
+		t.Fatalf("wrong multiplexer %+v", status.Multiplexer)
+	}
+	gear := status.Signal("Gear")
+	if !gear.Multiplexed || gear.MultiplexValue != 34 || gear.ValueTable[1] != "first" {
+		t.Fatalf("wrong multiplexed signal %+v", gear)
+	}
+	temp := status.Signal("Temp")
+	if temp.LittleEndian || !temp.Signed || temp.Offset != -40 || temp.Unit != "degC" || temp.Comment != "coolant\ntemperature" || len(temp.Receivers) != 2 {
+		t.Fatalf("wrong signal %+v", temp)
+	}
+
+	// J1939 parameter groups match any priority and source address
+	ccvs := db.MessageByName("CCVS")
+	if !ccvs.Extended || ccvs.ID != 0x18fef1fe || !ccvs.J1939 || ccvs.PGN() != 0xfef1 {
+		t.Fatalf("wrong J1939 message %+v", ccvs)
+	}
+	if db.Message(0x0cfef100, true) != ccvs || dbc.PGN(0x18ea00f9) != 0xea00 {
+		t.Fatal("expected a match by parameter group number")
+	}
+	if db.MessageByName("Request").Signal("Level").ValueType != dbc.Float32 {
+		t.Fatal("expected a float signal")
+	}
+
+	j1939, err := dbc.Parse(strings.NewReader(`BA_DEF_ "ProtocolType" STRING ;
+BA_ "ProtocolType" "J1939";
+BO_ 2364540158 EEC1: 8 Vector__XXX
+`))
+	if err != nil || !j1939.J1939 || j1939.Message(0x0cf00400, true) == nil {
+		t.Fatalf("expected a J1939 database %v", err)
+	}
+
+	if _, err := dbc.Parse(strings.NewReader("BO_ 1 A: 8 X\n SG_ B : 0|8@2+ (1,0) [0|0] \"\" X\n")); err == nil {
+		t.Fatal("expected an error for an invalid byte order")
+	}
+}
+
+func TestDecodeSignals(t *testing.T) {
+	status := parseVehicleDBC(t).MessageByName("Status")
+	values := status.Decode([]byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88})
+	expected := map[string]float64{"Mode": 17, "Speed": 1309, "Temp": 45, "Counter": 0x788}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, values)
+	}
+	for name, v := range expected {
+		if math.Abs(values[name]-v) > 1e-9 {
+			t.Fatalf("%s: expected %f, got %f", name, v, values[name])
+		}
+	}
+	if v, _ := status.Signal("Temp").Decode([]byte{0, 0, 0, 0, 0xf6}); v != -50 {
+		t.Fatalf("expected a negative temperature, got %f", v)
+	}
+
+	level := binary.LittleEndian.AppendUint32(nil, math.Float32bits(1.5))
+	if v, ok := parseVehicleDBC(t).MessageByName("Request").Signal("Level").Decode(level); !ok || v != 1.5 {
+		t.Fatalf("expected 1.5, got %f", v)
+	}
+}
+
+func TestDecodeCAN(t *testing.T) {
+	m := canFixture(t)
+	frames, err := buslog.ReadCAN(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups := buslog.DecodeCAN(frames, parseVehicleDBC(t))
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		names[i] = g.Name()
+	}
+	if strings.Join(names, " ") != "CAN1.Status CAN1.Status.m17 CAN1.CCVS CAN2.Request" {
+		t.Fatalf("wrong signal groups %v", names)
+	}
+
+	timestamps, speed := groups[2].Signal("WheelSpeed")
+	if len(timestamps) != 1 || timestamps[0] != 1 || speed[0] != 0xccbb/256.0 {
+		t.Fatalf("wrong wheel speed %v %v", timestamps, speed)
+	}
+	// the brake signal is beyond the 3 data bytes of the frame
+	if _, brake := groups[2].Signal("Brake"); !math.IsNaN(brake[0]) {
+		t.Fatalf("expected NaN, got %f", brake[0])
+	}
+
+	decoded, err := buslog.DecodeFile(m, parseVehicleDBC(t), filepath.Join(t.TempDir(), "decoded.mf4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decoded.File.Close()
+
+	if len(decoded.ChannelGroup) != 4 || decoded.ChannelGroup[0].GetName() != "CAN1.Status" {
+		t.Fatalf("wrong decoded groups %d", len(decoded.ChannelGroup))
+	}
+	temp := decoded.ChannelGroup[0].Channels["Temp"]
+	sample, err := temp.Sample()
+	if err != nil || len(sample) != 1 || sample[0] != 45.0 {
+		t.Fatalf("wrong temperature %v %v", sample, err)
+	}
+	if temp.GetUnit() != "degC" || temp.GetComment() != "coolant\ntemperature" {
+		t.Fatalf("wrong unit %q or comment %q", temp.GetUnit(), temp.GetComment())
+	}
+	mode, err := decoded.ChannelGroup[0].Channels["Mode"].Sample()
+	if err != nil || mode[0] != "speed" {
+		t.Fatalf("expected the text of the multiplexer, got %v %v", mode, err)
+	}
+	master, err := decoded.ChannelGroup[3].Channels["Service"].MasterValues()
+	if err != nil || len(master) != 1 || master[0] != 2 {
+		t.Fatalf("wrong timestamps %v %v", master, err)
+	}
+}
+
+func TestFindDBC(t *testing.T) {
+	m := canFixture(t)
+	if _, err := buslog.FindDBC(m); err == nil {
+		t.Fatal("expected no DBC")
+	}
+
+	path := filepath.Join(t.TempDir(), "attached.mf4")
+	e, err := m.EditCopy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddAttachment("vehicle.DBC", strings.NewReader(vehicleDBC), mf4.AttachmentOptions{Compress: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := buslog.FindDBC(reopen(t, path))
+	if err != nil || db.MessageByName("CCVS") == nil {
+		t.Fatalf("expected the attached DBC %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "vehicle.dbc")
+	if err := os.WriteFile(file, []byte(vehicleDBC), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if db, err := dbc.ParseFile(file); err != nil || len(db.Messages) != 3 {
+		t.Fatalf("expected the DBC file %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package mf4
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/FH"
+	"github.com/LincolnG4/GoMDF/blocks/MD"
+)
+
+// Tool identification written in the file history of edited files
+const (
+	ToolID      string = "GoMDF"
+	ToolVendor  string = "LincolnG4"
+	ToolVersion string = "1.0"
+)
+
+// Editor writes changes to an MF4 file, or to an edited copy of it. The
+// blocks of the original file are kept; changed blocks are appended at the end
+// of the file and the links pointing to them are updated, and a file history
+// entry records the change. Changes are written by Close.
+type Editor struct {
+	mf4 *MF4
+	w   *rewriter
+
+	//header comment, loaded on first change
+	header *MD.HDComment
+
+	//channel comments by channel address, loaded on first change
+	channels map[int64]*MD.CNComment
+
+	//original text of the edited comments, by address of their block
+	originals map[int64]string
+
+	//channel units by channel address
+	units map[int64]string
+
+	//attachment list, loaded on first change
+	attachments []editedAttachment
+
+	//description of the changes, for the file history
+	changes []string
+
+	//user written in the file history entry
+	UserName string
+
+	//keep only the blocks still referenced in the edited file, dropping for
+	//instance replaced comments and removed attachments
+	Compact bool
+}
+
+// Edit starts the edition of the file being read. New blocks are appended at
+// the end of the file and links are patched in place, so the file is not
+// rewritten. The MF4 must be read again to see the changes.
+func (m *MF4) Edit() (*Editor, error) {
+	w, err := openRewriter(m)
+	if err != nil {
+		return nil, err
+	}
+	return m.newEditor(w), nil
+}
+
+// EditCopy starts the edition of a copy of the file at `path`, leaving the
+// file being read unchanged. The file being read can't be overwritten.
+func (m *MF4) EditCopy(path string) (*Editor, error) {
+	w, err := newRewriter(m, path)
+	if err != nil {
+		return nil, err
+	}
+	return m.newEditor(w), nil
+}
+
+func (m *MF4) newEditor(w *rewriter) *Editor {
+	return &Editor{
+		mf4:       m,
+		w:         w,
+		channels:  make(map[int64]*MD.CNComment),
+		originals: make(map[int64]string),
+		units:     make(map[int64]string),
+	}
+}
+
+// Header returns the header comment of the edited file, to be changed in
+// place. It is written on Close.
+func (e *Editor) Header() *MD.HDComment {
+	if e.header == nil {
+		e.originals[blocks.IdblockSize] = e.mf4.GetMeasureComment()
+		e.header = e.mf4.HeaderMeta()
+		e.changes = append(e.changes, "header comment")
+	}
+	return e.header
+}
+
+// Channel returns the comment of the channel in the edited file, to be changed
+// in place. It is written on Close.
+func (e *Editor) Channel(c *Channel) (*MD.CNComment, error) {
+	if c.mf4 != e.mf4 || c.address == 0 {
+		return nil, fmt.Errorf("channel %s is not in the edited file", c.Name)
+	}
+
+	if _, ok := e.channels[c.address]; !ok {
+		e.originals[c.address] = c.GetComment()
+		e.channels[c.address] = c.Meta()
+		e.changes = append(e.changes, "comment of channel "+c.Name)
+	}
+	return e.channels[c.address], nil
+}
+
+// SetHeaderProperty sets the common property `name` of the header comment,
+// for instance a test id, the vehicle VIN or the driver
+func (e *Editor) SetHeaderProperty(name, value string) {
+	header := e.Header()
+	if header.Properties == nil {
+		header.Properties = &MD.CommonProperties{}
+	}
+	header.Properties.Set(name, value)
+}
+
+// SetChannelProperty sets the common property `name` of the comment of the
+// channel
+func (e *Editor) SetChannelProperty(c *Channel, name, value string) error {
+	meta, err := e.Channel(c)
+	if err != nil {
+		return err
+	}
+
+	if meta.Properties == nil {
+		meta.Properties = &MD.CommonProperties{}
+	}
+	meta.Properties.Set(name, value)
+	return nil
+}
+
+// SetChannelUnit sets the physical unit of the channel. It is written on
+// Close.
+func (e *Editor) SetChannelUnit(c *Channel, unit string) error {
+	if c.mf4 != e.mf4 || c.address == 0 {
+		return fmt.Errorf("channel %s is not in the edited file", c.Name)
+	}
+
+	if _, ok := e.units[c.address]; !ok {
+		e.changes = append(e.changes, "unit of channel "+c.Name)
+	}
+	e.units[c.address] = unit
+	return nil
+}
+
+// Close writes the changes and a file history entry describing them, then
+// closes the edited file, compacting it if Compact is set
+func (e *Editor) Close() error {
+	if err := e.write(); err != nil {
+		e.w.abort()
+		return err
+	}
+	if err := e.w.close(); err != nil {
+		return err
+	}
+	if e.Compact {
+		return compactFile(e.w.path)
+	}
+	return nil
+}
+
+// Abort discards the edited copy, or the changes of the file edited in place
+func (e *Editor) Abort() {
+	e.w.abort()
+}
+
+func (e *Editor) write() error {
+	if e.header != nil {
+		md, err := e.appendComment(blocks.IdblockSize, e.header)
+		if err != nil {
+			return err
+		}
+		// hd_md_comment
+		if err := e.w.setLink(blocks.IdblockSize, 5, md); err != nil {
+			return err
+		}
+	}
+
+	addresses := make([]int64, 0, len(e.channels))
+	for addr := range e.channels {
+		addresses = append(addresses, addr)
+	}
+	slices.Sort(addresses)
+	for _, addr := range addresses {
+		md, err := e.appendComment(addr, e.channels[addr])
+		if err != nil {
+			return err
+		}
+		// cn_md_comment
+		if err := e.w.setLink(addr, 7, md); err != nil {
+			return err
+		}
+	}
+
+	addresses = addresses[:0]
+	for addr := range e.units {
+		addresses = append(addresses, addr)
+	}
+	slices.Sort(addresses)
+	for _, addr := range addresses {
+		tx, err := e.appendText(e.units[addr])
+		if err != nil {
+			return err
+		}
+		// cn_md_unit
+		if err := e.w.setLink(addr, 6, tx); err != nil {
+			return err
+		}
+	}
+
+	if err := e.writeAttachments(); err != nil {
+		return err
+	}
+
+	if len(e.changes) == 0 {
+		return nil
+	}
+	return e.appendHistory("Updated " + strings.Join(e.changes, ", "))
+}
+
+// appendComment appends an MDBLOCK with the comment `v` of the block at
+// `addr`, patched into its original XML
+func (e *Editor) appendComment(addr int64, v MD.Schema) (int64, error) {
+	data, err := MD.Patch(e.originals[addr], v)
+	if err != nil {
+		return 0, err
+	}
+	return e.w.appendBlock(blocks.MdID, nil, data)
+}
+
+// appendHistory appends an FHBLOCK at the end of the file history
+func (e *Editor) appendHistory(description string) error {
+	fh, err := e.w.appendFileHistory(description, e.UserName)
+	if err != nil {
+		return err
+	}
+
+	// the last block of the history, or hd_fh_first
+	last, index := blocks.IdblockSize, 1
+	file := e.mf4.reader()
+	for next := e.mf4.getFileHistory(); next != 0; {
+		block, err := FH.New(file, next)
+		if err != nil {
+			return err
+		}
+		last, index = next, 0
+		next = block.Next()
+	}
+	return e.w.setLink(last, index, fh)
+}
+
+// appendFileHistory appends an FHBLOCK describing a change made now by
+// `userName`
+func (w *rewriter) appendFileHistory(description, userName string) (int64, error) {
+	md, err := MD.Encode(&MD.FHComment{
+		TX:          description,
+		ToolID:      ToolID,
+		ToolVendor:  ToolVendor,
+		ToolVersion: ToolVersion,
+		UserName:    userName,
+	})
+	if err != nil {
+		return 0, err
+	}
+	comment, err := w.appendBlock(blocks.MdID, nil, md)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	_, offset := now.Zone()
+	data := encodeBlockData(FH.Data{
+		TimeNS:      uint64(now.UnixNano()),
+		TZOffsetMin: int16(offset / 60),
+		TimeFlags:   blocks.TimeOffsetsValidFlag,
+	})
+	return w.appendBlock(blocks.FhID, []int64{0, comment}, data)
+}
@@ -0,0 +1,180 @@
+package mf4_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+func reopen(t *testing.T, path string) *mf4.MF4 {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	m, err := mf4.ReadFile(file, &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestEditProperties(t *testing.T) {
+	m := metadataFixture(t)
+	path := filepath.Join(t.TempDir(), "edited.mf4")
+
+	e, err := m.EditCopy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.UserName = "bench"
+	e.SetHeaderProperty("vin", "WVW999")
+	e.SetHeaderProperty("author", "John")
+	if err := e.SetChannelProperty(m.ChannelGroup[0].Channels["rpm"], "sensor", "hall"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := reopen(t, path)
+	meta := edited.HeaderMeta()
+	if meta.Author() != "John" || meta.Property("vin") != "WVW999" || meta.Project() != "P42" {
+		t.Fatalf("wrong header properties %+v", meta.Properties)
+	}
+	if meta.TX != "Test drive" || meta.Properties.Tree("vehicle").Value("vin") != "WVW123" {
+		t.Fatalf("header comment not kept %+v", meta)
+	}
+
+	cn := edited.ChannelGroup[0].Channels["rpm"].Meta()
+	if cn.TX != "engine speed" || cn.Properties.Value("sensor") != "hall" {
+		t.Fatalf("wrong channel comment %+v", cn)
+	}
+	if edited.ChannelGroup[0].Channels["speed"].Meta().DisplayName != "Vehicle.Speed" {
+		t.Fatal("unchanged channel comment lost")
+	}
+
+	log := edited.ReadChangeLog()
+	if len(log) != 1 || !strings.Contains(log[0], "Updated header comment, comment of channel rpm") || !strings.Contains(log[0], "bench") {
+		t.Fatalf("wrong file history %v", log)
+	}
+
+	// the original file is unchanged
+	if m.HeaderMeta().Author() != "Jane" || len(m.ReadChangeLog()) != 0 {
+		t.Fatal("original file changed")
+	}
+}
+
+func TestEditInPlace(t *testing.T) {
+	m := metadataFixture(t)
+	path := m.File.Name()
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := m.Edit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetHeaderProperty("vin", "WVW999")
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) <= len(before) {
+		t.Fatal("no block appended")
+	}
+
+	// only hd_md_comment and hd_fh_first are patched in the original blocks
+	links := []int{int(hdAddress) + 24 + 5*8, int(hdAddress) + 24 + 8}
+	for i := range before {
+		patched := false
+		for _, l := range links {
+			patched = patched || i >= l && i < l+8
+		}
+		if !patched && before[i] != after[i] {
+			t.Fatalf("original byte %d changed", i)
+		}
+	}
+
+	edited := reopen(t, path)
+	if edited.HeaderMeta().Property("vin") != "WVW999" || len(edited.ReadChangeLog()) != 1 {
+		t.Fatal("changes not written in place")
+	}
+}
+
+func TestEditKeepsVendorData(t *testing.T) {
+	f := newFixture(410)
+	comment := `<CNcomment xmlns="http://www.asam.net/mdf/v4"><TX xml:lang="en">speed</TX>` +
+		`<PR><vendor id="7">raw</vendor></PR>` +
+		`<common_properties><e name="sensor">hall</e></common_properties>` +
+		`<names><display>Vehicle.Speed</display></names></CNcomment>`
+	speed := f.channel("speed", cnData{DataType: 4, BitCount: 64})
+	f.link(speed, 7, f.metadata(comment))
+	cg := f.block("##CG", []int64{0, speed, 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 8}))
+	dg := f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records([]float64{1})), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "edited.mf4")
+	e, err := m.EditCopy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetChannelProperty(m.ChannelGroup[0].Channels["speed"], "position", "front"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the common properties are encoded again
+	expected := strings.Replace(comment, `</e></common_properties>`, `</e><e name="position">front</e></common_properties>`, 1)
+	got := strings.TrimRight(reopen(t, path).ChannelGroup[0].Channels["speed"].GetComment(), "\x00")
+	if got != expected {
+		t.Fatalf("expected comment\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestEditDisplayName(t *testing.T) {
+	m := metadataFixture(t)
+	path := filepath.Join(t.TempDir(), "edited.mf4")
+
+	e, err := m.EditCopy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, display := range map[string]string{"speed": "Vehicle.Velocity", "rpm": "Engine.Speed"} {
+		meta, err := e.Channel(m.ChannelGroup[0].Channels[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta.DisplayName = display
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := reopen(t, path)
+	speed := edited.ChannelGroup[0].Channels["speed"].Meta()
+	if speed.DisplayName != "Vehicle.Velocity" || speed.LinkerName != "vSpeed" {
+		t.Fatalf("wrong speed comment %+v", speed)
+	}
+	rpm := edited.ChannelGroup[0].Channels["rpm"].Meta()
+	if rpm.DisplayName != "Engine.Speed" || rpm.TX != "engine speed" {
+		t.Fatalf("wrong rpm comment %+v", rpm)
+	}
+}
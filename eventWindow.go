@@ -0,0 +1,116 @@
+package mf4
+
+import (
+	"fmt"
+
+	"github.com/LincolnG4/GoMDF/blocks/EV"
+)
+
+// EventWindow holds the samples of channels in a window around an event, or
+// between the beginning and the end of a range
+type EventWindow struct {
+	Event *Event
+
+	//bounds of the window in the sync domain of the event, in seconds,
+	//radians or meters. Angles and distances include the start angle and
+	//start distance of the measurement, as master values do.
+	Start float64
+	End   float64
+
+	//names of the channels, in the order they were requested
+	Channels []string
+
+	//master values and samples of each channel in the window
+	Axis    [][]float64
+	Samples [][]interface{}
+}
+
+// SamplesAroundEvent returns the samples of `channels` from `before` to
+// `after` the position of the event, in the sync domain of the event, for
+// instance seconds around a trigger. The masters of the channels must be in
+// the same domain as the event.
+func (m *MF4) SamplesAroundEvent(ev *Event, before, after float64, channels ...string) (*EventWindow, error) {
+	if before < 0 || after < 0 {
+		return nil, fmt.Errorf("invalid window %f before and %f after event %s", before, after, ev.Name)
+	}
+	return m.eventWindow(ev, ev.Position-before, ev.Position+after, channels)
+}
+
+// SamplesInEventRange returns the samples of `channels` between the beginning
+// and the end of the range of `ev`, which can be either of them
+func (m *MF4) SamplesInEventRange(ev *Event, channels ...string) (*EventWindow, error) {
+	begin, end := ev, ev.RangeEnd
+	if ev.Block.Data.RangeType == EV.EndRange {
+		begin, end = ev.Range, ev
+	}
+	if begin == nil || end == nil {
+		return nil, fmt.Errorf("event %s is not part of a complete range", ev.Name)
+	}
+
+	w, err := m.eventWindow(begin, begin.Position, end.Position, channels)
+	if err != nil {
+		return nil, err
+	}
+	w.Event = ev
+	return w, nil
+}
+
+func (m *MF4) eventWindow(ev *Event, start, end float64, channels []string) (*EventWindow, error) {
+	offset := m.syncOffset(ev.SyncType)
+	start, end = start+offset, end+offset
+
+	w := &EventWindow{
+		Event:    ev,
+		Start:    start,
+		End:      end,
+		Channels: channels,
+		Axis:     make([][]float64, len(channels)),
+		Samples:  make([][]interface{}, len(channels)),
+	}
+
+	for i, name := range channels {
+		cn, err := m.eventChannel(ev, name)
+		if err != nil {
+			return nil, err
+		}
+
+		w.Axis[i], w.Samples[i], err = cn.SampleWindow(start, end)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// eventChannel returns the channel `name` whose master is in the sync domain
+// of `ev`. Channels of the same name can be in several channel groups, for
+// instance sampled over time and over angle.
+func (m *MF4) eventChannel(ev *Event, name string) (*Channel, error) {
+	found := false
+	for _, cg := range m.ChannelGroup {
+		cn, ok := cg.Channels[name]
+		if !ok {
+			continue
+		}
+		if cn.MasterDomain() == ev.SyncType {
+			return cn, nil
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("channel %s doens't exist", name)
+	}
+	return nil, fmt.Errorf("channel %s has no %s master for event %s", name, ev.SyncType, ev.Name)
+}
+
+// Aligned returns the samples of all channels on the master values of the
+// window merged. Each value is the last sample of the channel at or before
+// the master value, 'nil' before its first sample in the window.
+func (w *EventWindow) Aligned() ([]float64, [][]interface{}) {
+	axis := mergeAxes(w.Axis, w.Start, w.End)
+	samples := make([][]interface{}, len(w.Samples))
+	for i := range w.Samples {
+		samples[i] = holdSamples(axis, w.Axis[i], w.Samples[i])
+	}
+	return axis, samples
+}
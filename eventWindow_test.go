@@ -0,0 +1,159 @@
+package mf4_test
+
+import (
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks"
+)
+
+// windowFixture has "speed" sampled every 0.5 s and "gear" every second, from
+// 0 to 5 s, a trigger at 2.5 s and a range from 1 s to 3 s
+func windowFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	group := func(name string, period float64, count int) int64 {
+		master := f.channel("t_"+name, cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+		value := f.channel(name, cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+		cg := f.block("##CG", []int64{0, f.chain(0, master, value), 0, 0, 0, 0}, encode(cgData{CycleCount: uint64(count), DataBytes: 16}))
+
+		rows := make([][]float64, count)
+		for i := range rows {
+			rows[i] = []float64{float64(i) * period, float64(i)}
+		}
+		return f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records(rows...)), 0}, make([]byte, 8))
+	}
+	f.link(hdAddress, 0, f.chain(0, group("speed", 0.5, 11), group("gear", 1, 6)))
+
+	end := f.block("##EV", []int64{0, 0, 0, f.text("test"), 0}, encode(evData{RangeType: 2, SyncType: 1, SyncBaseValue: 3, SyncFactor: 1}))
+	begin := f.block("##EV", []int64{end, 0, 0, f.text("test"), 0}, encode(evData{RangeType: 1, SyncType: 1, SyncBaseValue: 1, SyncFactor: 1}))
+	f.link(end, 2, begin)
+	trigger := f.block("##EV", []int64{begin, 0, 0, f.text("DTC"), 0}, encode(evData{Type: 5, SyncType: 1, SyncBaseValue: 25, SyncFactor: 0.1}))
+	f.link(hdAddress, 4, trigger)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSamplesAroundEvent(t *testing.T) {
+	m := windowFixture(t)
+	events, err := m.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := m.SamplesAroundEvent(events[0], 1, 0.5, "speed", "gear")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Start != 1.5 || w.End != 3 {
+		t.Fatalf("wrong window %f %f", w.Start, w.End)
+	}
+	if len(w.Samples[0]) != 4 || w.Samples[0][0] != 3.0 || w.Samples[0][3] != 6.0 {
+		t.Fatalf("wrong speed window %v %v", w.Axis[0], w.Samples[0])
+	}
+	if len(w.Samples[1]) != 2 || w.Axis[1][0] != 2 || w.Samples[1][1] != 3.0 {
+		t.Fatalf("wrong gear window %v %v", w.Axis[1], w.Samples[1])
+	}
+
+	axis, samples := w.Aligned()
+	expectedGear := []interface{}{nil, 2.0, 2.0, 3.0}
+	if len(axis) != 4 || axis[0] != 1.5 {
+		t.Fatalf("wrong aligned axis %v", axis)
+	}
+	for i, v := range expectedGear {
+		if samples[1][i] != v {
+			t.Fatalf("aligned gear %d: expected %v, got %v", i, v, samples[1][i])
+		}
+	}
+}
+
+func TestSamplesAroundAngleEvent(t *testing.T) {
+	m := angleFixture(t)
+	events, err := m.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the start angle of 1.5 rad applies to the event and to the master
+	w, err := m.SamplesAroundEvent(events[0], 0, 0.5, "torque")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Start != 2 || w.End != 2.5 {
+		t.Fatalf("wrong window %f %f", w.Start, w.End)
+	}
+	if len(w.Axis[0]) != 2 || w.Axis[0][0] != 2 || w.Samples[0][0] != 20.0 || w.Samples[0][1] != 30.0 {
+		t.Fatalf("wrong torque window %v %v", w.Axis[0], w.Samples[0])
+	}
+
+	axis, sample, err := m.ChannelGroup[0].Channels["torque"].SampleWindow(1.5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(axis) != 2 || axis[0] != 1.5 || axis[1] != 2 || sample[1] != 20.0 {
+		t.Fatalf("wrong sample window %v %v", axis, sample)
+	}
+}
+
+func TestSamplesInEventRange(t *testing.T) {
+	m := windowFixture(t)
+	events, err := m.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ev := range events[1:] {
+		w, err := m.SamplesInEventRange(ev, "gear")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Event != ev || len(w.Samples[0]) != 3 || w.Samples[0][0] != 1.0 || w.Samples[0][2] != 3.0 {
+			t.Fatalf("wrong range window %v", w.Samples[0])
+		}
+	}
+
+	if _, err := m.SamplesInEventRange(events[0], "gear"); err == nil {
+		t.Fatal("expected error for point event")
+	}
+}
+
+func TestSamplesAroundEventSameName(t *testing.T) {
+	f := newFixture(410)
+
+	group := func(syncType uint8, rows ...[]float64) int64 {
+		master := f.channel("master", cnData{Type: 2, SyncType: syncType, DataType: 4, BitCount: 64})
+		value := f.channel("torque", cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+		cg := f.block("##CG", []int64{0, f.chain(0, master, value), 0, 0, 0, 0}, encode(cgData{CycleCount: uint64(len(rows)), DataBytes: 16}))
+		return f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records(rows...)), 0}, make([]byte, 8))
+	}
+	f.link(hdAddress, 0, f.chain(0,
+		group(1, []float64{0, 1}, []float64{1, 2}),
+		group(2, []float64{0, 10}, []float64{1, 20})))
+	f.link(hdAddress, 4, f.block("##EV", []int64{0, 0, 0, f.text("TDC"), 0}, encode(evData{SyncType: 2, SyncBaseValue: 5, SyncFactor: 0.1})))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := m.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := m.SamplesAroundEvent(events[0], 0.5, 0.5, "torque")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Samples[0]) != 2 || w.Samples[0][0] != 10.0 || w.Samples[0][1] != 20.0 {
+		t.Fatalf("wrong angle torque window %v", w.Samples[0])
+	}
+
+	events[0].SyncType = blocks.DistanceSyncDomain
+	if _, err := m.SamplesAroundEvent(events[0], 0.5, 0.5, "torque"); err == nil {
+		t.Fatal("expected error for channel without distance master")
+	}
+}
@@ -0,0 +1,95 @@
+package mf4
+
+import (
+	"time"
+
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+	"github.com/LincolnG4/GoMDF/blocks/EV"
+)
+
+// Event is an event of the file, for instance a trigger or a marker, with
+// its references to other events, channels and attachments resolved
+type Event struct {
+	*EV.Event
+
+	//parent event, or 'nil'
+	Parent *Event
+
+	//for the end of a range, the event beginning the range, or 'nil'
+	Range *Event
+
+	//for the beginning of a range, the event ending the range, or 'nil'
+	RangeEnd *Event
+
+	//channels and channel groups the event applies to. An empty scope means
+	//the whole file.
+	ScopeChannels      []*Channel
+	ScopeChannelGroups []*ChannelGroup
+
+	//pointer to mf4 file
+	mf4 *MF4
+}
+
+// Events returns the events of the file with their references resolved
+func (m *MF4) Events() ([]*Event, error) {
+	file := m.reader()
+	events := make([]*Event, 0)
+	byAddress := make(map[int64]*Event)
+	for addr := m.getFirstEvent(); addr != 0; {
+		if byAddress[addr] != nil {
+			break
+		}
+
+		block, err := EV.New(file, m.MdfVersion(), addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ev := &Event{Event: block.Load(file), mf4: m}
+		events = append(events, ev)
+		byAddress[addr] = ev
+		addr = block.Next()
+	}
+
+	channels := make(map[int64]*Channel)
+	groups := make(map[int64]*ChannelGroup)
+	for i := range m.ChannelGroup {
+		cg := &m.ChannelGroup[i]
+		groups[cg.address] = cg
+		for _, cn := range cg.Channels {
+			channels[cn.address] = cn
+		}
+	}
+
+	for _, ev := range events {
+		link := ev.Block.Link
+		ev.Parent = byAddress[link.Parent]
+		if ev.Block.Data.RangeType == EV.EndRange {
+			ev.Range = byAddress[link.Range]
+			if ev.Range != nil {
+				ev.Range.RangeEnd = ev
+				ev.Previous = ev.Range.Block
+			}
+		}
+
+		for _, addr := range link.Scope {
+			if cn, ok := channels[addr]; ok {
+				ev.ScopeChannels = append(ev.ScopeChannels, cn)
+			} else if cg, ok := groups[addr]; ok {
+				ev.ScopeChannelGroups = append(ev.ScopeChannelGroups, cg)
+			}
+		}
+	}
+	return events, nil
+}
+
+// Time returns the absolute time of an event synchronized on time
+func (e *Event) Time() (time.Time, error) {
+	return e.mf4.EventTime(e.Event)
+}
+
+// Attachments returns the attachments referenced by the event, for instance
+// the video of a video sync event
+func (e *Event) Attachments() ([]AT.AttFile, error) {
+	return e.mf4.loadAttachments(e.Block.Link.ATReference)
+}
@@ -0,0 +1,84 @@
+package mf4_test
+
+import (
+	"testing"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// eventsFixture has a recording range from 1 s to 5 s, with a trigger marker
+// at 2.5 s scoped to the channel "speed" and referencing an attachment, and
+// an angle event in its channel group
+func eventsFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(420)
+	f.header(hdData{StartTimeNs: uint64(alignStart.UnixNano()), TimeFlags: 2})
+
+	speed := f.channel("speed", cnData{DataType: 4, BitCount: 64})
+	cg := f.block("##CG", []int64{0, speed, 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 8}))
+	dg := f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records([]float64{1})), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	at := f.block("##AT", []int64{0, f.text("trigger.png"), f.text("image/png"), 0}, make([]byte, 40))
+	f.link(hdAddress, 3, at)
+
+	angle := f.block("##EV", []int64{0, 0, 0, f.text("tdc"), 0, cg}, encode(evData{Type: 6, SyncType: 2, Cause: 2, ScopeCount: 1, SyncBaseValue: 314, SyncFactor: 0.01}))
+	end := f.block("##EV", []int64{angle, 0, 0, f.text("recording"), 0}, encode(evData{RangeType: 2, SyncType: 1, Cause: 4, SyncBaseValue: 5, SyncFactor: 1}))
+	trigger := f.block("##EV", []int64{end, 0, 0, f.text("overspeed"), f.text("too fast"), speed, at, f.text("limits")},
+		encode(evData{Type: 5, SyncType: 1, Cause: 1, Flags: 2, ScopeCount: 1, AttachmentCount: 1, SyncBaseValue: 2500, SyncFactor: 0.001}))
+	begin := f.block("##EV", []int64{trigger, 0, 0, f.text("recording"), 0}, encode(evData{RangeType: 1, SyncType: 1, Cause: 4, SyncBaseValue: 1, SyncFactor: 1}))
+	f.link(end, 2, begin)
+	f.link(trigger, 1, begin)
+	f.link(hdAddress, 4, begin)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestEvents(t *testing.T) {
+	m := eventsFixture(t)
+
+	events, err := m.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	begin, trigger, end, angle := events[0], events[1], events[2], events[3]
+
+	if begin.RangeType != "BEGIN" || begin.Type != "RECORDING" || begin.Cause != "USER" || begin.RangeEnd != end {
+		t.Fatalf("wrong range begin %+v", begin.Event)
+	}
+	if end.RangeType != "END" || end.Range != begin || end.Previous != begin.Block || end.Position != 5 {
+		t.Fatalf("wrong range end %+v", end.Event)
+	}
+
+	if trigger.Type != "TRIGGER" || trigger.Cause != "ERROR" || trigger.Comment != "too fast" || trigger.GroupName != "limits" || trigger.Parent != begin {
+		t.Fatalf("wrong trigger %+v", trigger.Event)
+	}
+	if len(trigger.ScopeChannels) != 1 || trigger.ScopeChannels[0].Name != "speed" || len(trigger.ScopeChannelGroups) != 0 {
+		t.Fatalf("wrong trigger scope %v", trigger.ScopeChannels)
+	}
+	at, err := trigger.Attachments()
+	if err != nil || len(at) != 1 || at[0].Name != "trigger.png" {
+		t.Fatalf("wrong trigger attachments %v %v", at, err)
+	}
+	when, err := trigger.Time()
+	if err != nil || !when.Equal(alignStart.Add(2500*time.Millisecond)) {
+		t.Fatalf("wrong trigger time %v %v", when, err)
+	}
+
+	if angle.SyncType != "ANGLE" || angle.Position != 3.14 || angle.Type != "MARKER" || angle.Cause != "TOOL" {
+		t.Fatalf("wrong angle event %+v", angle.Event)
+	}
+	if len(angle.ScopeChannelGroups) != 1 || angle.ScopeChannelGroups[0].Channels["speed"] == nil {
+		t.Fatalf("wrong angle event scope %v", angle.ScopeChannelGroups)
+	}
+	if _, err := angle.Time(); err == nil {
+		t.Fatal("expected error for time of angle event")
+	}
+}
@@ -0,0 +1,76 @@
+package mf4
+
+import (
+	"time"
+
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+	"github.com/LincolnG4/GoMDF/blocks/EV"
+	"github.com/LincolnG4/GoMDF/blocks/FH"
+	"github.com/LincolnG4/GoMDF/blocks/MD"
+)
+
+// FileHistoryEntry is a change of the file, recorded in an FHBLOCK
+type FileHistoryEntry struct {
+	//zero based index of the entry, referenced by the creator index of
+	//attachments and events
+	Index int
+
+	//time of the change, in its time zone when the offsets are valid
+	Time time.Time
+
+	//time zone and daylight saving offsets in minutes, and time flags
+	TZOffsetMin  int16
+	DSTOffsetMin int16
+	TimeFlags    uint8
+
+	//description of the change and of the tool that made it
+	Comment *MD.FHComment
+
+	//attachments created, or changed most recently, by this change
+	Attachments []AT.AttFile
+
+	//events created by this change
+	Events []*EV.Event
+}
+
+// FileHistoryEntries returns the changes of the file, from its creation, with
+// the attachments and events they created
+func (m *MF4) FileHistoryEntries() ([]FileHistoryEntry, error) {
+	file := m.reader()
+	entries := make([]FileHistoryEntry, 0)
+	for next := m.getFileHistory(); next != 0; {
+		fh, err := FH.New(file, next)
+		if err != nil {
+			return nil, err
+		}
+
+		comment := &MD.FHComment{}
+		MD.Parse(MD.New(file, fh.GetMdComment()), comment)
+		entries = append(entries, FileHistoryEntry{
+			Index:        len(entries),
+			Time:         fh.Time(),
+			TZOffsetMin:  fh.Data.TZOffsetMin,
+			DSTOffsetMin: fh.Data.DSTOffsetMin,
+			TimeFlags:    fh.Data.TimeFlags,
+			Comment:      comment,
+		})
+		next = fh.Next()
+	}
+
+	attachments, err := m.GetAttachments()
+	if err != nil {
+		return nil, err
+	}
+	for _, at := range attachments {
+		if i := int(at.Creator()); i < len(entries) {
+			entries[i].Attachments = append(entries[i].Attachments, at)
+		}
+	}
+
+	for _, ev := range m.ListEvents() {
+		if i := int(ev.Block.Data.CreatorIndex); i < len(entries) {
+			entries[i].Events = append(entries[i].Events, ev)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,93 @@
+package mf4_test
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+type fhData struct {
+	TimeNs       uint64
+	TZOffsetMin  int16
+	DSTOffsetMin int16
+	TimeFlags    uint8
+	Reserved     [3]byte
+}
+
+// historyFixture has two file history entries, an attachment changed by the
+// second one and an event created by the first one
+func historyFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	created := time.Date(2023, 3, 24, 14, 57, 36, 0, time.UTC)
+	fh2 := f.block("##FH", []int64{0, f.metadata("<FHcomment><TX>Added attachment</TX><tool_id>asammdf</tool_id><tool_vendor>asammdf</tool_vendor><tool_version>7.3.14</tool_version></FHcomment>")},
+		encode(fhData{TimeNs: uint64(created.Add(time.Hour).UnixNano()), TZOffsetMin: 60, DSTOffsetMin: 60, TimeFlags: 2}))
+	fh1 := f.block("##FH", []int64{fh2, f.metadata("<FHcomment><TX>created</TX><tool_id>CANape</tool_id><tool_vendor>Vector Informatik GmbH</tool_vendor><tool_version>12.0</tool_version><user_name>jdoe</user_name></FHcomment>")},
+		encode(fhData{TimeNs: uint64(created.UnixNano()), TZOffsetMin: -300, DSTOffsetMin: 60, TimeFlags: 2}))
+	f.link(hdAddress, 1, fh1)
+
+	atData := make([]byte, 40)
+	binary.LittleEndian.PutUint16(atData[2:], 1)
+	f.link(hdAddress, 3, f.block("##AT", []int64{0, f.text("notes.txt"), f.text("text/plain"), 0}, atData))
+
+	f.link(hdAddress, 4, f.block("##EV", []int64{0, 0, 0, f.text("start"), 0}, encode(evData{SyncType: 1, SyncFactor: 1})))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFileHistory(t *testing.T) {
+	m := historyFixture(t)
+
+	history, err := m.FileHistoryEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(history))
+	}
+
+	first := history[0]
+	if first.Comment.TX != "created" || first.Comment.ToolID != "CANape" || first.Comment.ToolVersion != "12.0" || first.Comment.UserName != "jdoe" {
+		t.Fatalf("wrong comment %+v", first.Comment)
+	}
+	if _, offset := first.Time.Zone(); offset != -4*3600 || first.Time.Hour() != 10 || first.TZOffsetMin != -300 || first.DSTOffsetMin != 60 {
+		t.Fatalf("wrong time %v", first.Time)
+	}
+	if len(first.Events) != 1 || first.Events[0].Name != "start" || len(first.Attachments) != 0 {
+		t.Fatalf("wrong creations of first entry %+v", first)
+	}
+
+	second := history[1]
+	if second.Index != 1 || second.Comment.ToolVendor != "asammdf" || len(second.Attachments) != 1 || second.Attachments[0].Name != "notes.txt" {
+		t.Fatalf("wrong second entry %+v", second)
+	}
+}
+
+func TestFileHistoryOfEditor(t *testing.T) {
+	m := historyFixture(t)
+	path := filepath.Join(t.TempDir(), "edited.mf4")
+
+	e, err := m.EditCopy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetHeaderProperty("test", "42")
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := reopen(t, path).FileHistoryEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[2].Comment.ToolID != mf4.ToolID || history[2].Comment.TX != "Updated header comment" {
+		t.Fatalf("wrong entry of the edition %+v", history)
+	}
+}
@@ -0,0 +1,124 @@
+package mf4
+
+import (
+	"fmt"
+
+	"github.com/LincolnG4/GoMDF/blocks/CH"
+	"github.com/LincolnG4/GoMDF/blocks/MD"
+	"github.com/LincolnG4/GoMDF/blocks/TX"
+)
+
+// Hierarchy is a level of the channel hierarchy of the file, built from
+// CHBLOCKs, for instance a device, an ECU function or a structure
+type Hierarchy struct {
+	//name of the level
+	Name string
+
+	//type of the level: GROUP, FUNCTION, STRUCTURE, MAP_LIST,
+	//INPUT_VARIABLES, OUTPUT_VARIABLES, LOCAL_VARIABLES,
+	//CALIBRATION_DEFINITION or CALIBRATION_REFERENCE
+	Type string
+
+	//comment of the level
+	Comment string
+
+	//channels referenced by the level
+	Channels []*Channel
+
+	//sub levels
+	Children []*Hierarchy
+}
+
+// ChannelHierarchy returns the top levels of the channel hierarchy of the
+// file, with their channels resolved. Element references to channels that
+// are not in a channel group, for instance members of a composition, are
+// skipped.
+func (m *MF4) ChannelHierarchy() ([]*Hierarchy, error) {
+	channels := make(map[int64]*Channel)
+	for _, cg := range m.ChannelGroup {
+		for _, cn := range cg.Channels {
+			channels[cn.address] = cn
+		}
+	}
+
+	return m.readHierarchy(m.Header.Link.ChFirst, channels, make(map[int64]bool))
+}
+
+// readHierarchy reads the list of CHBLOCKs starting at `addr` and their
+// children
+func (m *MF4) readHierarchy(addr int64, channels map[int64]*Channel, visited map[int64]bool) ([]*Hierarchy, error) {
+	file := m.reader()
+	levels := make([]*Hierarchy, 0)
+	for addr != 0 {
+		if visited[addr] {
+			return nil, fmt.Errorf("loop in channel hierarchy at address %d", addr)
+		}
+		visited[addr] = true
+
+		ch, err := CH.New(file, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		h := &Hierarchy{
+			Type:     ch.Type(),
+			Comment:  MD.New(file, ch.Link.MdComment),
+			Channels: make([]*Channel, 0, len(ch.Link.Elements)),
+		}
+		if ch.Link.TxName != 0 {
+			if h.Name, err = TX.GetText(file, ch.Link.TxName); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, e := range ch.Link.Elements {
+			if cn, ok := channels[e.Channel]; ok {
+				h.Channels = append(h.Channels, cn)
+			}
+		}
+
+		h.Children, err = m.readHierarchy(ch.Link.ChFirst, channels, visited)
+		if err != nil {
+			return nil, err
+		}
+
+		levels = append(levels, h)
+		addr = ch.Next()
+	}
+	return levels, nil
+}
+
+// Find returns the sub level at `path`, given by the names of the levels
+// below `h`, or 'nil'
+func (h *Hierarchy) Find(path ...string) *Hierarchy {
+	if len(path) == 0 {
+		return h
+	}
+
+	for _, child := range h.Children {
+		if child.Name == path[0] {
+			return child.Find(path[1:]...)
+		}
+	}
+	return nil
+}
+
+// Walk calls `fn` for `h` and all its sub levels, depth first, with the path
+// of names to each level. Walking stops at the first error.
+func (h *Hierarchy) Walk(fn func(path []string, level *Hierarchy) error) error {
+	return h.walk(nil, fn)
+}
+
+func (h *Hierarchy) walk(parent []string, fn func(path []string, level *Hierarchy) error) error {
+	path := append(parent[:len(parent):len(parent)], h.Name)
+	if err := fn(path, h); err != nil {
+		return err
+	}
+
+	for _, child := range h.Children {
+		if err := child.walk(path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package mf4_test
+
+import (
+	"strings"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// hierarchyFixture organizes the channels "speed" and "rpm" in a device with
+// an engine function holding its input variables
+func hierarchyFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	speed := f.channel("speed", cnData{DataType: 4, BitCount: 64})
+	rpm := f.channel("rpm", cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+	cg := f.block("##CG", []int64{0, f.chain(0, speed, rpm), 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 16}))
+	dg := f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records([]float64{1, 2})), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	chData := func(count uint32, kind uint8) []byte {
+		return encode(struct {
+			Count    uint32
+			Type     uint8
+			Reserved [3]byte
+		}{count, kind, [3]byte{}})
+	}
+
+	inputs := f.block("##CH", []int64{0, 0, f.text("inputs"), 0, dg, cg, rpm}, chData(1, 4))
+	engine := f.block("##CH", []int64{0, inputs, f.text("engine"), f.text("engine control"), dg, cg, speed}, chData(1, 1))
+	unused := f.block("##CH", []int64{0, 0, f.text("diagnostics"), 0}, chData(0, 0))
+	f.link(engine, 0, unused)
+	device := f.block("##CH", []int64{0, engine, f.text("ECU"), 0}, chData(0, 0))
+	f.link(hdAddress, 2, device)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestChannelHierarchy(t *testing.T) {
+	m := hierarchyFixture(t)
+
+	tree, err := m.ChannelHierarchy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 1 || tree[0].Name != "ECU" || tree[0].Type != "GROUP" || len(tree[0].Children) != 2 {
+		t.Fatalf("wrong top level %+v", tree)
+	}
+
+	engine := tree[0].Find("engine")
+	if engine == nil || engine.Type != "FUNCTION" || engine.Comment != "engine control" || len(engine.Channels) != 1 || engine.Channels[0].Name != "speed" {
+		t.Fatalf("wrong engine level %+v", engine)
+	}
+
+	inputs := tree[0].Find("engine", "inputs")
+	if inputs == nil || inputs.Type != "INPUT_VARIABLES" || inputs.Channels[0].Name != "rpm" {
+		t.Fatalf("wrong inputs level %+v", inputs)
+	}
+	if tree[0].Find("engine", "outputs") != nil {
+		t.Fatal("expected no level for unknown path")
+	}
+
+	var paths []string
+	err = tree[0].Walk(func(path []string, level *mf4.Hierarchy) error {
+		paths = append(paths, strings.Join(path, "/"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(paths, ",") != "ECU,ECU/engine,ECU/engine/inputs,ECU/diagnostics" {
+		t.Fatalf("wrong walk %v", paths)
+	}
+}
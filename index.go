@@ -0,0 +1,544 @@
+package mf4
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/DL"
+	"github.com/LincolnG4/GoMDF/blocks/DZ"
+	"github.com/LincolnG4/GoMDF/blocks/HL"
+)
+
+// BlockIndex locates the data blocks of a sorted data group by record index
+// and by master value, so ranged reads only load the blocks holding the
+// requested records.
+type BlockIndex struct {
+	//data blocks (DT, DV or DZ) in file order
+	Blocks []IndexedBlock
+
+	//length of a record in bytes, including record id and invalidation bytes
+	RecordSize uint64
+
+	//number of records of the data group
+	RecordCount uint64
+
+	//guards the master values computed on demand
+	mu sync.Mutex
+
+	//master value of the first record of each block, computed when the data
+	//list doesn't store it
+	computed map[int]float64
+}
+
+// IndexedBlock describes one data block of a data group
+type IndexedBlock struct {
+	//address of the DT, DV or DZ block
+	Address int64
+
+	//offset of the data section within the data of the whole data group
+	Offset uint64
+
+	//length of the data section, once decompressed
+	Length uint64
+
+	//index of the first record starting in the block
+	FirstRecord uint64
+
+	//master values of the first record, as stored in the data list
+	//(MDF 4.2). 'NaN' if not stored.
+	TimeValue     float64
+	AngleValue    float64
+	DistanceValue float64
+
+	//block id of the data ("##DT", "##DV" or "##DZ")
+	id string
+}
+
+// indexState builds the block index of a data group once. It is shared by all
+// copies of the data group.
+type indexState struct {
+	once  sync.Once
+	index *BlockIndex
+	err   error
+}
+
+// BlockIndex returns the block index of the data group `dataGroupIndex`. It
+// is built on first use, from the data list metadata when available.
+func (m *MF4) BlockIndex(dataGroupIndex int) (*BlockIndex, error) {
+	if dataGroupIndex < 0 || dataGroupIndex >= len(m.DataGroups) {
+		return nil, fmt.Errorf("data group %d doesn't exist", dataGroupIndex)
+	}
+	return m.DataGroups[dataGroupIndex].blockIndex(m)
+}
+
+// blockIndex returns the block index of the data group, building it on
+// first use
+func (d *DataGroup) blockIndex(m *MF4) (*BlockIndex, error) {
+	d.index.once.Do(func() {
+		d.index.index, d.index.err = d.buildBlockIndex(m)
+	})
+	return d.index.index, d.index.err
+}
+
+// buildBlockIndex lists the data blocks of the data group. Records of
+// unsorted data groups have different lengths, so they are only indexed by
+// block and RecordSize is 0.
+func (d *DataGroup) buildBlockIndex(m *MF4) (*BlockIndex, error) {
+	idx := &BlockIndex{computed: make(map[int]float64)}
+	for _, cg := range d.ChannelGroup {
+		if !d.block.IsSorted() || cg.Block.IsVLSD() {
+			continue
+		}
+		idx.RecordSize = uint64(cg.Block.Data.DataBytes) + uint64(cg.Block.Data.InvalBytes)
+		idx.RecordCount = cg.Block.Data.CycleCount
+		break
+	}
+
+	if d.DataAddress() == 0 {
+		return idx, nil
+	}
+
+	file := m.reader()
+	addr := d.DataAddress()
+	id, err := blocks.GetHeaderID(file, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch id {
+	case blocks.DtID, blocks.DvID, blocks.DzID:
+		err = idx.addBlock(file, addr, 0, math.NaN(), math.NaN(), math.NaN())
+	case blocks.DlID:
+		err = idx.addList(file, m.MdfVersion(), addr)
+	case blocks.HlID:
+		var hl *HL.Block
+		hl, err = HL.New(file, addr)
+		if err == nil {
+			err = idx.addList(file, m.MdfVersion(), hl.Link.DlFirst)
+		}
+	default:
+		err = fmt.Errorf("block %s can't be indexed", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// addList indexes the data blocks of the DL chain starting at `addr`
+func (idx *BlockIndex) addList(file io.ReadSeeker, version uint16, addr int64) error {
+	for addr != 0 {
+		dl, err := DL.New(file, version, addr)
+		if err != nil {
+			return err
+		}
+
+		for i, blockAddr := range dl.Link.Data {
+			if blockAddr == 0 {
+				continue
+			}
+
+			offset := idx.end()
+			if len(dl.Data.Offset) > i {
+				offset = dl.Data.Offset[i]
+			}
+
+			err = idx.addBlock(file, blockAddr, offset,
+				listValue(dl, DL.Time, i), listValue(dl, DL.Angle, i), listValue(dl, DL.Distance, i))
+			if err != nil {
+				return err
+			}
+		}
+		addr = dl.Next()
+	}
+	return nil
+}
+
+// listValue returns the master value `i` stored in the data list for the
+// domain `flag`, or 'NaN' if not stored
+func listValue(dl *DL.Block, flag int, i int) float64 {
+	values := dl.MasterValues(flag)
+	if len(values) <= i {
+		return math.NaN()
+	}
+	return values[i]
+}
+
+// addBlock appends the data block at `addr` starting at `offset`
+func (idx *BlockIndex) addBlock(file io.ReadSeeker, addr int64, offset uint64, timeValue, angleValue, distanceValue float64) error {
+	id, err := blocks.GetHeaderID(file, addr)
+	if err != nil {
+		return err
+	}
+
+	var length uint64
+	switch id {
+	case blocks.DtID, blocks.DvID:
+		length, err = blocks.GetLength(file, addr)
+	case blocks.DzID:
+		var dz *DZ.Block
+		dz, err = DZ.Info(file, addr)
+		length = dz.Data.OrgDataLenght
+	default:
+		err = fmt.Errorf("block %s can't be indexed", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	var firstRecord uint64
+	if idx.RecordSize > 0 {
+		firstRecord = (offset + idx.RecordSize - 1) / idx.RecordSize
+	}
+
+	idx.Blocks = append(idx.Blocks, IndexedBlock{
+		Address:       addr,
+		Offset:        offset,
+		Length:        length,
+		FirstRecord:   firstRecord,
+		TimeValue:     timeValue,
+		AngleValue:    angleValue,
+		DistanceValue: distanceValue,
+		id:            id,
+	})
+	return nil
+}
+
+// end returns the offset after the last indexed block
+func (idx *BlockIndex) end() uint64 {
+	if len(idx.Blocks) == 0 {
+		return 0
+	}
+	last := idx.Blocks[len(idx.Blocks)-1]
+	return last.Offset + last.Length
+}
+
+// Locate returns the position in Blocks of the data block holding the start
+// of record `record`
+func (idx *BlockIndex) Locate(record uint64) (int, error) {
+	if record >= idx.RecordCount {
+		return 0, fmt.Errorf("record %d out of range, data group has %d records", record, idx.RecordCount)
+	}
+
+	offset := record * idx.RecordSize
+	i := sort.Search(len(idx.Blocks), func(i int) bool {
+		return idx.Blocks[i].Offset+idx.Blocks[i].Length > offset
+	})
+	if i == len(idx.Blocks) {
+		return 0, fmt.Errorf("record %d not found in data blocks", record)
+	}
+	return i, nil
+}
+
+// scan calls `fn` with the data of the data group between the offsets `from`
+// and `to`, one data block at a time. Only the data blocks in this range are
+// read; a record may be split between two consecutive calls.
+func (idx *BlockIndex) scan(m *MF4, d *DataGroup, from, to uint64, fn func([]byte) error) error {
+	file := m.reader()
+	read := from
+
+	i := sort.Search(len(idx.Blocks), func(i int) bool {
+		return idx.Blocks[i].Offset+idx.Blocks[i].Length > from
+	})
+	for ; i < len(idx.Blocks) && idx.Blocks[i].Offset < to; i++ {
+		b := idx.Blocks[i]
+		lo := max(from, b.Offset) - b.Offset
+		hi := min(to, b.Offset+b.Length) - b.Offset
+
+		var data []byte
+		if b.id == blocks.DzID {
+			block, err := idx.readZipped(m, d, file, b.Address)
+			if err != nil {
+				return err
+			}
+			if hi > uint64(len(block)) {
+				return fmt.Errorf("data block at %d is shorter than expected", b.Address)
+			}
+			data = block[lo:hi]
+		} else {
+			data = make([]byte, hi-lo)
+			_, err := file.ReadAt(data, b.Address+int64(blocks.HeaderSize)+int64(lo))
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := fn(data); err != nil {
+			return err
+		}
+		read = b.Offset + hi
+	}
+
+	if read != to {
+		return fmt.Errorf("data blocks end before offset %d", to)
+	}
+	return nil
+}
+
+// readZipped returns the decompressed data of the DZBLOCK at `addr`, using
+// the block cache of the data group
+func (idx *BlockIndex) readZipped(m *MF4, d *DataGroup, file io.ReadSeeker, addr int64) ([]byte, error) {
+	if block, ok := m.cachedDataBlock(d, addr); ok {
+		return block, nil
+	}
+
+	dz, err := DZ.New(file, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := dz.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	m.storeDataBlock(d, addr, block)
+	return block, nil
+}
+
+// storedValue returns the master value of the block `i` stored in the data
+// list for `domain`, or 'NaN'
+func (b *IndexedBlock) storedValue(domain string) float64 {
+	switch domain {
+	case blocks.TimeSyncDomain:
+		return b.TimeValue
+	case blocks.AngleSyncDomain:
+		return b.AngleValue
+	case blocks.DistanceSyncDomain:
+		return b.DistanceValue
+	default:
+		return math.NaN()
+	}
+}
+
+// startValue returns the value of the master channel `master` at the first
+// record of block `i`. Values not stored in the data list are read once and
+// kept in the index.
+func (idx *BlockIndex) startValue(master *Channel, i int) (float64, error) {
+	domain := master.SyncDomain()
+	if v := idx.Blocks[i].storedValue(domain); !math.IsNaN(v) {
+		return v + master.mf4.syncOffset(domain), nil
+	}
+
+	idx.mu.Lock()
+	v, ok := idx.computed[i]
+	idx.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	values, err := master.masterRange(idx.Blocks[i].FirstRecord, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, fmt.Errorf("record %d not found", idx.Blocks[i].FirstRecord)
+	}
+
+	idx.mu.Lock()
+	idx.computed[i] = values[0]
+	idx.mu.Unlock()
+	return values[0], nil
+}
+
+// SampleRange returns `count` samples of the channel starting at record
+// `first`, applying the conversion. Only the data blocks holding these records
+// are read, located with the block index of the data group.
+func (c *Channel) SampleRange(first, count uint64) ([]interface{}, error) {
+	if sample, ok := c.cache.getPhysical(); ok {
+		first, end := clampRange(first, count, uint64(len(sample)))
+		return sample[first:end], nil
+	}
+
+	sample, err := c.RawSampleRange(first, count)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.applyConversion(&sample)
+	if err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
+
+// RawSampleRange returns `count` samples of the channel starting at record
+// `first`, not applying the conversion
+func (c *Channel) RawSampleRange(first, count uint64) ([]interface{}, error) {
+	total := c.ChannelGroup.Data.CycleCount
+	first, end := clampRange(first, count, total)
+
+	if c.block.IsVirtualMaster() {
+		measure := make([]interface{}, 0, end-first)
+		for i := first; i < end; i++ {
+			measure = append(measure, i)
+		}
+		return measure, nil
+	}
+
+	if sample, ok := c.cache.getRaw(); ok {
+		first, end := clampRange(first, count, uint64(len(sample)))
+		return sample[first:end], nil
+	}
+
+	if !c.DataGroup.block.IsSorted() {
+		return c.readUnsorted(first, end)
+	}
+
+	if c.block.IsVLSD() {
+		return nil, fmt.Errorf("channel %s: ranged reads of variable length channels are not supported", c.Name)
+	}
+
+	idx, err := c.DataGroup.blockIndex(c.mf4)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := c.valueLayout()
+	if layout.offset+layout.size > idx.RecordSize {
+		return nil, fmt.Errorf("channel %s exceeds the record size", c.Name)
+	}
+
+	measure := make([]interface{}, 0, end-first)
+
+	// records can span two data blocks, so the incomplete record at the end
+	// of a block is kept until the next one is read
+	var pending []byte
+	err = idx.scan(c.mf4, c.DataGroup, first*idx.RecordSize, end*idx.RecordSize, func(data []byte) error {
+		if len(pending) > 0 {
+			data = append(pending, data...)
+		}
+
+		pos := uint64(0)
+		for ; pos+idx.RecordSize <= uint64(len(data)); pos += idx.RecordSize {
+			value, err := layout.decode(data[pos : pos+idx.RecordSize])
+			if err != nil {
+				return err
+			}
+			measure = append(measure, value)
+		}
+		pending = append(pending[:0:0], data[pos:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return measure, nil
+}
+
+// SampleWindow returns the samples of the channel whose master value is
+// between `start` and `end` (inclusive), together with the master values.
+// Master values must increase monotonically, as for time, angle and distance
+// masters. Like MasterValues, angles and distances include the start angle
+// and start distance of the measurement. The first record of the window is found by binary search over the
+// blocks of the block index, so only a few data blocks are read.
+func (c *Channel) SampleWindow(start, end float64) ([]float64, []interface{}, error) {
+	master := c
+	if !c.IsMaster() {
+		master = c.Master
+	}
+	if master == nil || master.block == nil {
+		return nil, nil, fmt.Errorf("channel %s has no master channel", c.Name)
+	}
+
+	first, err := master.searchMaster(start, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last, err := master.searchMaster(end, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if last <= first {
+		return []float64{}, []interface{}{}, nil
+	}
+
+	axis, err := master.masterRange(first, last-first)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sample, err := c.SampleRange(first, last-first)
+	if err != nil {
+		return nil, nil, err
+	}
+	return axis, sample, nil
+}
+
+// searchMaster returns the first record whose master value is greater than or
+// equal to `v` (greater than `v` if `after` is set)
+func (c *Channel) searchMaster(v float64, after bool) (uint64, error) {
+	before := func(x float64) bool {
+		if after {
+			return x <= v
+		}
+		return x < v
+	}
+
+	idx, err := c.DataGroup.blockIndex(c.mf4)
+	if err != nil {
+		return 0, err
+	}
+
+	total := c.ChannelGroup.Data.CycleCount
+	n := sort.Search(len(idx.Blocks), func(i int) bool {
+		return idx.Blocks[i].FirstRecord >= total
+	})
+
+	// first block starting at or after the searched value
+	var searchErr error
+	k := sort.Search(n, func(i int) bool {
+		x, err := idx.startValue(c, i)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return !before(x)
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+
+	if k == 0 {
+		return 0, nil
+	}
+
+	// the searched record is in block k-1 or is the first record of block k
+	from := idx.Blocks[k-1].FirstRecord
+	to := total
+	if k < n {
+		to = idx.Blocks[k].FirstRecord
+	}
+
+	values, err := c.masterRange(from, to-from)
+	if err != nil {
+		return 0, err
+	}
+
+	j := sort.Search(len(values), func(i int) bool {
+		return !before(values[i])
+	})
+	return from + uint64(j), nil
+}
+
+// masterRange returns the master values of a master channel, see masterAxis
+func (c *Channel) masterRange(first, count uint64) ([]float64, error) {
+	sample, err := c.SampleRange(first, count)
+	if err != nil {
+		return nil, err
+	}
+	return c.masterAxis(sample)
+}
+
+// clampRange limits the range of `count` records starting at `first` to
+// `total` records, returning its first and end record
+func clampRange(first, count, total uint64) (uint64, uint64) {
+	first = min(first, total)
+	return first, first + min(count, total-first)
+}
@@ -0,0 +1,158 @@
+package mf4_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// listFixture has one channel group with a time master (float64) and an
+// int32 channel, with 10 records of 12 bytes stored in three DT blocks of 40
+// bytes, so records span block boundaries. Version 4.2 files store offsets and
+// the time of each block in the DL; 4.1 files use equal length blocks.
+func listFixture(t *testing.T, version uint16) *mf4.MF4 {
+	f := newFixture(version)
+
+	time := f.channel("time", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	value := f.channel("value", cnData{DataType: 2, ByteOffset: 8, BitCount: 32})
+	cg := f.block("##CG", []int64{0, f.chain(0, time, value), 0, 0, 0, 0}, encode(cgData{CycleCount: 10, DataBytes: 12}))
+
+	var records []byte
+	for i := 0; i < 10; i++ {
+		records = binary.LittleEndian.AppendUint64(records, math.Float64bits(float64(i)/10))
+		records = binary.LittleEndian.AppendUint32(records, uint32(i*10))
+	}
+	dts := []int64{
+		f.block("##DT", nil, records[0:40]),
+		f.block("##DT", nil, records[40:80]),
+		f.block("##DT", nil, records[80:120]),
+	}
+
+	var dl bytes.Buffer
+	if version >= 420 {
+		dl.Write([]byte{1 << 1, 0, 0, 0})
+		binary.Write(&dl, binary.LittleEndian, uint32(3))
+		binary.Write(&dl, binary.LittleEndian, []uint64{0, 40, 80})
+		binary.Write(&dl, binary.LittleEndian, []float64{0, 0.4, 0.7})
+	} else {
+		dl.Write([]byte{1, 0, 0, 0})
+		binary.Write(&dl, binary.LittleEndian, uint32(3))
+		binary.Write(&dl, binary.LittleEndian, uint64(40))
+	}
+	list := f.block("##DL", append([]int64{0}, dts...), dl.Bytes())
+
+	dg := f.block("##DG", []int64{0, cg, list, 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestBlockIndex(t *testing.T) {
+	for _, version := range []uint16{410, 420} {
+		m := listFixture(t, version)
+
+		idx, err := m.BlockIndex(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(idx.Blocks) != 3 || idx.RecordSize != 12 || idx.RecordCount != 10 {
+			t.Fatalf("%d: wrong index %+v", version, idx)
+		}
+
+		expectedFirst := []uint64{0, 4, 7}
+		for i, b := range idx.Blocks {
+			if b.Offset != uint64(i*40) || b.Length != 40 || b.FirstRecord != expectedFirst[i] {
+				t.Fatalf("%d: wrong block %d %+v", version, i, b)
+			}
+		}
+
+		stored := !math.IsNaN(idx.Blocks[1].TimeValue)
+		if stored != (version >= 420) || (stored && idx.Blocks[1].TimeValue != 0.4) {
+			t.Fatalf("%d: wrong time value %f", version, idx.Blocks[1].TimeValue)
+		}
+
+		// record 3 spans the first and the second block
+		if i, err := idx.Locate(3); err != nil || i != 0 {
+			t.Fatalf("%d: record 3 located in block %d, %v", version, i, err)
+		}
+		if _, err := idx.Locate(10); err == nil {
+			t.Fatalf("%d: expected error for record out of range", version)
+		}
+	}
+}
+
+func TestSampleRange(t *testing.T) {
+	m := listFixture(t, 410)
+	cn := m.ChannelGroup[0].Channels["value"]
+
+	sample, err := cn.SampleRange(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int32{30, 40, 50, 60, 70}
+	if len(sample) != len(expected) {
+		t.Fatalf("expected %d samples, got %v", len(expected), sample)
+	}
+	for i, v := range expected {
+		if sample[i] != v {
+			t.Fatalf("sample %d: expected %d, got %v", i, v, sample[i])
+		}
+	}
+
+	sample, err = cn.SampleRange(8, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sample) != 2 || sample[1] != int32(90) {
+		t.Fatalf("range not limited to the records, got %v", sample)
+	}
+
+	full, err := cn.Sample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(full) != 10 || full[3] != int32(30) || full[9] != int32(90) {
+		t.Fatalf("wrong samples across blocks %v", full)
+	}
+}
+
+func TestSampleWindow(t *testing.T) {
+	for _, version := range []uint16{410, 420} {
+		m := listFixture(t, version)
+		cn := m.ChannelGroup[0].Channels["value"]
+
+		axis, sample, err := cn.SampleWindow(0.25, 0.65)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []int32{30, 40, 50, 60}
+		if len(axis) != len(expected) || len(sample) != len(expected) {
+			t.Fatalf("%d: expected %d samples, got %v %v", version, len(expected), axis, sample)
+		}
+		for i, v := range expected {
+			if sample[i] != v || axis[i] != float64(v)/100 {
+				t.Fatalf("%d: sample %d: expected %d at %f, got %v at %f", version, i, v, float64(v)/100, sample[i], axis[i])
+			}
+		}
+
+		axis, sample, err = cn.SampleWindow(0.7, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sample) != 3 || axis[0] != 0.7 || sample[2] != int32(90) {
+			t.Fatalf("%d: wrong window at the end %v %v", version, axis, sample)
+		}
+
+		_, sample, err = cn.SampleWindow(2, 3)
+		if err != nil || len(sample) != 0 {
+			t.Fatalf("%d: expected empty window, got %v %v", version, sample, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package mf4
+
+import (
+	"sync"
+
+	"github.com/LincolnG4/GoMDF/blocks/CC"
+	"github.com/LincolnG4/GoMDF/blocks/MD"
+	"github.com/LincolnG4/GoMDF/blocks/SI"
+)
+
+// channelMeta holds the metadata of a channel that is resolved on first
+// access when ReadOptions.LazyMetadata is true. It is shared by all copies
+// of the channel.
+type channelMeta struct {
+	once       sync.Once
+	conversion CC.Conversion
+	sourceInfo SI.SourceInfo
+	comment    string
+	unit       string
+	err        error
+}
+
+// groupMeta holds the metadata of a channel group that is resolved on first
+// access when ReadOptions.LazyMetadata is true.
+type groupMeta struct {
+	once       sync.Once
+	sourceInfo SI.SourceInfo
+	comment    string
+	name       string
+
+	//address of the comment of the data group
+	commentAddress int64
+}
+
+// lazyMetadata returns `true` if conversions, source information and
+// comments are read on first access instead of when the file is opened
+func (m *MF4) lazyMetadata() bool {
+	return m.ReadOptions != nil && m.ReadOptions.LazyMetadata && !m.ReadOptions.InitAllChannels
+}
+
+// loadMeta reads the CCBLOCK, SIBLOCK, comment and unit of the channel once
+func (c *Channel) loadMeta() {
+	c.meta.once.Do(func() {
+		file := c.mf4.reader()
+		c.meta.conversion, c.meta.err = c.block.Conversion(file, c.block.DataType())
+		c.meta.sourceInfo = c.mf4.sourceInfo(c.block.Link.SiSource)
+		c.meta.comment = MD.New(file, c.block.CommentMd())
+
+		// the unit is a TXBLOCK or a CNunit MDBLOCK
+		unit := &MD.Comment{}
+		MD.Parse(MD.New(file, c.block.Link.MdUnit), unit)
+		c.meta.unit = unit.TX
+	})
+}
+
+// GetConversion returns the conversion of the channel, reading it from the
+// file on first access. It returns 'nil' if the channel has no conversion.
+func (c *Channel) GetConversion() (CC.Conversion, error) {
+	c.loadMeta()
+	return c.meta.conversion, c.meta.err
+}
+
+// GetSourceInfo returns the source information of the channel, reading it
+// from the file on first access
+func (c *Channel) GetSourceInfo() SI.SourceInfo {
+	c.loadMeta()
+	return c.meta.sourceInfo
+}
+
+// GetComment returns the comment of the channel, reading it from the file on
+// first access
+func (c *Channel) GetComment() string {
+	c.loadMeta()
+	return c.meta.comment
+}
+
+// GetUnit returns the physical unit of the channel, reading it from the file
+// on first access
+func (c *Channel) GetUnit() string {
+	c.loadMeta()
+	return c.meta.unit
+}
+
+// loadMeta reads the acquisition name and source and the data group comment
+// of the channel group once
+func (cg *ChannelGroup) loadMeta() {
+	cg.meta.once.Do(func() {
+		file := cg.mf4.reader()
+		cg.meta.sourceInfo = cg.mf4.sourceInfo(cg.Block.Link.SiAcqSource)
+		cg.meta.comment = MD.New(file, cg.meta.commentAddress)
+		cg.meta.name = MD.New(file, cg.Block.Link.TxAcqName)
+	})
+}
+
+// GetSourceInfo returns the acquisition source of the channel group, reading
+// it from the file on first access
+func (cg *ChannelGroup) GetSourceInfo() SI.SourceInfo {
+	cg.loadMeta()
+	return cg.meta.sourceInfo
+}
+
+// GetComment returns the comment of the data group holding the channel group,
+// reading it from the file on first access
+func (cg *ChannelGroup) GetComment() string {
+	cg.loadMeta()
+	return cg.meta.comment
+}
+
+// GetName returns the acquisition name of the channel group, reading it from
+// the file on first access
+func (cg *ChannelGroup) GetName() string {
+	cg.loadMeta()
+	return cg.meta.name
+}
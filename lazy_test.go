@@ -0,0 +1,140 @@
+package mf4_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+func TestLazyMetadata(t *testing.T) {
+	eagerFile, _ := os.Open("./samples/sample3.mf4")
+	defer eagerFile.Close()
+	lazyFile, _ := os.Open("./samples/sample3.mf4")
+	defer lazyFile.Close()
+
+	eager, err := mf4.ReadFile(eagerFile, &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := mf4.ReadFile(lazyFile, &mf4.ReadOptions{LazyMetadata: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, cg := range lazy.ChannelGroup {
+		if cg.Comment != "" || cg.GetComment() != eager.ChannelGroup[i].Comment {
+			t.Fatalf("channel group %d: wrong lazy comment", i)
+		}
+
+		for name, cn := range cg.Channels {
+			ref := eager.ChannelGroup[i].Channels[name]
+			if cn.Conversion != nil || cn.Comment != "" {
+				t.Fatalf("channel %s: metadata read on open", name)
+			}
+
+			cc, err := cn.GetConversion()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cc, ref.Conversion) {
+				t.Fatalf("channel %s: expected conversion %v, got %v", name, ref.Conversion, cc)
+			}
+			if cn.GetComment() != ref.Comment || cn.GetSourceInfo() != ref.SourceInfo {
+				t.Fatalf("channel %s: wrong lazy comment or source info", name)
+			}
+
+			sample, err := cn.Sample()
+			if err != nil {
+				t.Fatal(err)
+			}
+			refSample, err := ref.Sample()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(sample) != fmt.Sprint(refSample) {
+				t.Fatalf("channel %s: lazy samples differ", name)
+			}
+		}
+	}
+}
+
+func TestEagerMetadataByDefault(t *testing.T) {
+	file := manyChannelsFixture(2).open(t)
+	for _, opts := range []*mf4.ReadOptions{{}, {LazyMetadata: true, InitAllChannels: true}} {
+		m, err := mf4.ReadFile(file, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cn := m.ChannelGroup[0].Channels["signal_1"]
+		if cn.Conversion == nil || cn.Comment == "" || cn.SourceInfo.Name != "ecu_1" {
+			t.Fatalf("%+v: metadata not read on open: %v %q %v", opts, cn.Conversion, cn.Comment, cn.SourceInfo)
+		}
+	}
+}
+
+func TestBadConversion(t *testing.T) {
+	f := newFixture(410)
+	cn := f.channel("speed", cnData{BitCount: 8})
+	// cc_conversion pointing to a TXBLOCK
+	f.link(cn, 4, f.text("not a conversion"))
+	cg := f.block("##CG", []int64{0, cn, 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 1}))
+	f.link(hdAddress, 0, f.dataGroup(cg, []byte{1}))
+	file := f.open(t)
+
+	if _, err := mf4.ReadFile(file, &mf4.ReadOptions{}); err == nil {
+		t.Fatal("expected an error for the conversion")
+	}
+	m, err := mf4.ReadFile(file, &mf4.ReadOptions{LazyMetadata: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ChannelGroup[0].Channels["speed"].GetConversion(); err == nil {
+		t.Fatal("expected an error on first access")
+	}
+}
+
+// manyChannelsFixture has one channel group with `n` uint8 channels, each
+// one with a conversion, a source and a comment
+func manyChannelsFixture(n int) *fixture {
+	f := newFixture(410)
+
+	channels := make([]int64, n)
+	for i := range channels {
+		cn := f.channel(fmt.Sprintf("signal_%d", i), cnData{ByteOffset: uint32(i), BitCount: 8})
+		f.link(cn, 3, f.block("##SI", []int64{f.text(fmt.Sprintf("ecu_%d", i)), 0, 0}, []byte{1, 2, 0, 0, 0, 0, 0, 0}))
+		f.link(cn, 4, f.linear(0, 0.5))
+		f.link(cn, 7, f.metadata(fmt.Sprintf("<CNcomment><TX>signal %d</TX></CNcomment>", i)))
+		channels[i] = cn
+	}
+
+	cg := f.block("##CG", []int64{0, f.chain(0, channels...), 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: uint32(n)}))
+	dt := f.block("##DT", nil, make([]byte, n))
+	dg := f.block("##DG", []int64{0, cg, dt, 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+	return f
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	f := manyChannelsFixture(5000)
+
+	for _, lazy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("LazyMetadata=%v", lazy), func(b *testing.B) {
+			file := f.open(b)
+			for i := 0; i < b.N; i++ {
+				m, err := mf4.ReadFile(file, &mf4.ReadOptions{LazyMetadata: lazy})
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				sample, err := m.ChannelGroup[0].Channels["signal_42"].Sample()
+				if err != nil || len(sample) != 1 {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+// Package ldf parses LIN description files (LDF) and decodes the signals of
+// LIN frames: nodes, signals, unconditional frames and signal encodings.
+// Schedule tables, node attributes and event triggered or sporadic frames are
+// skipped.
+package ldf
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+	"github.com/LincolnG4/GoMDF/dbc"
+)
+
+// File is a LIN description file
+type File struct {
+	ProtocolVersion string
+	LanguageVersion string
+
+	//bit rate of the bus, in bit/s
+	Speed float64
+
+	Master string
+	Slaves []string
+
+	//signals and frames, in the order of the file
+	Signals []*Signal
+	Frames  []*Frame
+
+	//signal encoding types, by name
+	Encodings map[string]*Encoding
+
+	byID map[uint8]*Frame
+}
+
+// Signal is a signal of a LIN cluster
+type Signal struct {
+	Name string
+
+	//size in bits
+	Size uint32
+
+	//initial value, one value per byte for byte array signals
+	Init []uint64
+
+	Publisher   string
+	Subscribers []string
+
+	//encoding of the signal, or 'nil'
+	Encoding *Encoding
+}
+
+// Frame is an unconditional frame
+type Frame struct {
+	Name      string
+	ID        uint8
+	Publisher string
+
+	//number of data bytes
+	Length uint8
+
+	Signals []FrameSignal
+}
+
+// FrameSignal is a signal of a frame, at its bit offset
+type FrameSignal struct {
+	*Signal
+	Offset uint32
+}
+
+// Encoding is a signal encoding type
+type Encoding struct {
+	Name     string
+	Physical []PhysicalRange
+
+	//texts of raw values
+	Logical map[uint64]string
+}
+
+// PhysicalRange converts the raw values from Min to Max: physical value = raw
+// value * Scale + Offset
+type PhysicalRange struct {
+	Min    uint64
+	Max    uint64
+	Scale  float64
+	Offset float64
+	Unit   string
+}
+
+// ParseFile parses the LDF file at `path`
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// ParseAttachment parses an LDF embedded in, or referenced by, an attachment
+// of an MF4 file
+func ParseAttachment(at AT.AttFile) (*File, error) {
+	r, err := at.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	f, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("attachment %s: %w", at.Name, err)
+	}
+	return f, nil
+}
+
+// Frame returns the frame with identifier `id`, or 'nil'
+func (f *File) Frame(id uint8) *Frame {
+	return f.byID[id]
+}
+
+// FrameByName returns the frame `name`, or 'nil'
+func (f *File) FrameByName(name string) *Frame {
+	for _, fr := range f.Frames {
+		if fr.Name == name {
+			return fr
+		}
+	}
+	return nil
+}
+
+// Signal returns the signal `name`, or 'nil'
+func (f *File) Signal(name string) *Signal {
+	for _, s := range f.Signals {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Raw returns the raw value of the signal in the data of its frame, and
+// `false` if the data is too short or the signal is a byte array longer than
+// 64 bits. LIN signals are little endian.
+func (s FrameSignal) Raw(data []byte) (uint64, bool) {
+	if s.Size == 0 || s.Size > 64 || (s.Offset+s.Size+7)/8 > uint32(len(data)) {
+		return 0, false
+	}
+
+	var v uint64
+	for i := uint32(0); i < s.Size; i++ {
+		pos := s.Offset + i
+		v |= uint64(data[pos/8]>>(pos%8)&1) << i
+	}
+	return v, true
+}
+
+// Decode returns the physical value of the signal in the data of its frame.
+// Raw values outside of the physical ranges of the encoding are returned as
+// they are.
+func (s FrameSignal) Decode(data []byte) (float64, bool) {
+	v, ok := s.Raw(data)
+	if !ok {
+		return 0, false
+	}
+	if s.Encoding != nil {
+		return s.Encoding.Value(v), true
+	}
+	return float64(v), true
+}
+
+// Value returns the physical value of the raw value `v`, or `v` if it is
+// outside of the physical ranges
+func (e *Encoding) Value(v uint64) float64 {
+	if r, ok := e.Range(v); ok {
+		return float64(v)*r.Scale + r.Offset
+	}
+	return float64(v)
+}
+
+// ValueTexts returns the texts of the logical values by physical value, see
+// Value, or 'nil' if the encoding has no logical values
+func (e *Encoding) ValueTexts() map[float64]string {
+	if len(e.Logical) == 0 {
+		return nil
+	}
+
+	r := make(map[float64]string, len(e.Logical))
+	for v, text := range e.Logical {
+		r[e.Value(v)] = text
+	}
+	return r
+}
+
+// Range returns the physical range of the raw value `v`
+func (e *Encoding) Range(v uint64) (PhysicalRange, bool) {
+	for _, r := range e.Physical {
+		if v >= r.Min && v <= r.Max {
+			return r, true
+		}
+	}
+	return PhysicalRange{}, false
+}
+
+// Decode returns the physical values of the signals in the data of the frame
+func (fr *Frame) Decode(data []byte) map[string]float64 {
+	r := make(map[string]float64, len(fr.Signals))
+	for _, s := range fr.Signals {
+		if v, ok := s.Decode(data); ok {
+			r[s.Name] = v
+		}
+	}
+	return r
+}
+
+// Message returns the frame as a DBC message, to decode it with the dbc
+// package. Signals use the first physical range of their encoding and the
+// logical values as value table, so the message only decodes the values of
+// that range: use FrameSignal.Decode for the others. Byte array signals longer
+// than 64 bits are left out.
+func (fr *Frame) Message() *dbc.Message {
+	m := &dbc.Message{
+		ID:          uint32(fr.ID),
+		Name:        fr.Name,
+		Size:        uint32(fr.Length),
+		Transmitter: fr.Publisher,
+		Signals:     make([]*dbc.Signal, 0, len(fr.Signals)),
+	}
+	for _, s := range fr.Signals {
+		if s.Size == 0 || s.Size > 64 {
+			continue
+		}
+		ds := &dbc.Signal{
+			Name:         s.Name,
+			StartBit:     s.Offset,
+			Length:       s.Size,
+			LittleEndian: true,
+			Factor:       1,
+			Max:          float64(uint64(1)<<s.Size - 1),
+			Receivers:    s.Subscribers,
+		}
+		if s.Encoding != nil {
+			if len(s.Encoding.Physical) > 0 {
+				r := s.Encoding.Physical[0]
+				ds.Factor, ds.Offset, ds.Unit = r.Scale, r.Offset, r.Unit
+				ds.Min = float64(r.Min)*r.Scale + r.Offset
+				ds.Max = float64(r.Max)*r.Scale + r.Offset
+			}
+			if len(s.Encoding.Logical) > 0 {
+				ds.ValueTable = make(map[int64]string, len(s.Encoding.Logical))
+				for v, text := range s.Encoding.Logical {
+					ds.ValueTable[int64(v)] = text
+				}
+			}
+		}
+		m.Signals = append(m.Signals, ds)
+	}
+	return m
+}
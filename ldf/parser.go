@@ -0,0 +1,490 @@
+package ldf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// token is a word, number, string or punctuation of an LDF file
+type token struct {
+	text string
+
+	//the token is a quoted string, without the quotes
+	quoted bool
+
+	line int
+}
+
+// lex splits an LDF file in tokens, without comments
+func lex(src string) ([]token, error) {
+	tokens := make([]token, 0, len(src)/4)
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			tokens = append(tokens, token{text: src[i+1 : i+1+end], quoted: true, line: line})
+			line += strings.Count(src[i+1:i+1+end], "\n")
+			i += end + 2
+		case strings.IndexByte("{}:;,=", c) >= 0:
+			tokens = append(tokens, token{text: src[i : i+1], line: line})
+			i++
+		default:
+			// words and numbers: identifiers, decimal, hexadecimal and
+			// floating point numbers
+			j := i
+			for j < len(src) && strings.IndexByte(" \t\r\n{}:;,=\"/", src[j]) < 0 {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("line %d: unexpected %q", line, c)
+			}
+			tokens = append(tokens, token{text: src[i:j], line: line})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parser reads the sections of an LDF file
+type parser struct {
+	tokens []token
+	pos    int
+	f      *File
+}
+
+// Parse parses a LIN description file
+func Parse(r io.Reader) (*File, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := lex(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		tokens: tokens,
+		f: &File{
+			Signals:   make([]*Signal, 0),
+			Frames:    make([]*Frame, 0),
+			Encodings: make(map[string]*Encoding),
+			byID:      make(map[uint8]*Frame),
+		},
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	for _, fr := range p.f.Frames {
+		p.f.byID[fr.ID] = fr
+	}
+	return p.f, nil
+}
+
+// parse reads all the sections
+func (p *parser) parse() error {
+	for !p.done() {
+		t := p.next()
+		var err error
+		switch t.text {
+		case "LIN_protocol_version":
+			p.f.ProtocolVersion, err = p.assignment()
+		case "LIN_language_version":
+			p.f.LanguageVersion, err = p.assignment()
+		case "LIN_speed":
+			var speed string
+			if speed, err = p.assignment(); err == nil {
+				err = p.speed(t, speed)
+			}
+		case "Nodes":
+			err = p.section(p.nodes)
+		case "Signals":
+			err = p.section(p.signals)
+		case "Frames":
+			err = p.section(p.frames)
+		case "Signal_encoding_types":
+			err = p.section(p.encodings)
+		case "Signal_representation":
+			err = p.section(p.representations)
+		default:
+			// other statements end with a semicolon, other sections with
+			// their closing brace
+			err = p.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	if t.line == 0 && len(p.tokens) > 0 {
+		t.line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+// expect reads the punctuation `s`
+func (p *parser) expect(s string) error {
+	t := p.next()
+	if t.text != s || t.quoted {
+		return p.errorf(t, "expected %q, got %q", s, t.text)
+	}
+	return nil
+}
+
+// accept reads the punctuation `s` if it's next
+func (p *parser) accept(s string) bool {
+	if t := p.peek(); t.text == s && !t.quoted {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// uint reads a decimal or hexadecimal integer
+func (p *parser) uint() (uint64, error) {
+	t := p.next()
+	v, err := strconv.ParseUint(t.text, 0, 64)
+	if err != nil {
+		return 0, p.errorf(t, "expected an integer, got %q", t.text)
+	}
+	return v, nil
+}
+
+func (p *parser) float() (float64, error) {
+	t := p.next()
+	v, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		if u, uerr := strconv.ParseUint(t.text, 0, 64); uerr == nil {
+			return float64(u), nil
+		}
+		return 0, p.errorf(t, "expected a number, got %q", t.text)
+	}
+	return v, nil
+}
+
+// assignment reads = <value> ;, the value being a string or the words up to
+// the semicolon
+func (p *parser) assignment() (string, error) {
+	if err := p.expect("="); err != nil {
+		return "", err
+	}
+	words := make([]string, 0, 2)
+	for !p.done() && !p.accept(";") {
+		words = append(words, p.next().text)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// speed sets the bit rate from "19.2 kbps"
+func (p *parser) speed(t token, s string) error {
+	value, unit, _ := strings.Cut(s, " ")
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return p.errorf(t, "invalid LIN speed %q", s)
+	}
+	if unit == "kbps" || unit == "" {
+		v *= 1000
+	}
+	p.f.Speed = v
+	return nil
+}
+
+// skip skips a statement up to its semicolon, or a section up to its closing
+// brace
+func (p *parser) skip() error {
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		if t.quoted {
+			continue
+		}
+		switch t.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	if depth > 0 {
+		return p.errorf(token{}, "unterminated section")
+	}
+	return nil
+}
+
+// section reads { <entries> } with `entry` reading each entry
+func (p *parser) section(entry func() error) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.accept("}") {
+		if p.done() {
+			return p.errorf(token{}, "unterminated section")
+		}
+		if err := entry(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list reads the words separated by commas up to the semicolon
+func (p *parser) list() []string {
+	r := make([]string, 0)
+	for !p.done() && !p.accept(";") {
+		if t := p.next(); t.text != "," || t.quoted {
+			r = append(r, t.text)
+		}
+	}
+	return r
+}
+
+// nodes reads Master: <node>, <time base>, <jitter> ; or Slaves: <nodes> ;
+func (p *parser) nodes() error {
+	name := p.next()
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	values := p.list()
+	switch name.text {
+	case "Master":
+		if len(values) > 0 {
+			p.f.Master = values[0]
+		}
+	case "Slaves":
+		p.f.Slaves = values
+	}
+	return nil
+}
+
+// signals reads <name>: <size>, <init>, <publisher>, <subscribers> ;, the
+// initial value of byte arrays being {<byte>, ...}
+func (p *parser) signals() error {
+	name := p.next()
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	size, err := p.uint()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(","); err != nil {
+		return err
+	}
+
+	s := &Signal{Name: name.text, Size: uint32(size), Init: make([]uint64, 0, 1)}
+	if p.accept("{") {
+		for !p.accept("}") {
+			if p.accept(",") {
+				continue
+			}
+			v, err := p.uint()
+			if err != nil {
+				return err
+			}
+			s.Init = append(s.Init, v)
+		}
+	} else {
+		v, err := p.uint()
+		if err != nil {
+			return err
+		}
+		s.Init = append(s.Init, v)
+	}
+	if err := p.expect(","); err != nil {
+		return err
+	}
+
+	nodes := p.list()
+	if len(nodes) == 0 {
+		return p.errorf(name, "signal %s has no publisher", name.text)
+	}
+	s.Publisher, s.Subscribers = nodes[0], nodes[1:]
+	p.f.Signals = append(p.f.Signals, s)
+	return nil
+}
+
+// frames reads <name>: <id>, <publisher>, <length> { <signal>, <offset> ; ... }
+func (p *parser) frames() error {
+	name := p.next()
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	id, err := p.uint()
+	if err != nil {
+		return err
+	}
+	if id > 0x3f {
+		return p.errorf(name, "invalid identifier %d of frame %s", id, name.text)
+	}
+	if err := p.expect(","); err != nil {
+		return err
+	}
+	publisher := p.next()
+	if err := p.expect(","); err != nil {
+		return err
+	}
+	length, err := p.uint()
+	if err != nil {
+		return err
+	}
+
+	fr := &Frame{Name: name.text, ID: uint8(id), Publisher: publisher.text, Length: uint8(length), Signals: make([]FrameSignal, 0)}
+	err = p.section(func() error {
+		t := p.next()
+		if err := p.expect(","); err != nil {
+			return err
+		}
+		offset, err := p.uint()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+		s := p.f.Signal(t.text)
+		if s == nil {
+			return p.errorf(t, "unknown signal %s in frame %s", t.text, name.text)
+		}
+		fr.Signals = append(fr.Signals, FrameSignal{Signal: s, Offset: uint32(offset)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.f.Frames = append(p.f.Frames, fr)
+	return nil
+}
+
+// encodings reads <name> { physical_value, <min>, <max>, <scale>, <offset>
+// [, "<unit>"] ; logical_value, <value> [, "<text>"] ; ... }
+func (p *parser) encodings() error {
+	name := p.next()
+	e := &Encoding{Name: name.text, Physical: make([]PhysicalRange, 0), Logical: make(map[uint64]string)}
+	err := p.section(func() error {
+		kind := p.next()
+		switch kind.text {
+		case "physical_value":
+			var r PhysicalRange
+			if err := p.expect(","); err != nil {
+				return err
+			}
+			var err error
+			if r.Min, err = p.uint(); err != nil {
+				return err
+			}
+			if err := p.expect(","); err != nil {
+				return err
+			}
+			if r.Max, err = p.uint(); err != nil {
+				return err
+			}
+			if err := p.expect(","); err != nil {
+				return err
+			}
+			if r.Scale, err = p.float(); err != nil {
+				return err
+			}
+			if err := p.expect(","); err != nil {
+				return err
+			}
+			if r.Offset, err = p.float(); err != nil {
+				return err
+			}
+			if p.accept(",") {
+				r.Unit = p.next().text
+			}
+			e.Physical = append(e.Physical, r)
+		case "logical_value":
+			if err := p.expect(","); err != nil {
+				return err
+			}
+			v, err := p.uint()
+			if err != nil {
+				return err
+			}
+			text := ""
+			if p.accept(",") {
+				text = p.next().text
+			}
+			e.Logical[v] = text
+		default:
+			// bcd_value and ascii_value
+		}
+		return p.skip()
+	})
+	if err != nil {
+		return err
+	}
+	p.f.Encodings[e.Name] = e
+	return nil
+}
+
+// representations reads <encoding>: <signals> ;
+func (p *parser) representations() error {
+	name := p.next()
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	e, ok := p.f.Encodings[name.text]
+	if !ok {
+		return p.errorf(name, "unknown signal encoding type %s", name.text)
+	}
+	for _, signal := range p.list() {
+		if s := p.f.Signal(signal); s != nil {
+			s.Encoding = e
+		}
+	}
+	return nil
+}
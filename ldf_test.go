@@ -0,0 +1,168 @@
+package mf4_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LincolnG4/GoMDF/buslog"
+	"github.com/LincolnG4/GoMDF/ldf"
+)
+
+// doorLDF describes the LIN frames of busFixture
+const doorLDF = `LIN_description_file;
+LIN_protocol_version = "2.1";
+LIN_language_version = "2.1";
+LIN_speed = 19.2 kbps;
+
+Nodes {
+  Master: BCM, 5 ms, 0.1 ms;
+  Slaves: Door;
+}
+
+Signals {
+  WindowPos: 8, 0, Door, BCM;
+  WindowState: 2, 0, Door, BCM;
+  Serial: 16, {0, 0}, Door, BCM;
+  Command: 8, 0, BCM, Door;
+}
+
+Diagnostic_signals {
+  MasterReqB0: 8, 0;
+}
+
+Frames {
+  DoorStatus: 0x10, Door, 2 {
+    WindowPos, 0;
+    WindowState, 8;
+  }
+  DoorCommand: 17, BCM, 1 {
+    Command, 0; // open or close
+  }
+}
+
+Schedule_tables {
+  Normal {
+    DoorStatus delay 10 ms;
+  }
+}
+
+/* window position in percent, or invalid */
+Signal_encoding_types {
+  Position {
+    physical_value, 0, 99, 1, -40, "%";
+    physical_value, 100, 200, 0.5, 10, "%";
+    logical_value, 255, "invalid";
+  }
+  State {
+    logical_value, 0, "closed";
+    logical_value, 1, "open";
+    logical_value, 2, "moving";
+  }
+}
+
+Signal_representation {
+  Position: WindowPos;
+  State: WindowState;
+}
+`
+
+func parseDoorLDF(t *testing.T) *ldf.File {
+	t.Helper()
+	f, err := ldf.Parse(strings.NewReader(doorLDF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestParseLDF(t *testing.T) {
+	f := parseDoorLDF(t)
+	if f.ProtocolVersion != "2.1" || f.Speed != 19200 || f.Master != "BCM" || len(f.Slaves) != 1 || f.Slaves[0] != "Door" {
+		t.Fatalf("wrong LDF %+v", f)
+	}
+	if len(f.Signals) != 4 || len(f.Frames) != 2 {
+		t.Fatalf("expected 4 signals and 2 frames, got %d and %d", len(f.Signals), len(f.Frames))
+	}
+	if serial := f.Signal("Serial"); serial.Size != 16 || len(serial.Init) != 2 || serial.Publisher != "Door" || serial.Subscribers[0] != "BCM" {
+		t.Fatalf("wrong byte array signal %+v", serial)
+	}
+
+	status := f.Frame(0x10)
+	if status == nil || status.Name != "DoorStatus" || status.Publisher != "Door" || status.Length != 2 || len(status.Signals) != 2 {
+		t.Fatalf("wrong frame %+v", status)
+	}
+	if status.Signals[1].Offset != 8 || status.Signals[1].Encoding.Logical[2] != "moving" {
+		t.Fatalf("wrong frame signal %+v", status.Signals[1])
+	}
+	if f.FrameByName("DoorCommand").ID != 17 {
+		t.Fatal("expected a decimal identifier")
+	}
+
+	values := status.Decode([]byte{0x64, 0x02})
+	if values["WindowPos"] != 60 || values["WindowState"] != 2 {
+		t.Fatalf("wrong values %v", values)
+	}
+	// raw values outside of the physical ranges are kept
+	if v, _ := status.Signals[0].Decode([]byte{0xff, 0}); v != 255 {
+		t.Fatalf("expected the raw value, got %f", v)
+	}
+
+	if _, err := ldf.Parse(strings.NewReader("Frames {\n  A: 1, X, 1 {\n    Unknown, 0;\n  }\n}\n")); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("expected an error for an unknown signal, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "door.ldf")
+	if err := os.WriteFile(path, []byte(doorLDF), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := ldf.ParseFile(path); err != nil || len(f.Frames) != 2 {
+		t.Fatalf("expected the LDF file %v", err)
+	}
+}
+
+func TestDecodeLIN(t *testing.T) {
+	frames, err := buslog.ReadLIN(busFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups := buslog.DecodeLIN(frames, parseDoorLDF(t))
+	if len(groups) != 2 || groups[0].Name() != "LIN1.DoorStatus" || groups[1].Name() != "LIN1.DoorCommand" {
+		t.Fatalf("wrong signal groups %v", groups)
+	}
+	timestamps, position := groups[0].Signal("WindowPos")
+	if len(timestamps) != 1 || position[0] != 60 {
+		t.Fatalf("wrong window position %v %v", timestamps, position)
+	}
+
+	// each raw value uses the physical range it is in, values of none of
+	// the ranges are kept raw
+	ranged := buslog.DecodeLIN([]buslog.LINFrame{
+		{Timestamp: 0, BusChannel: 1, ID: 0x10, Data: []byte{10, 0}},
+		{Timestamp: 1, BusChannel: 1, ID: 0x10, Data: []byte{150, 0}},
+		{Timestamp: 2, BusChannel: 1, ID: 0x10, Data: []byte{255, 0}},
+	}, parseDoorLDF(t))
+	if _, position := ranged[0].Signal("WindowPos"); len(position) != 3 || position[0] != -30 || position[1] != 85 || position[2] != 255 {
+		t.Fatalf("wrong window positions %v", position)
+	}
+	if text := ranged[0].ValueTexts[0][255]; text != "invalid" {
+		t.Fatalf("expected the logical value of the raw value, got %q", text)
+	}
+
+	path := filepath.Join(t.TempDir(), "door.mf4")
+	if err := buslog.ExportSignals(path, time.Time{}, groups); err != nil {
+		t.Fatal(err)
+	}
+	decoded := reopen(t, path)
+	state := decoded.ChannelGroup[0].Channels["WindowState"]
+	sample, err := state.Sample()
+	if err != nil || len(sample) != 1 || sample[0] != "moving" {
+		t.Fatalf("expected the logical value, got %v %v", sample, err)
+	}
+	if unit := decoded.ChannelGroup[0].Channels["WindowPos"].GetUnit(); unit != "%" {
+		t.Fatalf("expected unit %%, got %q", unit)
+	}
+}
@@ -0,0 +1,188 @@
+package mf4
+
+import (
+	"fmt"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/AT"
+)
+
+// IsMaster returns `true` if channel is the master (or virtual master) of its
+// channel group
+func (c *Channel) IsMaster() bool {
+	return c.block.IsMaster()
+}
+
+// IsSynchronization returns `true` if channel is a synchronization channel,
+// i.e. its values are positions in an external stream like a video
+func (c *Channel) IsSynchronization() bool {
+	return c.block.IsSynchronization()
+}
+
+// SyncDomain returns the synchronization domain of a master or
+// synchronization channel ("TIME", "ANGLE", "DISTANCE", "INDEX"). It returns
+// "NONE" for value channels.
+func (c *Channel) SyncDomain() string {
+	return c.block.SyncDomain()
+}
+
+// MasterDomain returns the synchronization domain of the channel's master.
+// If the channel is the master, its own domain is returned.
+func (c *Channel) MasterDomain() string {
+	if c.IsMaster() {
+		return c.SyncDomain()
+	}
+
+	if c.Master == nil || c.Master.block == nil {
+		return blocks.NoSyncDomain
+	}
+	return c.Master.SyncDomain()
+}
+
+// SyncChannels returns the synchronization channels of the channel group
+// the channel belongs to. Their samples map each record to a position in the
+// referenced stream, see SyncReference.
+func (c *Channel) SyncChannels() []*Channel {
+	if c.group == nil {
+		return nil
+	}
+
+	r := make([]*Channel, 0)
+	for _, cn := range c.group.Channels {
+		if cn.IsSynchronization() {
+			r = append(r, cn)
+		}
+	}
+	return r
+}
+
+// SyncReference returns the attachment (e.g. a video or another measurement
+// file) a synchronization channel points to.
+func (c *Channel) SyncReference() (*AT.AttFile, error) {
+	if !c.IsSynchronization() {
+		return nil, fmt.Errorf("channel %s is not a synchronization channel", c.Name)
+	}
+
+	if c.block.Link.Data == 0 {
+		return nil, fmt.Errorf("channel %s has no stream reference", c.Name)
+	}
+
+	return c.mf4.loadAttachment(c.block.Link.Data)
+}
+
+// MasterValues returns the master axis for each sample of the channel. Angle
+// and distance values are shifted by the start angle/start distance of the
+// measurement, when it is valid, so they are absolute values in radians or
+// meters.
+func (c *Channel) MasterValues() ([]float64, error) {
+	master := c
+	if !c.IsMaster() {
+		master = c.Master
+	}
+
+	if master == nil || master.block == nil {
+		return nil, fmt.Errorf("channel %s has no master channel", c.Name)
+	}
+
+	sample, err := master.Sample()
+	if err != nil {
+		return nil, err
+	}
+	return master.masterAxis(sample)
+}
+
+// masterAxis converts samples of the master channel to master values, shifted
+// by the start angle/start distance of the measurement. It is used by all
+// reads of master values, so they are in the same absolute scale.
+func (c *Channel) masterAxis(sample []interface{}) ([]float64, error) {
+	offset := c.mf4.syncOffset(c.SyncDomain())
+	values := make([]float64, len(sample))
+	for i, v := range sample {
+		f, err := toFloat64(v)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = f + offset
+	}
+	return values, nil
+}
+
+// syncOffset returns the value added to the values of `domain` so they are
+// absolute: the start angle or start distance of the measurement when it is
+// valid, else 0
+func (m *MF4) syncOffset(domain string) float64 {
+	var offset float64
+	switch domain {
+	case blocks.AngleSyncDomain:
+		offset, _ = m.StartAngleRad()
+	case blocks.DistanceSyncDomain:
+		offset, _ = m.StartDistanceM()
+	}
+	return offset
+}
+
+// MasterChannels returns the master channels of the file synchronized in the
+// given domain ("TIME", "ANGLE", "DISTANCE", "INDEX").
+func (m *MF4) MasterChannels(domain string) []*Channel {
+	r := make([]*Channel, 0)
+	for _, cg := range m.ChannelGroup {
+		for _, cn := range cg.Channels {
+			if cn.IsMaster() && cn.SyncDomain() == domain {
+				r = append(r, cn)
+			}
+		}
+	}
+	return r
+}
+
+// SampleByDomain returns the samples of the channel together with its master
+// axis, given the master is synchronized in `domain`. It allows to get, for
+// instance, samples by crank angle or by distance driven.
+func (m *MF4) SampleByDomain(domain string, channelName string) ([]float64, []interface{}, error) {
+	for _, cg := range m.ChannelGroup {
+		cn, ok := cg.Channels[channelName]
+		if !ok || cn.MasterDomain() != domain {
+			continue
+		}
+
+		axis, err := cn.MasterValues()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sample, err := cn.Sample()
+		if err != nil {
+			return nil, nil, err
+		}
+		return axis, sample, nil
+	}
+	return nil, nil, fmt.Errorf("channel %s with %s master doesn't exist", channelName, domain)
+}
+
+// toFloat64 converts a numeric sample value to float64
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("variable type %T: not numerical", v)
+	}
+}
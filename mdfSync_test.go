@@ -0,0 +1,217 @@
+package mf4_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks"
+)
+
+// angleFixture has one channel group with an angle master, a value channel and
+// a synchronization channel pointing to a video attachment, and an event at
+// 0.5 rad after the start angle
+func angleFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+	f.header(hdData{Flags: 1, StartAngleRad: 1.5})
+
+	at := f.block("##AT", []int64{0, f.text("video.mp4"), f.text("video/mp4"), 0}, make([]byte, 40))
+	f.link(hdAddress, 3, at)
+
+	angle := f.channel("angle", cnData{Type: 2, SyncType: 2, DataType: 4, BitCount: 64})
+	value := f.channel("torque", cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+	frame := f.channel("frame", cnData{Type: 4, SyncType: 4, DataType: 4, ByteOffset: 16, BitCount: 64})
+	f.link(frame, 5, at)
+
+	cg := f.block("##CG", []int64{0, f.chain(0, angle, value, frame), 0, 0, 0, 0}, encode(cgData{CycleCount: 3, DataBytes: 24}))
+	dt := f.block("##DT", nil, float64Records([]float64{0, 10, 0}, []float64{0.5, 20, 1}, []float64{1, 30, 2}))
+	dg := f.block("##DG", []int64{0, cg, dt, 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+	f.link(hdAddress, 4, f.block("##EV", []int64{0, 0, 0, f.text("TDC"), 0}, encode(evData{SyncType: 2, SyncBaseValue: 5, SyncFactor: 0.1})))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMasterChannelsByDomain(t *testing.T) {
+	m := angleFixture(t)
+
+	if n := len(m.MasterChannels(blocks.AngleSyncDomain)); n != 1 {
+		t.Fatalf("expected 1 angle master, got %d", n)
+	}
+	if n := len(m.MasterChannels(blocks.TimeSyncDomain)); n != 0 {
+		t.Fatalf("expected no time master, got %d", n)
+	}
+
+	axis, sample, err := m.SampleByDomain(blocks.AngleSyncDomain, "torque")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedAxis := []float64{1.5, 2, 2.5}
+	for i := range expectedAxis {
+		if axis[i] != expectedAxis[i] {
+			t.Fatalf("axis[%d]: expected %f, got %f", i, expectedAxis[i], axis[i])
+		}
+	}
+	if len(sample) != 3 || sample[2].(float64) != 30 {
+		t.Fatalf("wrong samples %v", sample)
+	}
+
+	if _, _, err := m.SampleByDomain(blocks.DistanceSyncDomain, "torque"); err == nil {
+		t.Fatal("expected error for distance domain")
+	}
+}
+
+func TestSyncChannelReference(t *testing.T) {
+	m := angleFixture(t)
+
+	cn := m.ChannelGroup[0].Channels["torque"]
+	syncs := cn.SyncChannels()
+	if len(syncs) != 1 || syncs[0].Name != "frame" {
+		t.Fatalf("expected sync channel frame, got %v", syncs)
+	}
+	if syncs[0].SyncDomain() != blocks.IndexSyncDomain {
+		t.Fatalf("expected INDEX domain, got %s", syncs[0].SyncDomain())
+	}
+
+	att, err := syncs[0].SyncReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Name != "video.mp4" {
+		t.Fatalf("expected video.mp4, got %s", att.Name)
+	}
+
+	if _, err := cn.SyncReference(); err == nil {
+		t.Fatal("expected error for value channel")
+	}
+}
+
+func TestTimeMasterDomain(t *testing.T) {
+	testcase := loadSimpleTestCase()
+	m, _ := mf4.ReadFile(testcase.file, &mf4.ReadOptions{})
+
+	masters := m.MasterChannels(blocks.TimeSyncDomain)
+	if len(masters) != 1 || masters[0].Name != "time" {
+		t.Fatalf("expected master time, got %v", masters)
+	}
+	if _, err := m.StartAngleRad(); err == nil {
+		t.Fatal("start angle should not be valid")
+	}
+}
+
+func TestRemoteMasterChannelGroup(t *testing.T) {
+	f := newFixture(420)
+
+	time := f.channel("time", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	cgMaster := f.block("##CG", []int64{0, time, 0, 0, 0, 0, 0}, encode(cgData{CycleCount: 2, DataBytes: 8}))
+	dtMaster := f.block("##DT", nil, float64Records([]float64{0.1}, []float64{0.2}))
+	dg1 := f.block("##DG", []int64{0, cgMaster, dtMaster, 0}, make([]byte, 8))
+
+	speed := f.channel("speed", cnData{DataType: 4, BitCount: 64})
+	cgRemote := f.block("##CG", []int64{0, speed, 0, 0, 0, 0, cgMaster}, encode(cgData{CycleCount: 2, Flags: 1 << 3, DataBytes: 8}))
+	dtRemote := f.block("##DT", nil, float64Records([]float64{50}, []float64{60}))
+	dg2 := f.block("##DG", []int64{0, cgRemote, dtRemote, 0}, make([]byte, 8))
+
+	f.link(hdAddress, 0, f.chain(0, dg1, dg2))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cn := m.ChannelGroup[1].Channels["speed"]
+	if cn.Master == nil || cn.Master.Name != "time" {
+		t.Fatalf("expected remote master time, got %v", cn.Master)
+	}
+	if m.ChannelGroup[1].Master().Name != "time" {
+		t.Fatal("channel group master not linked")
+	}
+	if cn.MasterDomain() != blocks.TimeSyncDomain {
+		t.Fatalf("expected TIME domain, got %s", cn.MasterDomain())
+	}
+
+	axis, err := cn.MasterValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(axis) != 2 || axis[0] != 0.1 || axis[1] != 0.2 {
+		t.Fatalf("wrong master values %v", axis)
+	}
+}
+
+func TestRemoteMasterChain(t *testing.T) {
+	f := newFixture(420)
+
+	// "a" references the master of "b", which references the master of "c"
+	time := f.channel("time", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	cgC := f.block("##CG", []int64{0, time, 0, 0, 0, 0, 0}, encode(cgData{CycleCount: 2, DataBytes: 8}))
+	cgB := f.block("##CG", []int64{0, f.channel("b", cnData{DataType: 4, BitCount: 64}), 0, 0, 0, 0, cgC}, encode(cgData{CycleCount: 2, Flags: 1 << 3, DataBytes: 8}))
+	cgA := f.block("##CG", []int64{0, f.channel("a", cnData{DataType: 4, BitCount: 64}), 0, 0, 0, 0, cgB}, encode(cgData{CycleCount: 2, Flags: 1 << 3, DataBytes: 8}))
+
+	dataGroup := func(cg int64) int64 {
+		dt := f.block("##DT", nil, float64Records([]float64{1}, []float64{2}))
+		return f.block("##DG", []int64{0, cg, dt, 0}, make([]byte, 8))
+	}
+	f.link(hdAddress, 0, f.chain(0, dataGroup(cgA), dataGroup(cgB), dataGroup(cgC)))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	master := m.ChannelGroup[2].Channels["time"]
+	for i, name := range []string{"a", "b"} {
+		if cn := m.ChannelGroup[i].Channels[name]; cn.Master != master {
+			t.Fatalf("%s: expected master time of the last group, got %v", name, cn.Master)
+		}
+		if m.ChannelGroup[i].Master() != master {
+			t.Fatalf("%s: channel group master not linked", name)
+		}
+	}
+}
+
+func TestChannelAttachments(t *testing.T) {
+	f := newFixture(410)
+	dbc := f.block("##AT", []int64{0, f.text("vehicle.dbc"), 0, 0}, make([]byte, 40))
+	arxml := f.block("##AT", []int64{0, f.text("vehicle.arxml"), 0, 0}, make([]byte, 40))
+	f.link(hdAddress, 3, f.chain(0, dbc, arxml))
+
+	time := f.channel("time", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	// two attachments, then the default X axis
+	links := []int64{0, 0, f.text("frame"), 0, 0, 0, 0, 0, dbc, arxml, 0, 0, time}
+	frame := f.block("##CN", links, encode(cnData{DataType: 4, ByteOffset: 8, BitCount: 64, Flags: 1 << 12, AttachmentCount: 2}))
+
+	cg := f.block("##CG", []int64{0, f.chain(0, time, frame), 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 16}))
+	dg := f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records([]float64{0, 7})), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cn := m.ChannelGroup[0].Channels["frame"]
+	at, err := cn.Attachments()
+	if err != nil || len(at) != 2 || at[0].Name != "vehicle.dbc" || at[1].Name != "vehicle.arxml" {
+		t.Fatalf("wrong channel attachments %v %v", at, err)
+	}
+	if sample, err := cn.Sample(); err != nil || sample[0] != 7.0 {
+		t.Fatalf("wrong samples %v %v", sample, err)
+	}
+	if at, err := m.ChannelGroup[0].Channels["time"].Attachments(); err != nil || len(at) != 0 {
+		t.Fatalf("expected no attachments, got %v %v", at, err)
+	}
+
+	e, err := m.EditCopy(filepath.Join(t.TempDir(), "edited.mf4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Abort()
+	if err := e.RemoveAttachment("vehicle.arxml"); err == nil {
+		t.Fatal("expected an error removing an attachment referenced by a channel")
+	}
+}
@@ -3,17 +3,58 @@ package mf4
 import (
 	"fmt"
 	"time"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/EV"
 )
 
+// GetTimeNs returns the wall clock time `t`, in nanoseconds, at the place of
+// the recording. The time zone `tzo` and daylight saving `dlo` offsets, in
+// minutes, are added only if they are valid for the time flags `tf`.
+//
+// Deprecated: use StartTime, which returns the time in its time zone.
 func (m *MF4) GetTimeNs(t uint64, tzo uint64, dlo uint64, tf uint8) int64 {
-	if m.isTimeOffsetValid(tf) {
+	if !m.isTimeOffsetValid(tf) {
 		return int64(t)
 	}
-	return int64(t + tzo + dlo)
+	return int64(t) + (int64(int16(tzo))+int64(int16(dlo)))*int64(time.Minute)
+}
+
+// StartTime returns the start time of the measurement. When the time offsets
+// are valid, the time is in a fixed zone built from the time zone and daylight
+// saving offsets of the header. When the header stores local time, the wall
+// clock of the recording is returned in blocks.LocalTimeZone, as its zone is
+// unknown, unless the time class is an external absolute source, whose time
+// is UTC.
+func (m *MF4) StartTime() time.Time {
+	return m.Header.StartTime()
+}
+
+// TimeClass returns the time source of the start time: LOCAL_PC,
+// EXTERNAL or EXTERNAL_ABSOLUTE (GPS, PTP...)
+func (m *MF4) TimeClass() string {
+	if c, ok := blocks.TimeClassMap[m.getTimeClass()]; ok {
+		return c
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", m.getTimeClass())
+}
+
+// IsLocalTime reports whether the start time is the local time of the
+// recording, without time zone information
+func (m *MF4) IsLocalTime() bool {
+	return blocks.IsLocalTime(m.getTimeFlag(), m.getTimeClass())
 }
 
-func (m *MF4) formatTimeLT(t int64) time.Time {
-	return time.Unix(0, t)
+// EventTime returns the absolute time of an event synchronized on time, the
+// start time of the measurement plus the event position in seconds
+func (m *MF4) EventTime(ev *EV.Event) (time.Time, error) {
+	data := ev.Block.Data
+	if blocks.SyncTypeMap[data.SyncType] != blocks.TimeSyncDomain {
+		return time.Time{}, fmt.Errorf("event %s is not synchronized on time", ev.Name)
+	}
+
+	position := float64(data.SyncBaseValue) * data.SyncFactor
+	return m.StartTime().Add(time.Duration(position * float64(time.Second))), nil
 }
 
 // Time zone offset in minutes. Range (-840, 840) minutes. For instance,
@@ -37,12 +78,11 @@ func (m *MF4) DaylightOffsetMin(tFlag uint8) (int16, error) {
 }
 
 func (m *MF4) getDaylightOffsetMin() int16 {
-	return m.Header.Data.TZOffsetMin
+	return m.Header.Data.DSTOffsetMin
 }
 
-// [False]: Local time flag
-//
-// [True]: Time offsets valid flag
+// isTimeOffsetValid checks the "time offsets valid" flag (bit 1). The local
+// time flag (bit 0) takes precedence.
 func (m *MF4) isTimeOffsetValid(timeFlag uint8) bool {
-	return timeFlag == 1
+	return timeFlag&blocks.LocalTimeFlag == 0 && timeFlag&blocks.TimeOffsetsValidFlag != 0
 }
@@ -0,0 +1,112 @@
+package mf4_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks"
+)
+
+type evData struct {
+	Type            uint8
+	SyncType        uint8
+	RangeType       uint8
+	Cause           uint8
+	Flags           uint8
+	Reserved1       [3]byte
+	ScopeCount      uint32
+	AttachmentCount uint16
+	CreatorIndex    uint16
+	SyncBaseValue   int64
+	SyncFactor      float64
+}
+
+// timeFixture starts at 2024-03-31 10:00 UTC, recorded in UTC+1 with one
+// hour of daylight saving, and has an event 90 seconds after the start
+func timeFixture(t *testing.T, flags, class uint8) *mf4.MF4 {
+	f := newFixture(420)
+	start := time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)
+	f.header(hdData{StartTimeNs: uint64(start.UnixNano()), TZOffsetMin: 60, DSTOffsetMin: 60, TimeFlags: flags, TimeClass: class})
+
+	fh := f.block("##FH", []int64{0, f.metadata("<FHcomment><TX>created</TX></FHcomment>")},
+		encode(struct {
+			TimeNs   uint64
+			TZ, DST  int16
+			Flags    uint8
+			Reserved [3]byte
+		}{uint64(start.Add(-time.Hour).UnixNano()), -300, 0, 2, [3]byte{}}))
+	f.link(hdAddress, 1, fh)
+
+	ev := f.block("##EV", []int64{0, 0, 0, f.text("trigger"), 0},
+		encode(evData{SyncType: 1, SyncBaseValue: 180, SyncFactor: 0.5}))
+	f.link(hdAddress, 4, ev)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestStartTime(t *testing.T) {
+	m := timeFixture(t, 2, 16)
+
+	start := m.StartTime()
+	if _, offset := start.Zone(); offset != 2*3600 {
+		t.Fatalf("expected offset of 2 hours, got %d s", offset)
+	}
+	if start.Hour() != 12 || !start.Equal(time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("wrong start time %v", start)
+	}
+	if m.TimeClass() != "EXTERNAL_ABSOLUTE" || m.IsLocalTime() {
+		t.Fatalf("wrong time class %s", m.TimeClass())
+	}
+
+	dst, err := m.DaylightOffsetMin(m.Header.Data.TimeFlags)
+	if err != nil || dst != 60 {
+		t.Fatalf("expected daylight offset of 60 min, got %d %v", dst, err)
+	}
+
+	events := m.ListEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	at, err := m.EventTime(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, offset := at.Zone(); !at.Equal(start.Add(90*time.Second)) || offset != 2*3600 {
+		t.Fatalf("wrong event time %v", at)
+	}
+
+	log := m.ReadChangeLog()
+	if len(log) != 1 || !strings.HasPrefix(log[0], "2024-03-31 04:00:00 -0500") {
+		t.Fatalf("wrong change log %v", log)
+	}
+}
+
+func TestStartTimeLocal(t *testing.T) {
+	m := timeFixture(t, 1, 0)
+
+	start := m.StartTime()
+	if !m.IsLocalTime() || start.Location() != blocks.LocalTimeZone || start.Hour() != 10 {
+		t.Fatalf("local time not returned as is: %v", start)
+	}
+	if _, err := m.TimezoneOffsetMin(m.Header.Data.TZOffsetMin, m.Header.Data.TimeFlags); err == nil {
+		t.Fatal("expected error for time zone of local time")
+	}
+	if m.TimeClass() != "LOCAL_PC" {
+		t.Fatalf("wrong time class %s", m.TimeClass())
+	}
+}
+
+func TestStartTimeLocalExternalAbsolute(t *testing.T) {
+	m := timeFixture(t, 1, 16)
+
+	start := m.StartTime()
+	if m.IsLocalTime() || start.Location() != time.UTC || start.Hour() != 10 {
+		t.Fatalf("external absolute time not returned in UTC: %v", start)
+	}
+}
@@ -0,0 +1,27 @@
+package mf4
+
+import "github.com/LincolnG4/GoMDF/blocks/MD"
+
+// HeaderMeta returns the comment of the header parsed as <HDcomment>: the
+// description of the measurement, its time source, constants and common
+// properties such as author, department, project and subject
+func (m *MF4) HeaderMeta() *MD.HDComment {
+	meta := &MD.HDComment{}
+	MD.Parse(m.GetMeasureComment(), meta)
+	return meta
+}
+
+// Meta returns the comment of the channel parsed as <CNcomment>, with its
+// display name, linker name, address and axis monotony
+func (c *Channel) Meta() *MD.CNComment {
+	meta := &MD.CNComment{}
+	MD.Parse(c.GetComment(), meta)
+	return meta
+}
+
+// Meta returns the comment of the channel group block parsed as <CGcomment>
+func (cg *ChannelGroup) Meta() *MD.CGComment {
+	meta := &MD.CGComment{}
+	MD.Parse(MD.New(cg.mf4.reader(), cg.Block.Link.MdComment), meta)
+	return meta
+}
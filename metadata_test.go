@@ -0,0 +1,91 @@
+package mf4_test
+
+import (
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+const hdComment = `<HDcomment xmlns="http://www.asam.net/mdf/v4">
+<TX>Test drive</TX>
+<time_source>INCA PC Reference Time</time_source>
+<constants><const name="g">9.81</const></constants>
+<common_properties>
+	<e name="author">Jane</e>
+	<e name="project"> P42 </e>
+	<e name="subject">TestVehicle</e>
+	<tree name="vehicle">
+		<e name="vin">WVW123</e>
+		<tree name="engine"><e name="type" unit="cc">1984</e></tree>
+	</tree>
+	<list name="drivers"><tree><e name="name">A</e></tree><tree><e name="name">B</e></tree></list>
+</common_properties>
+</HDcomment>`
+
+// metadataFixture has an XML header comment, a channel with an XML comment and
+// a channel with a plain TX comment
+func metadataFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+	f.link(hdAddress, 5, f.metadata(hdComment))
+
+	speed := f.channel("speed", cnData{DataType: 4, BitCount: 64})
+	f.link(speed, 7, f.metadata(`<CNcomment><TX>Vehicle speed</TX><names><display>
+		Vehicle.Speed
+	</display></names><linker_name>vSpeed</linker_name><address byte_count="8">0x1000</address><axis_monotony>MON_INCREASE</axis_monotony></CNcomment>`))
+	rpm := f.channel("rpm", cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+	f.link(rpm, 7, f.text("engine speed"))
+
+	cg := f.block("##CG", []int64{0, f.chain(0, speed, rpm), 0, 0, 0, f.metadata("<CGcomment><TX>group</TX><names><name>CAN1</name></names></CGcomment>")},
+		encode(cgData{CycleCount: 1, DataBytes: 16}))
+	dg := f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records([]float64{1, 2})), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestHeaderMeta(t *testing.T) {
+	m := metadataFixture(t)
+
+	meta := m.HeaderMeta()
+	if meta.Author() != "Jane" || meta.Project() != "P42" || meta.Subject() != "TestVehicle" || meta.Department() != "" {
+		t.Fatalf("wrong common properties %+v", meta.Properties)
+	}
+	if meta.TX != "Test drive" || meta.TimeSource != "INCA PC Reference Time" {
+		t.Fatalf("wrong header comment %+v", meta)
+	}
+	if len(meta.Constants.Values) != 1 || meta.Constant("g") != "9.81" {
+		t.Fatalf("wrong constants %+v", meta.Constants)
+	}
+
+	vehicle := meta.Properties.Tree("vehicle")
+	if vehicle == nil || vehicle.Value("vin") != "WVW123" || vehicle.Tree("engine").Elements[0].Unit != "cc" {
+		t.Fatalf("wrong property tree %+v", vehicle)
+	}
+	if len(meta.Properties.Lists) != 1 || meta.Properties.Lists[0].Trees[1].Value("name") != "B" {
+		t.Fatalf("wrong property list %+v", meta.Properties.Lists)
+	}
+}
+
+func TestChannelMeta(t *testing.T) {
+	m := metadataFixture(t)
+	cg := m.ChannelGroup[0]
+
+	meta := cg.Channels["speed"].Meta()
+	if meta.DisplayName != "Vehicle.Speed" || meta.TX != "Vehicle speed" || meta.LinkerName != "vSpeed" ||
+		meta.Address != "0x1000" || meta.AxisMonotony != "MON_INCREASE" {
+		t.Fatalf("wrong channel comment %+v", meta)
+	}
+
+	meta = cg.Channels["rpm"].Meta()
+	if meta.TX != "engine speed" || meta.DisplayName != "" {
+		t.Fatalf("plain text comment not used as TX %+v", meta)
+	}
+
+	if group := cg.Meta(); group.TX != "group" || group.Names.Name != "CAN1" {
+		t.Fatalf("wrong channel group comment %+v", group)
+	}
+}
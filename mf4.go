@@ -4,40 +4,61 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/LincolnG4/GoMDF/blocks"
 	"github.com/LincolnG4/GoMDF/blocks/AT"
 	"github.com/LincolnG4/GoMDF/blocks/CG"
 	"github.com/LincolnG4/GoMDF/blocks/CN"
-	"github.com/LincolnG4/GoMDF/blocks/DL"
 	"github.com/LincolnG4/GoMDF/blocks/EV"
 	"github.com/LincolnG4/GoMDF/blocks/FH"
 	"github.com/LincolnG4/GoMDF/blocks/HD"
 	"github.com/LincolnG4/GoMDF/blocks/ID"
-	"github.com/LincolnG4/GoMDF/blocks/MD"
 	"github.com/LincolnG4/GoMDF/blocks/SI"
 	"github.com/LincolnG4/GoMDF/blocks/TX"
 	"github.com/davecgh/go-spew/spew"
 )
 
+// MF4 is a parsed ASAM MDF 4 file.
+//
+// Concurrency: after ReadFile returns, an MF4 and its channels can be used
+// from several goroutines. Blocks are read through readers with their own
+// offset using positional reads (ReadAt), so no seek cursor is shared. Every
+// sample read creates its own reader state, and the caches of channels and
+// data groups are guarded by mutexes. ReadChannels reads several channels in
+// parallel.
 type MF4 struct {
 	File           *os.File
 	Header         *HD.Block
 	Identification *ID.Block
 
 	//Address to First File History Block
+	//
+	// Deprecated: use FileHistoryEntries to read the file history.
 	FileHistory int64
 
 	DataGroups   []DataGroup
 	ChannelGroup []ChannelGroup
 	Channels     []Channel
 
-	//Unsorted
-	UnsortedBlocks []*UnsortedBlock
-
 	ReadOptions *ReadOptions
+
+	//tracks cached samples when CachePolicy is CacheLRU
+	lru *lruCache
+
+	//temporary files used to sort unsorted data groups
+	tempMu    sync.Mutex
+	tempFiles []*os.File
+
+	//source information by SIBLOCK address, read once for all the channel
+	//groups and channels sharing a source
+	sourceMu sync.Mutex
+	sources  map[int64]SI.SourceInfo
 }
 
 type ReadOptions struct {
@@ -53,23 +74,45 @@ type ReadOptions struct {
 	// memory
 	MemoryOptimized bool
 
+	// CachePolicy defines which samples are kept in memory after a channel
+	// is read: CachePhysical (default), CacheNone, CacheRaw or CacheLRU.
+	// If MemoryOptimized is set and CachePolicy is left as default, samples
+	// are not cached.
+	CachePolicy CachePolicy
+
+	// CacheLimitBytes is the maximum estimated memory used by the samples and
+	// the data blocks in cache with CacheLRU.
+	CacheLimitBytes int64
+
+	// SortTempDir is the directory of the temporary files used to sort the
+	// records of unsorted data groups. If empty, records are sorted in
+	// memory. Temporary files are removed by MF4.Close.
+	SortTempDir string
+
+	// LazyMetadata indicates whether to defer reading the metadata of the
+	// channels until it is used.
+	// Default is false, conversions, source information and comments of all
+	// channels are read when the file is opened and set in the Conversion,
+	// SourceInfo and Comment fields.
+	//
+	// If true, only the data group and channel group chains and the channel
+	// names and layouts are read, and the Conversion, SourceInfo and Comment
+	// fields are left empty. Conversions, source information and comments
+	// are read on first access through GetConversion, GetSourceInfo and
+	// GetComment. This approach opens files with a large number of channels
+	// much faster, and avoids reading metadata that might never be used.
+	LazyMetadata bool
+
 	// InitAllChannels indicates whether to read all channels during
-	// initialization.
-	// If true, all predefined channels will be created and ready for use
-	// immediately after initialization. This can be useful when you need all
-	// channels available from the start and want to avoid delays caused by
-	// creating channels later on.
+	// initialization. It overrides LazyMetadata.
 	//
-	// If false, channels are created on-demand as they are needed. This
-	// approach can be more memory-efficient if you have a large number of
-	// channels or don't need all of them immediately. It also avoids
-	// preallocating resources that might never be used
-	//InitAllChannels bool
-}
+	// Deprecated: metadata is read on open unless LazyMetadata is set.
+	InitAllChannels bool
 
-type UnsortedBlock struct {
-	dataGroup         *DataGroup
-	channelGroupsByID map[uint64]*ChannelGroup
+	// AttachmentSearchPath are the file systems where external attachments
+	// are looked for, after the directory of the MF4 file. External
+	// attachments are found by their relative path, then by their file name.
+	AttachmentSearchPath []fs.FS
 }
 
 func ReadFile(file *os.File, readOptions *ReadOptions) (*MF4, error) {
@@ -79,6 +122,9 @@ func ReadFile(file *os.File, readOptions *ReadOptions) (*MF4, error) {
 		Identification: ID.New(file, address),
 		ReadOptions:    readOptions,
 	}
+	if mf4File.cachePolicy() == CacheLRU {
+		mf4File.lru = newLRUCache(readOptions.CacheLimitBytes)
+	}
 	fileVersion := mf4File.MdfVersion()
 	if fileVersion < 400 {
 		return nil, fmt.Errorf("file version is not >= 4.00")
@@ -87,279 +133,221 @@ func ReadFile(file *os.File, readOptions *ReadOptions) (*MF4, error) {
 	if fileVersion >= 400 {
 		mf4File.loadHeader()
 		mf4File.loadFirstFileHistory()
-		mf4File.read()
+		if err := mf4File.read(); err != nil {
+			return nil, err
+		}
 	}
 	return &mf4File, nil
 }
 
-func (m *MF4) read() {
-	var file *os.File = m.File
-	var comment string
+func (m *MF4) read() error {
+	var file io.ReadSeeker = m.reader()
 
 	if !m.IsFinalized() {
-		panic("MF4 NOT FINALIZED, PACKAGE IS NOT PREPARED")
+		return fmt.Errorf("file is not finalized")
 	}
 
 	version := m.MdfVersion()
 	nextDataGroupAddress := m.firstDataGroup()
 	m.Channels = make([]Channel, 0)
+	groups := make([]*ChannelGroup, 0)
+	groupsByAddress := make(map[int64]*ChannelGroup)
+	channels := make(map[*ChannelGroup][]*Channel)
 
 	dgindex := 0
 	for nextDataGroupAddress != 0 {
 		var dataGroup DataGroup
-		var UnsortedBlocks UnsortedBlock
-		isUnsorted := false
 
 		dataGroup = NewDataGroup(file, nextDataGroupAddress)
-		m.DataGroups = append(m.DataGroups, dataGroup)
-
-		comment = MD.New(file, dataGroup.block.MetadataComment())
 
 		nextAddressCG := dataGroup.block.FirstChannelGroup()
 		cgIndex := 0
 		for nextAddressCG != 0 {
-			var masterChannel Channel
-
 			cgBlock, err := CG.New(file, version, nextAddressCG)
 			if err != nil {
-				panic(err)
+				return fmt.Errorf("channel group at %d: %w", nextAddressCG, err)
 			}
 
 			channelGroup := &ChannelGroup{
-				Block:      cgBlock,
-				Channels:   make(map[string]*Channel),
-				DataGroup:  dataGroup.block,
-				SourceInfo: SI.Get(file, version, cgBlock.Link.SiAcqSource),
-				Comment:    comment,
+				Block:     cgBlock,
+				Channels:  make(map[string]*Channel),
+				DataGroup: dataGroup.block,
+				master:    &Channel{},
+				meta:      &groupMeta{commentAddress: dataGroup.block.MetadataComment()},
+				address:   nextAddressCG,
+				mf4:       m,
+			}
+			if !m.lazyMetadata() {
+				channelGroup.SourceInfo = channelGroup.GetSourceInfo()
+				channelGroup.Comment = channelGroup.GetComment()
 			}
 
 			dataGroup.ChannelGroup = append(dataGroup.ChannelGroup, channelGroup)
+			groups = append(groups, channelGroup)
+			groupsByAddress[nextAddressCG] = channelGroup
 
 			nextAddressCN := cgBlock.FirstChannel()
 			for nextAddressCN != 0 {
 				cnBlock, err := CN.New(file, version, nextAddressCN)
 				if err != nil {
-					panic(err)
-				}
-
-				cc, err := cnBlock.Conversion(m.File, cnBlock.DataType())
-				if err != nil {
-					panic(err)
+					return fmt.Errorf("channel at %d: %w", nextAddressCN, err)
 				}
 
 				cn := &Channel{
-					Name:              cnBlock.ChannelName(m.File),
+					Name:              cnBlock.ChannelName(file),
 					ChannelGroup:      cgBlock,
 					ChannelGroupIndex: cgIndex,
 					DataGroup:         &dataGroup,
 					DataGroupIndex:    dgindex,
 					Type:              cnBlock.Type(),
-					Master:            &masterChannel,
-					SourceInfo:        SI.Get(file, version, cnBlock.Link.SiSource),
-					Comment:           MD.New(file, cnBlock.CommentMd()),
-					Conversion:        cc,
+					Master:            channelGroup.master,
 					block:             cnBlock,
-					isUnsorted:        false,
+					meta:              &channelMeta{},
+					group:             channelGroup,
+					cache:             newSampleCache(),
+					address:           nextAddressCN,
 					mf4:               m,
 				}
 
-				// save master channel address
-				if cnBlock.IsMaster() {
-					masterChannel = *cn
-					cn.Master = nil
+				if !m.lazyMetadata() {
+					cn.Conversion, err = cn.GetConversion()
+					if err != nil {
+						return fmt.Errorf("channel %s: %w", cn.Name, err)
+					}
+					cn.SourceInfo = cn.GetSourceInfo()
+					cn.Comment = cn.GetComment()
 				}
 
-				// Unsorted file
-				if dataGroup.block.Data.RecIDSize != 0 {
-					cn.CachedSamples = make([]interface{}, 0)
-					isUnsorted = true
-					if UnsortedBlocks.dataGroup == nil {
-						UnsortedBlocks = newUnsortedGroup(dataGroup)
-					}
+				cn.components, err = m.readComposition(file, cn, map[int64]bool{nextAddressCN: true})
+				if err != nil {
+					return err
+				}
 
-					UnsortedBlocks.channelGroupsByID[cgBlock.Data.RecordId] = channelGroup
-					if cnBlock.Link.Data != 0 {
-						vsldMap := make(map[string]*Channel)
-						vsldMap["vlsd"] = cn
-						cn.isUnsorted = true
-						VLSDBlock, err := CG.New(file, version, cnBlock.Link.Data)
-						if err != nil {
-							panic(err)
-						}
-
-						VLSD := &ChannelGroup{
-							Block:      VLSDBlock,
-							Channels:   vsldMap,
-							DataGroup:  dataGroup.block,
-							SourceInfo: SI.Get(file, version, VLSDBlock.Link.SiAcqSource),
-							Comment:    comment,
-						}
-
-						UnsortedBlocks.channelGroupsByID[VLSDBlock.Data.RecordId] = VLSD
-					}
+				if cnBlock.IsMaster() {
+					cn.Master = nil
+					channelGroup.master = cn
 				}
 
 				channelGroup.Channels[cn.Name] = cn
-				m.Channels = append(m.Channels, *cn)
+				channels[channelGroup] = append(channels[channelGroup], cn)
 				nextAddressCN = cnBlock.Next()
 			}
-			m.ChannelGroup = append(m.ChannelGroup, *channelGroup)
+			channelGroup.setMaster(channelGroup.master, channels[channelGroup])
 			nextAddressCG = cgBlock.Next()
 		}
 
-		if isUnsorted {
-			m.UnsortedBlocks = append(m.UnsortedBlocks, &UnsortedBlocks)
-			m.Sort(UnsortedBlocks)
-		}
-
+		m.DataGroups = append(m.DataGroups, dataGroup)
 		nextDataGroupAddress = dataGroup.block.Next()
 		dgindex++
 	}
-}
-
-// Sort is applied for unsorted files.
-func (m *MF4) Sort(us UnsortedBlock) error {
-	headerID, err := blocks.GetHeaderID(m.File, us.dataGroup.block.Link.Data)
-	if err != nil {
-		return err
-	}
 
-	var (
-		addr             int64 = us.dataGroup.block.Link.Data
-		dtl              *DL.Block
-		offsetDT, target uint64
-	)
+	m.linkRemoteMasters(groups, groupsByAddress, channels)
 
-	isDataList := false
-	k := 0
-	if headerID == blocks.DlID {
-		dtl, err = DL.New(m.File, m.MdfVersion(), addr)
-		if err != nil {
-			return err
+	// channel groups and channels are copied once their masters are linked
+	for _, cg := range groups {
+		m.ChannelGroup = append(m.ChannelGroup, *cg)
+		for _, cn := range channels[cg] {
+			m.Channels = append(m.Channels, *cn)
 		}
-
-		addr = dtl.Link.Data[k]
-		offsetDT = dtl.DataSectionLength(k)
-		target = offsetDT
-		isDataList = true
-		k++
 	}
+	return nil
+}
 
-	dtsize, err := blocks.GetLength(m.File, addr)
-	if err != nil {
-		return err
+// readComposition reads the members of the structure `parent`, from the list
+// of CNBLOCKs referenced by cn_composition, and their own members. Members
+// share the record of the channel group; arrays (CABLOCK) are not read.
+func (m *MF4) readComposition(file io.ReadSeeker, parent *Channel, visited map[int64]bool) ([]*Channel, error) {
+	addr := parent.block.Link.Composition
+	if addr == 0 {
+		return nil, nil
 	}
-
-	pos := 0
-	buf := make([]byte, dtsize)
-	_, err = io.ReadFull(m.File, buf)
-	if err != nil {
-		return err
+	if id, err := blocks.GetHeaderID(file, addr); err != nil || id != blocks.CnID {
+		return nil, err
 	}
 
-	var (
-		byteSize            int
-		rowSize             int
-		bsize, size, offset uint32
-		i, dataBlockSize    uint64
-	)
-
-	for uint64(pos) < dtsize {
-		if i == target && isDataList {
-			// Next list
-			if k == len(dtl.Link.Data) && dtl.Next() != 0 {
-				dtl, err := DL.New(m.File, m.MdfVersion(), addr)
-				if err != nil {
-					return err
-				}
-				addr = dtl.Link.Data[k]
-				k = 0
-			}
-
-			//Next Data
-			offsetDT = dtl.DataSectionLength(k)
-			target += offsetDT
-
-			if _, err = m.File.Seek(addr, io.SeekStart); err != nil {
-				return err
-			}
-
-			dtsize, err = blocks.GetLength(m.File, addr)
-			if err != nil {
-				return err
-			}
-
-			if dtsize != dataBlockSize {
-				buf = make([]byte, dtsize)
-				dataBlockSize = dtsize
-			}
-
-			_, err = io.ReadFull(m.File, buf)
-			if err != nil {
-				return err
-			}
-			pos = 0
-			k += 1
+	members := make([]*Channel, 0)
+	for addr != 0 {
+		if visited[addr] {
+			return nil, fmt.Errorf("loop in composition of channel %s at address %d", parent.Name, addr)
 		}
+		visited[addr] = true
 
-		byteSize = int(us.dataGroup.block.RecordIDSize())
-		id, err := bytesOfRecordIDSize(byteSize, buf[pos:pos+byteSize])
+		cnBlock, err := CN.New(file, m.MdfVersion(), addr)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
-		pos += byteSize
-
-		cg, ok := us.channelGroupsByID[id]
-		if !ok {
-			panic(fmt.Sprintf("id %d not found", id))
+		cn := &Channel{
+			Name:              cnBlock.ChannelName(file),
+			ChannelGroup:      parent.ChannelGroup,
+			ChannelGroupIndex: parent.ChannelGroupIndex,
+			DataGroup:         parent.DataGroup,
+			DataGroupIndex:    parent.DataGroupIndex,
+			Type:              cnBlock.Type(),
+			Master:            parent.Master,
+			block:             cnBlock,
+			meta:              &channelMeta{},
+			group:             parent.group,
+			cache:             newSampleCache(),
+			address:           addr,
+			mf4:               m,
+		}
+		cn.components, err = m.readComposition(file, cn, visited)
+		if err != nil {
+			return nil, err
 		}
 
-		if cg.Block.IsVLSD() {
-			var sampleLength uint32
-
-			sampleLength = binary.LittleEndian.Uint32(buf[pos : pos+4])
-			pos += 4
-
-			cn := cg.Channels["vlsd"]
-			size = cn.block.SignalBytesRange()
-			value, err := parseSignalMeasure(buf[pos:pos+int(sampleLength)], cn.block.ByteOrder(), cn.block.LoadDataType(int(size)))
-			if err != nil {
-				return err
-			}
-			pos += int(sampleLength)
+		members = append(members, cn)
+		addr = cnBlock.Next()
+	}
+	return members, nil
+}
+
+// linkRemoteMasters sets the master of channel groups flagged with remote
+// master (MDF 4.2) to the master channel of the group referenced by
+// cg_cg_master, possibly located in another data group. Groups are linked in
+// the order of the file, following chains of remote masters to the group
+// holding the master channel.
+func (m *MF4) linkRemoteMasters(groups []*ChannelGroup, groupsByAddress map[int64]*ChannelGroup, channels map[*ChannelGroup][]*Channel) {
+	for _, cg := range groups {
+		if cg.Block.RemoteMaster() == 0 {
+			continue
+		}
 
-			cn.CachedSamples = append(cn.CachedSamples, value)
-		} else {
-			rowSize = int(cg.Block.Data.DataBytes)
+		master := remoteMaster(cg, groupsByAddress, map[*ChannelGroup]bool{})
+		if master != cg.master && master.block != nil {
+			cg.setMaster(master, channels[cg])
+		}
+	}
+}
 
-			for _, cn := range cg.Channels {
-				if cn.isUnsorted {
-					continue
-				}
+// remoteMaster returns the master channel of the group, or of the group
+// referenced by its remote master link, recursively. Loops stop at the first
+// group visited twice.
+func remoteMaster(cg *ChannelGroup, groupsByAddress map[int64]*ChannelGroup, visited map[*ChannelGroup]bool) *Channel {
+	visited[cg] = true
+	remote, ok := groupsByAddress[cg.Block.RemoteMaster()]
+	if !ok || visited[remote] {
+		return cg.master
+	}
+	return remoteMaster(remote, groupsByAddress, visited)
+}
 
-				offset = cn.block.Data.ByteOffset
-				bsize = offset + cn.block.Data.BitCount/8
-				size = cn.block.SignalBytesRange()
+// setMaster sets the master channel of the group, of its channels and of their
+// members. The master channel itself keeps a 'nil' master.
+func (cg *ChannelGroup) setMaster(master *Channel, channels []*Channel) {
+	cg.master = master
 
-				value, err := parseSignalMeasure(buf[pos+int(offset):pos+int(bsize)], cn.block.ByteOrder(), cn.block.LoadDataType(int(size)))
-				if err != nil {
-					panic(err)
-				}
-				cn.CachedSamples = append(cn.CachedSamples, value)
+	var link func(channels []*Channel)
+	link = func(channels []*Channel) {
+		for _, cn := range channels {
+			if cn.Master != nil {
+				cn.Master = master
 			}
-			pos += rowSize
+			link(cn.components)
 		}
 	}
-	return nil
-}
-
-func newUnsortedGroup(dataGroup DataGroup) UnsortedBlock {
-	unsortedMap := make(map[uint64]*ChannelGroup, 0)
-	return UnsortedBlock{
-		dataGroup:         &dataGroup,
-		channelGroupsByID: unsortedMap,
-	}
+	link(channels)
 }
 
 // GetChannelSample loads sample based DataGroupName and ChannelName
@@ -379,6 +367,65 @@ func (m *MF4) GetChannelSample(indexDataGroup int, channelName string) ([]interf
 	return cn.Sample()
 }
 
+// ReadChannels reads the samples of the given channels, decoding data groups
+// concurrently. Channels of the same data group are read by the same goroutine
+// so they share the decompressed data blocks. If a name exists in several
+// channel groups, the first one in file order is read.
+func (m *MF4) ReadChannels(names ...string) (map[string][]interface{}, error) {
+	byDataGroup := make(map[int][]*Channel)
+	for _, name := range names {
+		cn, err := m.findChannel(name)
+		if err != nil {
+			return nil, err
+		}
+		byDataGroup[cn.DataGroupIndex] = append(byDataGroup[cn.DataGroupIndex], cn)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	result := make(map[string][]interface{}, len(names))
+	for _, channels := range byDataGroup {
+		wg.Add(1)
+		go func(channels []*Channel) {
+			defer wg.Done()
+			for _, cn := range channels {
+				sample, err := cn.Sample()
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("channel %s: %w", cn.Name, err)
+				}
+				result[cn.Name] = sample
+				mu.Unlock()
+
+				if err != nil {
+					return
+				}
+			}
+		}(channels)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
+
+// findChannel returns the first channel named `name` in file order
+func (m *MF4) findChannel(name string) (*Channel, error) {
+	for _, cg := range m.ChannelGroup {
+		if cn, ok := cg.Channels[name]; ok {
+			return cn, nil
+		}
+	}
+	return nil, fmt.Errorf("channel %s doens't exist", name)
+}
+
 // ListAllChannelsNames returns an slice with all channels from the MF4 file
 func (m *MF4) ListAllChannels() []Channel {
 	return m.Channels
@@ -441,14 +488,15 @@ func (m *MF4) ListEvents() []*EV.Event {
 		return nil
 	}
 
+	file := m.reader()
 	r := make([]*EV.Event, 0)
 	nextEvent := m.getFirstEvent()
 	for nextEvent != 0 {
-		event, err := EV.New(m.File, m.MdfVersion(), nextEvent)
+		event, err := EV.New(file, m.MdfVersion(), nextEvent)
 		if err != nil {
 			return nil
 		}
-		r = append(r, event.Load(m.File))
+		r = append(r, event.Load(file))
 		nextEvent = event.Next()
 	}
 	return r
@@ -460,12 +508,54 @@ func readArrayBlock(file *os.File, addr int64) {
 
 // GetAttachmemts iterates over all AT blocks and return to an array
 func (m *MF4) GetAttachments() ([]AT.AttFile, error) {
-	return AT.Get(m.File, m.getFirstAttachment())
+	attachments, err := AT.Get(m.reader(), m.getFirstAttachment())
+	for i := range attachments {
+		attachments[i].SearchPath = m.attachmentSearchPath()
+	}
+	return attachments, err
 }
 
-// Saves attachment file input to output path
-func (m *MF4) SaveAttachmentTo(attachment AT.AttFile, outputPath string) AT.AttFile {
-	return attachment.Save(m.File, outputPath)
+// loadAttachment reads the attachment at `addr`
+func (m *MF4) loadAttachment(addr int64) (*AT.AttFile, error) {
+	file := m.reader()
+	at, err := AT.New(file, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	a := at.LoadAttachmentFile(file)
+	a.SearchPath = m.attachmentSearchPath()
+	return a, nil
+}
+
+// loadAttachments reads the attachments at `addrs`
+func (m *MF4) loadAttachments(addrs []int64) ([]AT.AttFile, error) {
+	r := make([]AT.AttFile, 0, len(addrs))
+	for _, addr := range addrs {
+		a, err := m.loadAttachment(addr)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, *a)
+	}
+	return r, nil
+}
+
+// attachmentSearchPath returns the file systems where external attachments
+// are looked for: the directory of the MF4 file, then
+// ReadOptions.AttachmentSearchPath
+func (m *MF4) attachmentSearchPath() []fs.FS {
+	r := []fs.FS{os.DirFS(filepath.Dir(m.File.Name()))}
+	if m.ReadOptions != nil {
+		r = append(r, m.ReadOptions.AttachmentSearchPath...)
+	}
+	return r
+}
+
+// SaveAttachmentTo saves the attachment in the directory `outputPath`, see
+// AT.AttFile.Save
+func (m *MF4) SaveAttachmentTo(attachment AT.AttFile, outputPath string) (AT.AttFile, error) {
+	return attachment.Save(m.reader(), outputPath)
 }
 
 // GetAttachmemts iterates over all AT blocks and return to an array
@@ -498,6 +588,13 @@ func (m *MF4) IsFinalized() bool {
 	return m.Identification.UnfinalizedFlag == 0
 }
 
+// reader returns a reader over the MF4 file with its own offset. It reads
+// with ReadAt, so readers can be used concurrently without sharing the seek
+// cursor of the file.
+func (m *MF4) reader() *io.SectionReader {
+	return io.NewSectionReader(m.File, 0, math.MaxInt64)
+}
+
 func (m *MF4) firstDataGroup() int64 {
 	return m.Header.Link.DgFirst
 }
@@ -517,7 +614,7 @@ func (m *MF4) getFirstEvent() int64 {
 // Start angle in radians at the beginning of the measurement serves as the
 // reference point for angle synchronous measurements.
 func (m *MF4) StartAngleRad() (float64, error) {
-	if !m.isDistanceValid() {
+	if !m.isAngleValid() {
 		return 0, fmt.Errorf("start angle rad is not valid for this file")
 	}
 	return m.getStartAngleRad(), nil
@@ -545,7 +642,7 @@ func bytesOfRecordIDSize(size int, buf []byte) (uint64, error) {
 // Start distance in meters in meters at the beginning of the measurement serves
 // as the reference point for distance synchronous measurements.
 func (m *MF4) StartDistanceM() (float64, error) {
-	if m.isDistanceValid() {
+	if !m.isDistanceValid() {
 		return 0, fmt.Errorf("start distance meters is not valid for this file")
 	}
 	return m.getStartDistanceM(), nil
@@ -556,7 +653,7 @@ func (m *MF4) GetMeasureComment() string {
 		return ""
 	}
 
-	t, err := TX.GetText(m.File, m.getHeaderMdComment())
+	t, err := TX.GetText(m.reader(), m.getHeaderMdComment())
 	if err != nil {
 		return ""
 	}
@@ -573,14 +670,17 @@ func (m *MF4) GetMeasureComment() string {
 // Parameters:
 //
 //	m: A pointer to the MF4 instance containing the file change log.
+//
+// Deprecated: use FileHistoryEntries, which returns structured entries.
 func (m *MF4) ReadChangeLog() []string {
+	file := m.reader()
 	r := make([]string, 0)
 	nextAddressFH := m.getFileHistory()
 	for nextAddressFH != 0 {
-		fhBlock, _ := FH.New(m.File, nextAddressFH)
+		fhBlock, _ := FH.New(file, nextAddressFH)
 
-		c := fhBlock.GetChangeLog(m.File)
-		t := fhBlock.GetTimeNs()
+		c := fhBlock.GetChangeLog(file)
+		t := fhBlock.Time()
 		f := fhBlock.GetTimeFlag()
 
 		r = append(r, m.formatLog(t, f, c))
@@ -589,26 +689,23 @@ func (m *MF4) ReadChangeLog() []string {
 	return r
 }
 
-// StartTimeNs returns the start timestamp of measurement in nanoseconds
+// GetStartTimeNs returns the start timestamp of measurement in nanoseconds
+// since 1970-01-01, UTC unless the header stores local time
 func (m *MF4) GetStartTimeNs() int64 {
-	t := m.getStartTimeNs()
-	tzo := uint64(m.getHDTimezoneOffsetMin())
-	dlo := uint64(m.getDaylightOffsetMin())
-	tf := m.getTimeFlag()
-	return m.GetTimeNs(t, tzo, dlo, tf)
+	return int64(m.getStartTimeNs())
 }
 
+// GetStartTimeLT returns the start time of the measurement, see StartTime
 func (m *MF4) GetStartTimeLT() time.Time {
-	return m.formatTimeLT(m.GetStartTimeNs())
+	return m.StartTime()
 }
 
 func (m *MF4) getFileHistory() int64 {
 	return m.FileHistory
 }
 
-func (m *MF4) formatLog(t int64, f uint8, c string) string {
-	ts := m.formatTimeLT(t)
-	return fmt.Sprint(ts, f, c)
+func (m *MF4) formatLog(t time.Time, f uint8, c string) string {
+	return fmt.Sprint(t, f, c)
 }
 
 func (m *MF4) getHDTimezoneOffsetMin() int16 {
@@ -631,8 +728,14 @@ func (m *MF4) getStartDistanceM() float64 {
 	return m.Header.Data.StartDistM
 }
 
+// isAngleValid checks the "start angle valid" flag (bit 0)
+func (m *MF4) isAngleValid() bool {
+	return blocks.IsBitSet(int(m.Header.Data.Flags), 0)
+}
+
+// isDistanceValid checks the "start distance valid" flag (bit 1)
 func (m *MF4) isDistanceValid() bool {
-	return m.Header.Data.Flags == 1
+	return blocks.IsBitSet(int(m.Header.Data.Flags), 1)
 }
 
 func (m *MF4) getTimeClass() uint8 {
@@ -641,7 +744,7 @@ func (m *MF4) getTimeClass() uint8 {
 
 func (m *MF4) loadHeader() {
 	var err error
-	m.Header, err = HD.New(m.File, blocks.IdblockSize)
+	m.Header, err = HD.New(m.reader(), blocks.IdblockSize)
 	if err != nil {
 		panic(err)
 	}
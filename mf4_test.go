@@ -180,3 +180,24 @@ func compareSlices(s1, s2 interface{}) (bool, string) {
 
 	return true, ""
 }
+
+func TestReadCorruptFiles(t *testing.T) {
+	unfinalized := newFixture(410)
+	// id_unfin_flags
+	unfinalized.buf[60] = 1
+
+	// first channel pointing to a TXBLOCK
+	badChannel := newFixture(410)
+	cg := badChannel.block("##CG", []int64{0, badChannel.text("speed"), 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 1}))
+	badChannel.link(hdAddress, 0, badChannel.dataGroup(cg, []byte{1}))
+
+	// first channel group pointing to a TXBLOCK
+	badGroup := newFixture(410)
+	badGroup.link(hdAddress, 0, badGroup.dataGroup(badGroup.text("group"), nil))
+
+	for name, f := range map[string]*fixture{"unfinalized": unfinalized, "channel": badChannel, "channel group": badGroup} {
+		if _, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{}); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
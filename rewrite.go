@@ -0,0 +1,276 @@
+package mf4
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+)
+
+// rewriter writes a modified MF4 file, either a copy or the file itself. The
+// blocks of the original file are kept as they are, links are patched in place
+// and new blocks are appended at the end of the file, so unchanged blocks keep
+// their addresses.
+type rewriter struct {
+	file *os.File
+	path string
+
+	//end of the file, where the next block is appended
+	size int64
+
+	//the original file is written. abort restores its size and the original
+	//value of the patched links.
+	inPlace  bool
+	original int64
+	links    map[int64][8]byte
+}
+
+// newRewriter copies the file of `m` to `path`
+func newRewriter(m *MF4, path string) (*rewriter, error) {
+	if info, err := os.Stat(path); err == nil {
+		if src, err := m.File.Stat(); err == nil && os.SameFile(info, src) {
+			return nil, fmt.Errorf("can't overwrite the file being read: %s", path)
+		}
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := io.Copy(out, io.NewSectionReader(m.File, 0, 1<<62))
+	if err != nil {
+		out.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return &rewriter{file: out, path: path, size: n}, nil
+}
+
+// openRewriter opens the file of `m` to write it in place
+func openRewriter(m *MF4) (*rewriter, error) {
+	path := m.File.Name()
+	out, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	return &rewriter{file: out, path: path, size: n, inPlace: true, original: n, links: make(map[int64][8]byte)}, nil
+}
+
+// setLink sets the link `index` of the block at `addr` to `value`
+func (w *rewriter) setLink(addr int64, index int, value int64) error {
+	offset := addr + int64(blocks.HeaderSize) + int64(index)*8
+	if _, ok := w.links[offset]; w.inPlace && !ok {
+		var old [8]byte
+		if _, err := w.file.ReadAt(old[:], offset); err != nil {
+			return err
+		}
+		w.links[offset] = old
+	}
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(value))
+	_, err := w.file.WriteAt(buf[:], offset)
+	return err
+}
+
+// appendBlock appends a block with `links` and `data` at the end of the file
+// and returns its address
+func (w *rewriter) appendBlock(id string, links []int64, data []byte) (int64, error) {
+	return w.appendBlockFrom(id, links, int64(len(data)), bytes.NewReader(data))
+}
+
+// appendBlockFrom appends a block whose data section, of `length` bytes, is
+// read from `r`. Blocks are aligned to 8 bytes.
+func (w *rewriter) appendBlockFrom(id string, links []int64, length int64, r io.Reader) (int64, error) {
+	addr := (w.size + 7) &^ 7
+	if _, err := w.file.Seek(w.size, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w.file)
+	bw.Write(make([]byte, addr-w.size))
+
+	header := blocks.Header{
+		ID:        blocks.SplitIdToArray(id),
+		Length:    uint64(blocks.HeaderSize) + uint64(len(links))*8 + uint64(length),
+		LinkCount: uint64(len(links)),
+	}
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, links); err != nil {
+		return 0, err
+	}
+	if _, err := io.CopyN(bw, r, length); err != nil {
+		return 0, err
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	w.size = addr + int64(header.Length)
+	return addr, nil
+}
+
+// encodeBlockData returns the data section `v` of a block
+func encodeBlockData(v any) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, v)
+	return b.Bytes()
+}
+
+// close closes the written file
+func (w *rewriter) close() error {
+	return w.file.Close()
+}
+
+// abort closes and removes the written copy. A file written in place gets
+// back its original links and size.
+func (w *rewriter) abort() {
+	if !w.inPlace {
+		w.file.Close()
+		os.Remove(w.path)
+		return
+	}
+
+	for offset, old := range w.links {
+		w.file.WriteAt(old[:], offset)
+	}
+	w.file.Truncate(w.original)
+	w.file.Close()
+}
+
+// Compact writes to `path` a copy of the file made only of the blocks
+// reachable from the header, for instance after attachments or comments were
+// replaced by Editor. Blocks keep their order and links are updated to their
+// new addresses.
+func (m *MF4) Compact(path string) error {
+	if info, err := os.Stat(path); err == nil {
+		if src, err := m.File.Stat(); err == nil && os.SameFile(info, src) {
+			return fmt.Errorf("can't overwrite the file being read: %s", path)
+		}
+	}
+	return compact(m.File, path)
+}
+
+// compactFile compacts the file at `path` in place. The file is left as it is
+// if compaction fails.
+func compactFile(path string) error {
+	tmp := path + ".uncompacted"
+	if err := os.Rename(path, tmp); err != nil {
+		return err
+	}
+
+	src, err := os.Open(tmp)
+	if err == nil {
+		err = compact(src, path)
+		src.Close()
+	}
+	if err != nil {
+		os.Rename(tmp, path)
+		return err
+	}
+	return os.Remove(tmp)
+}
+
+// compactBlock is a block reachable from the header
+type compactBlock struct {
+	header blocks.Header
+	links  []int64
+}
+
+// compact copies the identification block and the blocks of `src` reachable
+// from the header to `path`
+func compact(src io.ReaderAt, path string) error {
+	reachable := make(map[int64]*compactBlock)
+	pending := []int64{blocks.IdblockSize}
+	for len(pending) > 0 {
+		addr := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if addr == 0 || reachable[addr] != nil {
+			continue
+		}
+
+		b := &compactBlock{}
+		r := io.NewSectionReader(src, addr, 1<<62)
+		if err := binary.Read(r, binary.LittleEndian, &b.header); err != nil {
+			return fmt.Errorf("block at %d: %w", addr, err)
+		}
+		b.links = make([]int64, b.header.LinkCount)
+		if err := binary.Read(r, binary.LittleEndian, b.links); err != nil {
+			return fmt.Errorf("block at %d: %w", addr, err)
+		}
+		reachable[addr] = b
+		pending = append(pending, b.links...)
+	}
+
+	addresses := make([]int64, 0, len(reachable))
+	for addr := range reachable {
+		addresses = append(addresses, addr)
+	}
+	slices.Sort(addresses)
+
+	// new addresses, in the same order, aligned to 8 bytes
+	moved := make(map[int64]int64, len(addresses))
+	next := blocks.IdblockSize
+	for _, addr := range addresses {
+		moved[addr] = next
+		next = (next + int64(reachable[addr].header.Length) + 7) &^ 7
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	written := int64(0)
+	err = func() error {
+		if _, err := io.Copy(w, io.NewSectionReader(src, 0, blocks.IdblockSize)); err != nil {
+			return err
+		}
+		written = blocks.IdblockSize
+
+		for _, addr := range addresses {
+			b := reachable[addr]
+			w.Write(make([]byte, moved[addr]-written))
+
+			links := make([]int64, len(b.links))
+			for i, l := range b.links {
+				links[i] = moved[l]
+			}
+			if err := binary.Write(w, binary.LittleEndian, b.header); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.LittleEndian, links); err != nil {
+				return err
+			}
+
+			offset := int64(blocks.HeaderSize) + int64(len(links))*8
+			length := int64(b.header.Length) - offset
+			if _, err := io.Copy(w, io.NewSectionReader(src, addr+offset, length)); err != nil {
+				return err
+			}
+			written = moved[addr] + int64(b.header.Length)
+		}
+		return w.Flush()
+	}()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
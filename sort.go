@@ -0,0 +1,416 @@
+package mf4
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/CG"
+)
+
+// sortChunkRecords is the number of records read at once from sorted records
+const sortChunkRecords = 1 << 14
+
+// recordStore keeps the records of one channel group of an unsorted data
+// group, without record ids, in memory or in a temporary file
+type recordStore interface {
+	io.Writer
+	io.ReaderAt
+
+	//Size returns the number of bytes written
+	Size() int64
+
+	//Flush writes buffered records, before reading
+	Flush() error
+}
+
+type memoryStore struct {
+	data []byte
+}
+
+func (s *memoryStore) Write(p []byte) (int, error) {
+	s.data = append(s.data, p...)
+	return len(p), nil
+}
+
+func (s *memoryStore) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(s.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, s.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (s *memoryStore) Size() int64 {
+	return int64(len(s.data))
+}
+
+func (s *memoryStore) Flush() error {
+	return nil
+}
+
+type fileStore struct {
+	file *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+func (s *fileStore) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.size += int64(n)
+	return n, err
+}
+
+func (s *fileStore) ReadAt(p []byte, off int64) (int, error) {
+	return s.file.ReadAt(p, off)
+}
+
+func (s *fileStore) Size() int64 {
+	return s.size
+}
+
+func (s *fileStore) Flush() error {
+	return s.w.Flush()
+}
+
+// sortState sorts the records of an unsorted data group once. It is shared by
+// all copies of the data group.
+type sortState struct {
+	once   sync.Once
+	stores map[uint64]recordStore
+	err    error
+}
+
+// newRecordStore returns an in memory store, or a temporary file in
+// ReadOptions.SortTempDir if set
+func (m *MF4) newRecordStore() (recordStore, error) {
+	if m.ReadOptions == nil || m.ReadOptions.SortTempDir == "" {
+		return &memoryStore{}, nil
+	}
+
+	file, err := os.CreateTemp(m.ReadOptions.SortTempDir, "mf4-sort-*")
+	if err != nil {
+		return nil, err
+	}
+
+	m.tempMu.Lock()
+	m.tempFiles = append(m.tempFiles, file)
+	m.tempMu.Unlock()
+	return &fileStore{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// Close removes the temporary files used to sort unsorted data groups. The
+// MF4 file itself is not closed.
+func (m *MF4) Close() error {
+	m.tempMu.Lock()
+	defer m.tempMu.Unlock()
+
+	var firstErr error
+	for _, file := range m.tempFiles {
+		file.Close()
+		if err := os.Remove(file.Name()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	m.tempFiles = nil
+	return firstErr
+}
+
+// Sort sorts the records of all unsorted data groups. Unsorted data groups
+// are otherwise sorted when one of their channels is first read.
+func (m *MF4) Sort() error {
+	for i := range m.DataGroups {
+		if m.DataGroups[i].block.IsSorted() {
+			continue
+		}
+
+		if _, err := m.DataGroups[i].sortedRecords(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedRecords returns the records of the data group by record id, sorting
+// them on first use
+func (d *DataGroup) sortedRecords(m *MF4) (map[uint64]recordStore, error) {
+	d.sorted.once.Do(func() {
+		d.sorted.stores, d.sorted.err = d.sortRecords(m)
+	})
+	return d.sorted.stores, d.sorted.err
+}
+
+// sortRecords reads the data of the data group once, block by block, and
+// writes each record to the store of its channel group. Fixed length records
+// are stored without record id; VLSD records keep their length prefix, the
+// layout of an SDBLOCK.
+func (d *DataGroup) sortRecords(m *MF4) (map[uint64]recordStore, error) {
+	idx, err := d.blockIndex(m)
+	if err != nil {
+		return nil, err
+	}
+
+	idSize := int(d.block.RecordIDSize())
+	sizes := make(map[uint64]int)
+	stores := make(map[uint64]recordStore)
+	for _, cg := range d.ChannelGroup {
+		id := cg.Block.Data.RecordId
+		stores[id], err = m.newRecordStore()
+		if err != nil {
+			return nil, err
+		}
+
+		// VLSD records have variable length
+		sizes[id] = -1
+		if !cg.Block.IsVLSD() {
+			sizes[id] = int(cg.Block.Data.DataBytes) + int(cg.Block.Data.InvalBytes)
+		}
+	}
+
+	// records can span two data blocks, so the incomplete record at the end
+	// of a block is kept until the next one is read
+	var pending []byte
+	err = idx.scan(m, d, 0, idx.end(), func(data []byte) error {
+		if len(pending) > 0 {
+			data = append(pending, data...)
+		}
+
+		pos := 0
+		for pos+idSize <= len(data) {
+			id, err := bytesOfRecordIDSize(idSize, data[pos:pos+idSize])
+			if err != nil {
+				return err
+			}
+
+			store, ok := stores[id]
+			if !ok {
+				return fmt.Errorf("record id %d not found in data group", id)
+			}
+
+			start := pos + idSize
+			end := start + sizes[id]
+			if sizes[id] < 0 {
+				if start+4 > len(data) {
+					break
+				}
+				end = start + 4 + int(binary.LittleEndian.Uint32(data[start:start+4]))
+			}
+			if end > len(data) {
+				break
+			}
+
+			if _, err := store.Write(data[start:end]); err != nil {
+				return err
+			}
+			pos = end
+		}
+		pending = append(pending[:0:0], data[pos:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) != 0 {
+		return nil, fmt.Errorf("incomplete record at the end of data group")
+	}
+
+	for _, store := range stores {
+		if err := store.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	return stores, nil
+}
+
+// readUnsorted returns the raw samples of a channel of an unsorted data group
+// between the records `first` and `end`, read from the sorted records of its
+// channel group
+func (c *Channel) readUnsorted(first, end uint64) ([]interface{}, error) {
+	stores, err := c.DataGroup.sortedRecords(c.mf4)
+	if err != nil {
+		return nil, err
+	}
+
+	size := c.block.SignalBytesRange()
+	byteOrder := c.block.ByteOrder()
+	dataType := c.block.LoadDataType(int(size))
+
+	if c.block.IsVLSD() {
+		vlsd, err := CG.New(c.mf4.reader(), c.mf4.MdfVersion(), c.block.Link.Data)
+		if err != nil {
+			return nil, err
+		}
+		return readSignalData(stores[vlsd.Data.RecordId], first, end, byteOrder, dataType)
+	}
+
+	store := stores[c.ChannelGroup.Data.RecordId]
+	recordSize := uint64(c.ChannelGroup.Data.DataBytes) + uint64(c.ChannelGroup.Data.InvalBytes)
+	layout := c.valueLayout()
+	if store == nil || recordSize == 0 {
+		return []interface{}{}, nil
+	}
+	if layout.offset+layout.size > recordSize {
+		return nil, fmt.Errorf("channel %s exceeds the record size", c.Name)
+	}
+
+	end = min(end, uint64(store.Size())/recordSize)
+	measure := make([]interface{}, 0, end-min(first, end))
+	buf := make([]byte, min(sortChunkRecords, end-min(first, end))*recordSize)
+	for r := first; r < end; {
+		n := min(sortChunkRecords, end-r)
+		chunk := buf[:n*recordSize]
+		if _, err := store.ReadAt(chunk, int64(r*recordSize)); err != nil {
+			return nil, err
+		}
+
+		for pos := uint64(0); pos+recordSize <= uint64(len(chunk)); pos += recordSize {
+			value, err := layout.decode(chunk[pos : pos+recordSize])
+			if err != nil {
+				return nil, err
+			}
+			measure = append(measure, value)
+		}
+		r += n
+	}
+	return measure, nil
+}
+
+// readSignalData returns the values `first` to `end` of signal data stored
+// as length-prefixed values
+func readSignalData(store recordStore, first, end uint64, byteOrder binary.ByteOrder, dataType interface{}) ([]interface{}, error) {
+	measure := make([]interface{}, 0)
+	if store == nil {
+		return measure, nil
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(store, 0, store.Size()))
+	var length [4]byte
+	for i := uint64(0); i < end; i++ {
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		n := int(binary.LittleEndian.Uint32(length[:]))
+		if i < first {
+			if _, err := r.Discard(n); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+
+		value, err := parseSignalMeasure(data, byteOrder, dataType)
+		if err != nil {
+			return nil, err
+		}
+		measure = append(measure, value)
+	}
+	return measure, nil
+}
+
+// SaveSorted writes a copy of the file to `path` where every unsorted data
+// group is replaced by one sorted data group per channel group. VLSD channel
+// groups are converted to SDBLOCKs referenced by their channel. Sorted data
+// groups and all other blocks are copied as they are.
+func (m *MF4) SaveSorted(path string) error {
+	w, err := newRewriter(m, path)
+	if err != nil {
+		return err
+	}
+
+	if err := m.writeSorted(w); err != nil {
+		w.abort()
+		return err
+	}
+	return w.close()
+}
+
+func (m *MF4) writeSorted(w *rewriter) error {
+	// block linking to the next data group, starting with hd_dg_first. The
+	// link to the next block is the first link of both HD and DG blocks.
+	prev := blocks.IdblockSize
+
+	for i := range m.DataGroups {
+		dg := &m.DataGroups[i]
+		if dg.block.IsSorted() {
+			if err := w.setLink(prev, 0, dg.address); err != nil {
+				return err
+			}
+			prev = dg.address
+			continue
+		}
+
+		stores, err := dg.sortedRecords(m)
+		if err != nil {
+			return err
+		}
+
+		// VLSD records already have the SDBLOCK layout
+		signalData := make(map[int64]int64)
+		for _, cg := range dg.ChannelGroup {
+			if !cg.Block.IsVLSD() {
+				continue
+			}
+
+			store := stores[cg.Block.Data.RecordId]
+			sd, err := w.appendBlockFrom(blocks.SdID, nil, store.Size(), io.NewSectionReader(store, 0, store.Size()))
+			if err != nil {
+				return err
+			}
+			signalData[cg.address] = sd
+		}
+
+		for _, cg := range dg.ChannelGroup {
+			if cg.Block.IsVLSD() {
+				continue
+			}
+
+			store := stores[cg.Block.Data.RecordId]
+			dt, err := w.appendBlockFrom(blocks.DtID, nil, store.Size(), io.NewSectionReader(store, 0, store.Size()))
+			if err != nil {
+				return err
+			}
+
+			sorted, err := w.appendBlock(blocks.DgID, []int64{0, cg.address, dt, dg.block.MetadataComment()}, make([]byte, 8))
+			if err != nil {
+				return err
+			}
+
+			// each channel group is alone in its data group
+			if err := w.setLink(cg.address, 0, 0); err != nil {
+				return err
+			}
+
+			for _, cn := range cg.Channels {
+				sd, ok := signalData[cn.block.Link.Data]
+				if !cn.block.IsVLSD() || !ok {
+					continue
+				}
+				if err := w.setLink(cn.address, 5, sd); err != nil {
+					return err
+				}
+			}
+
+			if err := w.setLink(prev, 0, sorted); err != nil {
+				return err
+			}
+			prev = sorted
+		}
+	}
+	return w.setLink(prev, 0, 0)
+}
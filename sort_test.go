@@ -0,0 +1,176 @@
+package mf4_test
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// unsortedFixture has an unsorted data group with record ids of `idSize`
+// bytes and three channel groups: "speed" (id 1), "text" with a VLSD channel
+// (id 2) and its VLSD channel group (id 3). The records are split in a DZ, a
+// DT and a DZ block, referenced by a DL behind a HL, so records span blocks.
+func unsortedFixture(t *testing.T, idSize int, opts *mf4.ReadOptions) *mf4.MF4 {
+	f := newFixture(410)
+
+	t1 := f.channel("t1", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	speed := f.channel("speed", cnData{ByteOffset: 8, BitCount: 16})
+	cgSpeed := f.block("##CG", []int64{0, f.chain(0, t1, speed), 0, 0, 0, 0}, encode(cgData{RecordId: 1, CycleCount: 4, DataBytes: 10}))
+
+	cgVLSD := f.block("##CG", []int64{0, 0, 0, 0, 0, 0}, encode(cgData{RecordId: 3, CycleCount: 3, Flags: 1}))
+	t2 := f.channel("t2", cnData{Type: 2, SyncType: 1, DataType: 4, BitCount: 64})
+	text := f.channel("text", cnData{Type: 1, DataType: 7, ByteOffset: 8, BitCount: 64})
+	f.link(text, 5, cgVLSD)
+	cgText := f.block("##CG", []int64{0, f.chain(0, t2, text), 0, 0, 0, 0}, encode(cgData{RecordId: 2, CycleCount: 3, DataBytes: 16}))
+
+	id := func(v uint64) []byte {
+		b := binary.LittleEndian.AppendUint64(nil, v)
+		return b[:idSize]
+	}
+	speedRecord := func(time float64, v uint16) []byte {
+		r := append(id(1), float64Records([]float64{time})...)
+		return binary.LittleEndian.AppendUint16(r, v)
+	}
+	textRecord := func(time float64, offset uint64) []byte {
+		r := append(id(2), float64Records([]float64{time})...)
+		return binary.LittleEndian.AppendUint64(r, offset)
+	}
+	vlsdRecord := func(s string) []byte {
+		r := binary.LittleEndian.AppendUint32(id(3), uint32(len(s)))
+		return append(r, s...)
+	}
+
+	var data []byte
+	for _, r := range [][]byte{
+		speedRecord(0, 10), vlsdRecord("hello"), textRecord(0, 0),
+		speedRecord(0.1, 20), vlsdRecord("mf4"), speedRecord(0.2, 30),
+		textRecord(0.1, 9), vlsdRecord(""), speedRecord(0.3, 40), textRecord(0.2, 16),
+	} {
+		data = append(data, r...)
+	}
+
+	split := []int{0, len(data) / 3, 2 * len(data) / 3, len(data)}
+	dl := f.dataList([]uint64{0, uint64(split[1]), uint64(split[2])},
+		f.zipped(data[split[0]:split[1]]),
+		f.block("##DT", nil, data[split[1]:split[2]]),
+		f.zipped(data[split[2]:split[3]]))
+
+	dgData := make([]byte, 8)
+	dgData[0] = uint8(idSize)
+	dg := f.block("##DG", []int64{0, f.chain(0, cgSpeed, cgText, cgVLSD), f.headerList(dl), 0}, dgData)
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func checkUnsortedSamples(t *testing.T, m *mf4.MF4) {
+	t.Helper()
+
+	channels, err := m.ReadChannels("speed", "t1", "text", "t2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	speed := []uint16{10, 20, 30, 40}
+	for i, v := range speed {
+		if channels["speed"][i] != v || channels["t1"][i] != float64(i)/10 {
+			t.Fatalf("record %d: wrong speed samples %v %v", i, channels["t1"], channels["speed"])
+		}
+	}
+
+	text := []string{"hello", "mf4", ""}
+	if len(channels["text"]) != len(text) || len(channels["t2"]) != len(text) {
+		t.Fatalf("wrong text samples %v %v", channels["t2"], channels["text"])
+	}
+	for i, v := range text {
+		if channels["text"][i] != v {
+			t.Fatalf("record %d: expected %q, got %v", i, v, channels["text"][i])
+		}
+	}
+}
+
+func TestUnsortedRecordIDSizes(t *testing.T) {
+	for _, idSize := range []int{1, 2, 4, 8} {
+		m := unsortedFixture(t, idSize, &mf4.ReadOptions{})
+		checkUnsortedSamples(t, m)
+
+		cn := m.ChannelGroup[0].Channels["speed"]
+		sample, err := cn.SampleRange(1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sample) != 2 || sample[0] != uint16(20) || sample[1] != uint16(30) {
+			t.Fatalf("id size %d: wrong range %v", idSize, sample)
+		}
+	}
+}
+
+func TestUnsortedTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	m := unsortedFixture(t, 1, &mf4.ReadOptions{SortTempDir: dir, CachePolicy: mf4.CacheNone})
+
+	if err := m.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Fatalf("expected a temporary file per channel group, got %d", len(entries))
+	}
+
+	checkUnsortedSamples(t, m)
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("temporary files not removed: %v", entries)
+	}
+}
+
+func TestSaveSorted(t *testing.T) {
+	m := unsortedFixture(t, 2, &mf4.ReadOptions{})
+
+	path := filepath.Join(t.TempDir(), "sorted.mf4")
+	if err := m.SaveSorted(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SaveSorted(m.File.Name()); err == nil {
+		t.Fatal("expected error when overwriting the file being read")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	sorted, err := mf4.ReadFile(file, &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sorted.DataGroups) != 2 {
+		t.Fatalf("expected one data group per channel group, got %d", len(sorted.DataGroups))
+	}
+	for i := range sorted.DataGroups {
+		idx, err := sorted.BlockIndex(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idx.RecordSize == 0 || math.Mod(float64(idx.Blocks[0].Length), float64(idx.RecordSize)) != 0 {
+			t.Fatalf("data group %d not sorted: %+v", i, idx)
+		}
+	}
+
+	checkUnsortedSamples(t, sorted)
+}
@@ -0,0 +1,107 @@
+package mf4
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/LincolnG4/GoMDF/blocks/SI"
+)
+
+// Source is a source of the file, with the channel groups acquired from it
+// and the channels it is the source of. Sources with the same information,
+// for instance written once per channel group by a bus logger, are merged.
+type Source struct {
+	SI.SourceInfo
+
+	ChannelGroups []*ChannelGroup
+	Channels      []*Channel
+}
+
+// sourceInfo returns the source information of the SIBLOCK at `addr`, read
+// once
+func (m *MF4) sourceInfo(addr int64) SI.SourceInfo {
+	m.sourceMu.Lock()
+	defer m.sourceMu.Unlock()
+
+	if si, ok := m.sources[addr]; ok {
+		return si
+	}
+	if m.sources == nil {
+		m.sources = make(map[int64]SI.SourceInfo)
+	}
+
+	si := SI.Get(m.reader(), m.MdfVersion(), addr)
+	m.sources[addr] = si
+	return si
+}
+
+// Sources returns the sources of the channel groups and channels of the file,
+// in the order of their first use
+func (m *MF4) Sources() []*Source {
+	r := make([]*Source, 0)
+	byInfo := make(map[SI.SourceInfo]*Source)
+	source := func(info SI.SourceInfo) *Source {
+		s, ok := byInfo[info]
+		if !ok {
+			s = &Source{SourceInfo: info}
+			byInfo[info] = s
+			r = append(r, s)
+		}
+		return s
+	}
+
+	for i := range m.ChannelGroup {
+		cg := &m.ChannelGroup[i]
+		if cg.Block.Link.SiAcqSource != 0 {
+			s := source(cg.GetSourceInfo())
+			s.ChannelGroups = append(s.ChannelGroups, cg)
+		}
+
+		for _, cn := range sortedChannels(cg) {
+			if cn.block.Link.SiSource != 0 {
+				s := source(cn.GetSourceInfo())
+				s.Channels = append(s.Channels, cn)
+			}
+		}
+	}
+	return r
+}
+
+// ChannelsBySource returns the channels whose source, or the acquisition
+// source of their channel group, is on a bus of type `bus` and named `name`,
+// for instance the channels logged from "CAN1". An empty `name` matches all
+// sources on the bus type.
+func (m *MF4) ChannelsBySource(bus SI.BusType, name string) []*Channel {
+	match := func(addr int64) bool {
+		if addr == 0 {
+			return false
+		}
+		si := m.sourceInfo(addr)
+		return si.Bus == bus && (name == "" || si.Name == name)
+	}
+
+	r := make([]*Channel, 0)
+	for i := range m.ChannelGroup {
+		cg := &m.ChannelGroup[i]
+		group := match(cg.Block.Link.SiAcqSource)
+		for _, cn := range sortedChannels(cg) {
+			if match(cn.block.Link.SiSource) || (group && cn.block.Link.SiSource == 0) {
+				r = append(r, cn)
+			}
+		}
+	}
+	return r
+}
+
+// sortedChannels returns the channels of the channel group in the order of
+// their blocks in the file
+func sortedChannels(cg *ChannelGroup) []*Channel {
+	r := make([]*Channel, 0, len(cg.Channels))
+	for _, cn := range cg.Channels {
+		r = append(r, cn)
+	}
+	slices.SortFunc(r, func(a, b *Channel) int {
+		return cmp.Compare(a.address, b.address)
+	})
+	return r
+}
@@ -0,0 +1,114 @@
+package mf4_test
+
+import (
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+	"github.com/LincolnG4/GoMDF/blocks/SI"
+)
+
+type siData struct {
+	Type     uint8
+	BusType  uint8
+	Flags    uint8
+	Reserved [5]byte
+}
+
+// source appends an SIBLOCK
+func (f *fixture) source(name, path, comment string, d siData) int64 {
+	links := []int64{f.text(name), 0, 0}
+	if path != "" {
+		links[1] = f.text(path)
+	}
+	if comment != "" {
+		links[2] = f.metadata(comment)
+	}
+	return f.block("##SI", links, encode(d))
+}
+
+// sourcesFixture has two channel groups logged from CAN1, each with its own
+// SIBLOCK, and a simulated LIN channel group with an ECU channel
+func sourcesFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	can := `<SIcomment><TX>powertrain</TX><bus>CAN1</bus><protocol>J1939</protocol><channel>1</channel></SIcomment>`
+	groups := make([]int64, 3)
+	for i, name := range []string{"engine", "gearbox"} {
+		cn := f.channel(name, cnData{DataType: 4, BitCount: 64})
+		cg := f.block("##CG", []int64{0, cn, 0, f.source("CAN1", "Vector", can, siData{Type: 2, BusType: 2}), 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 8}))
+		groups[i] = f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records([]float64{1})), 0}, make([]byte, 8))
+	}
+
+	lin := f.channel("window", cnData{DataType: 4, BitCount: 64})
+	ecu := f.channel("door", cnData{DataType: 4, ByteOffset: 8, BitCount: 64})
+	f.link(ecu, 3, f.source("BCM", "", "", siData{Type: 1, BusType: 3}))
+	cg := f.block("##CG", []int64{0, f.chain(0, lin, ecu), 0, f.source("LIN 2", "", "", siData{Type: 2, BusType: 3, Flags: 1}), 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 16}))
+	groups[2] = f.block("##DG", []int64{0, cg, f.block("##DT", nil, float64Records([]float64{1, 2})), 0}, make([]byte, 8))
+	f.link(hdAddress, 0, f.chain(0, groups...))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSourceInfo(t *testing.T) {
+	m := sourcesFixture(t)
+
+	can := m.ChannelGroup[0].GetSourceInfo()
+	if can.SourceType != SI.BusSource || can.Bus != SI.CAN || can.Bus.String() != "CAN" || can.Simulated {
+		t.Fatalf("wrong CAN source %+v", can)
+	}
+	meta := can.Meta()
+	if meta.TX != "powertrain" || meta.Bus.String() != "CAN1" || meta.Protocol.String() != "J1939" {
+		t.Fatalf("wrong CAN source comment %+v", meta)
+	}
+	if n, ok := can.BusChannel(); !ok || n != 1 {
+		t.Fatalf("expected bus channel 1, got %d %v", n, ok)
+	}
+
+	lin := m.ChannelGroup[2].GetSourceInfo()
+	if lin.Bus != SI.LIN || !lin.Simulated || lin.Meta().Bus != nil {
+		t.Fatalf("wrong LIN source %+v", lin)
+	}
+	if n, ok := lin.BusChannel(); !ok || n != 2 {
+		t.Fatalf("expected bus channel 2 from the name, got %d %v", n, ok)
+	}
+	if _, ok := m.ChannelGroup[2].Channels["door"].GetSourceInfo().BusChannel(); ok {
+		t.Fatal("expected no bus channel for an ECU")
+	}
+}
+
+func TestSources(t *testing.T) {
+	m := sourcesFixture(t)
+
+	sources := m.Sources()
+	if len(sources) != 3 {
+		t.Fatalf("expected 3 sources, got %d", len(sources))
+	}
+	if sources[0].Name != "CAN1" || len(sources[0].ChannelGroups) != 2 || len(sources[0].Channels) != 0 {
+		t.Fatalf("CAN source not merged %+v", sources[0])
+	}
+	if sources[1].Name != "LIN 2" || sources[2].Name != "BCM" || sources[2].SourceType != SI.ECUSource || len(sources[2].Channels) != 1 {
+		t.Fatalf("wrong sources %+v %+v", sources[1], sources[2])
+	}
+
+	names := func(channels []*mf4.Channel) []string {
+		r := make([]string, len(channels))
+		for i, cn := range channels {
+			r[i] = cn.Name
+		}
+		return r
+	}
+	if r := names(m.ChannelsBySource(SI.CAN, "CAN1")); len(r) != 2 || r[0] != "engine" || r[1] != "gearbox" {
+		t.Fatalf("wrong CAN1 channels %v", r)
+	}
+	// the ECU channel has its own source, on LIN
+	if r := names(m.ChannelsBySource(SI.LIN, "")); len(r) != 2 || r[0] != "window" || r[1] != "door" {
+		t.Fatalf("wrong LIN channels %v", r)
+	}
+	if r := m.ChannelsBySource(SI.CAN, "CAN2"); len(r) != 0 {
+		t.Fatalf("expected no CAN2 channels, got %v", names(r))
+	}
+}
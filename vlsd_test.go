@@ -0,0 +1,106 @@
+package mf4_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	mf4 "github.com/LincolnG4/GoMDF"
+)
+
+// signalData returns the data section of a SDBLOCK holding the values
+func signalData(values ...string) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+		b = append(b, v...)
+	}
+	return b
+}
+
+// vlsdFixture has three VLSD string channels whose values are stored in a
+// SDBLOCK, in a DZBLOCK and in a HLBLOCK listing two SDBLOCKs
+func vlsdFixture(t *testing.T) *mf4.MF4 {
+	f := newFixture(410)
+
+	sd := f.block("##SD", nil, signalData("one", "two", "three"))
+
+	data := signalData("four", "five", "six")
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+	var d bytes.Buffer
+	d.WriteString("SD")
+	d.Write([]byte{0, 0})
+	binary.Write(&d, binary.LittleEndian, uint32(0))
+	binary.Write(&d, binary.LittleEndian, uint64(len(data)))
+	binary.Write(&d, binary.LittleEndian, uint64(z.Len()))
+	d.Write(z.Bytes())
+	dz := f.block("##DZ", nil, d.Bytes())
+
+	first := f.block("##SD", nil, signalData("seven", "eight"))
+	second := f.block("##SD", nil, signalData("nine"))
+	var l bytes.Buffer
+	l.Write([]byte{0, 0, 0, 0})
+	binary.Write(&l, binary.LittleEndian, uint32(2))
+	binary.Write(&l, binary.LittleEndian, []uint64{0, 0})
+	dl := f.block("##DL", []int64{0, first, second}, l.Bytes())
+	hl := f.block("##HL", []int64{dl}, make([]byte, 8))
+
+	plain := f.channel("plain", cnData{Type: 1, DataType: 7, BitCount: 64})
+	f.link(plain, 5, sd)
+	zipped := f.channel("zipped", cnData{Type: 1, DataType: 7, ByteOffset: 8, BitCount: 64})
+	f.link(zipped, 5, dz)
+	listed := f.channel("listed", cnData{Type: 1, DataType: 7, ByteOffset: 16, BitCount: 64})
+	f.link(listed, 5, hl)
+
+	cg := f.block("##CG", []int64{0, f.chain(0, plain, zipped, listed), 0, 0, 0, 0}, encode(cgData{CycleCount: 3, DataBytes: 24}))
+	dt := f.block("##DT", nil, make([]byte, 3*24))
+	dg := f.block("##DG", []int64{0, cg, dt, 0}, make([]byte, 8))
+	f.link(hdAddress, 0, dg)
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestVLSDSignalData(t *testing.T) {
+	m := vlsdFixture(t)
+
+	for name, expected := range map[string]string{
+		"plain":  "[one two three]",
+		"zipped": "[four five six]",
+		"listed": "[seven eight nine]",
+	} {
+		sample, err := m.GetChannelSample(0, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if fmt.Sprint(sample) != expected {
+			t.Fatalf("%s: expected %s, got %v", name, expected, sample)
+		}
+	}
+}
+
+func TestVLSDUnsupportedBlock(t *testing.T) {
+	f := newFixture(410)
+	// signal data in a DTBLOCK
+	text := f.channel("text", cnData{Type: 1, DataType: 7, BitCount: 64})
+	f.link(text, 5, f.block("##DT", nil, signalData("one")))
+	cg := f.block("##CG", []int64{0, text, 0, 0, 0, 0}, encode(cgData{CycleCount: 1, DataBytes: 8}))
+	f.link(hdAddress, 0, f.dataGroup(cg, make([]byte, 8)))
+
+	m, err := mf4.ReadFile(f.open(t), &mf4.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetChannelSample(0, "text"); err == nil || !strings.Contains(err.Error(), "unsupported block") {
+		t.Fatalf("expected an unsupported block error, got %v", err)
+	}
+}
@@ -0,0 +1,333 @@
+package mf4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/LincolnG4/GoMDF/blocks"
+	"github.com/LincolnG4/GoMDF/blocks/CG"
+	"github.com/LincolnG4/GoMDF/blocks/CN"
+	"github.com/LincolnG4/GoMDF/blocks/HD"
+	"github.com/LincolnG4/GoMDF/blocks/ID"
+	"github.com/LincolnG4/GoMDF/blocks/SI"
+)
+
+// Writer writes a new MF4 file made of channel groups of floating point
+// channels sampled at shared timestamps, for instance signals decoded from
+// bus frames, or of records laid out by the caller, for instance bus events
+type Writer struct {
+	//start of the measurement, written in the header. The time of Close is
+	//used if it's zero.
+	StartTime time.Time
+
+	//user name written in the file history
+	UserName string
+
+	w *rewriter
+
+	//last data group, linked to the next one
+	lastGroup int64
+}
+
+// WriterChannel is a channel written by Writer
+type WriterChannel struct {
+	Name    string
+	Unit    string
+	Comment string
+
+	//one value per timestamp of the channel group
+	Values []float64
+
+	//texts of values, written as a value to text conversion
+	ValueTexts map[float64]string
+}
+
+// NewWriter creates the MF4 file at `path`
+func NewWriter(path string) (*Writer, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	id := ID.Block{VersionNumber: blocks.Version410}
+	copy(id.File[:], "MDF     ")
+	copy(id.Version[:], "4.10    ")
+	copy(id.Program[:], fmt.Sprintf("%-8.8s", ToolID))
+	w := &Writer{w: &rewriter{file: out, path: path}}
+	if _, err := out.Write(encodeBlockData(id)); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	w.w.size = blocks.IdblockSize
+
+	// the header is written first at its fixed address, its links and start
+	// time are set by AddGroup and Close
+	if _, err := w.w.appendBlock(blocks.HdID, make([]int64, 6), encodeBlockData(HD.Data{})); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+// RecordChannel is a channel of the records written by Writer.AddRecords
+type RecordChannel struct {
+	Name    string
+	Unit    string
+	Comment string
+
+	//texts of values, written as a value to text conversion
+	ValueTexts map[float64]string
+
+	//type, data type and position of the channel in the records
+	Data CN.Data
+}
+
+// RecordGroup is a channel group written by Writer.AddRecords, with its
+// records as they are stored
+type RecordGroup struct {
+	Name string
+
+	//cg_flags, for instance CG.BusEventFlag
+	Flags uint16
+
+	//acquisition source, or 'nil'
+	Source *SI.SourceInfo
+
+	Channels []RecordChannel
+
+	//size of a record in bytes and records, one after the other
+	RecordSize uint32
+	Records    []byte
+}
+
+// AddGroup writes a channel group `name` whose records hold the time master
+// channel, with the `timestamps` in seconds, and `channels`
+func (w *Writer) AddGroup(name string, timestamps []float64, channels ...WriterChannel) error {
+	for _, c := range channels {
+		if len(c.Values) != len(timestamps) {
+			return fmt.Errorf("channel %s: expected %d values, got %d", c.Name, len(timestamps), len(c.Values))
+		}
+	}
+
+	g := RecordGroup{
+		Name:       name,
+		Channels:   make([]RecordChannel, 0, len(channels)+1),
+		RecordSize: uint32(8 * (len(channels) + 1)),
+	}
+	g.Channels = append(g.Channels, RecordChannel{
+		Name: "time",
+		Unit: "s",
+		Data: CN.Data{Type: CN.Master, SyncType: CN.TimeSync, DataType: CN.IEEE754FloatLE, BitCount: 64},
+	})
+	for i, c := range channels {
+		g.Channels = append(g.Channels, RecordChannel{
+			Name:       c.Name,
+			Unit:       c.Unit,
+			Comment:    c.Comment,
+			ValueTexts: c.ValueTexts,
+			Data:       CN.Data{DataType: CN.IEEE754FloatLE, ByteOffset: uint32(8 * (i + 1)), BitCount: 64},
+		})
+	}
+
+	// records of the master and channel values, in little endian
+	g.Records = make([]byte, 0, int(g.RecordSize)*len(timestamps))
+	for i, t := range timestamps {
+		g.Records = binary.LittleEndian.AppendUint64(g.Records, math.Float64bits(t))
+		for _, c := range channels {
+			g.Records = binary.LittleEndian.AppendUint64(g.Records, math.Float64bits(c.Values[i]))
+		}
+	}
+	return w.AddRecords(g)
+}
+
+// AddRecords writes the channel group `g` in its own data group
+func (w *Writer) AddRecords(g RecordGroup) error {
+	if g.RecordSize == 0 || len(g.Records)%int(g.RecordSize) != 0 {
+		return fmt.Errorf("channel group %s: %d bytes aren't records of %d bytes", g.Name, len(g.Records), g.RecordSize)
+	}
+
+	var first, last int64
+	for _, c := range g.Channels {
+		cn, err := w.appendChannel(c)
+		if err != nil {
+			return err
+		}
+		// cn_cn_next
+		if last == 0 {
+			first = cn
+		} else if err := w.w.setLink(last, 0, cn); err != nil {
+			return err
+		}
+		last = cn
+	}
+
+	dt, err := w.w.appendBlock(blocks.DtID, nil, g.Records)
+	if err != nil {
+		return err
+	}
+	acqName, err := w.appendText(g.Name)
+	if err != nil {
+		return err
+	}
+	var source int64
+	if g.Source != nil {
+		if source, err = w.appendSource(*g.Source); err != nil {
+			return err
+		}
+	}
+	cg, err := w.w.appendBlock(blocks.CgID, []int64{0, first, acqName, source, 0, 0}, encodeBlockData(CG.Data{
+		CycleCount:    uint64(len(g.Records) / int(g.RecordSize)),
+		Flags:         g.Flags,
+		PathSeparator: '.',
+		DataBytes:     g.RecordSize,
+	}))
+	if err != nil {
+		return err
+	}
+	dg, err := w.w.appendBlock(blocks.DgID, []int64{0, cg, dt, 0}, make([]byte, 8))
+	if err != nil {
+		return err
+	}
+
+	// dg_dg_next, or hd_dg_first
+	if w.lastGroup == 0 {
+		err = w.w.setLink(blocks.IdblockSize, 0, dg)
+	} else {
+		err = w.w.setLink(w.lastGroup, 0, dg)
+	}
+	w.lastGroup = dg
+	return err
+}
+
+// appendSource appends an SIBLOCK with the name, path, comment, type and bus
+// of `s`
+func (w *Writer) appendSource(s SI.SourceInfo) (int64, error) {
+	links := make([]int64, 3)
+	for i, text := range []string{s.Name, s.Path, s.Comment} {
+		if text == "" {
+			continue
+		}
+		addr, err := w.appendText(text)
+		if err != nil {
+			return 0, err
+		}
+		links[i] = addr
+	}
+
+	var flags uint8
+	if s.Simulated {
+		flags = 1
+	}
+	return w.w.appendBlock(blocks.SiID, links, []byte{uint8(s.SourceType), uint8(s.Bus), flags, 0, 0, 0, 0, 0})
+}
+
+// appendChannel appends the CNBLOCK of `c`, with its name, unit, comment and
+// value to text conversion
+func (w *Writer) appendChannel(c RecordChannel) (int64, error) {
+	links := make([]int64, 8)
+	texts := []struct {
+		index int
+		text  string
+	}{{2, c.Name}, {6, c.Unit}, {7, c.Comment}}
+	for _, t := range texts {
+		if t.text == "" {
+			continue
+		}
+		addr, err := w.appendText(t.text)
+		if err != nil {
+			return 0, err
+		}
+		links[t.index] = addr
+	}
+
+	if len(c.ValueTexts) > 0 {
+		cc, err := w.appendValueTexts(c.ValueTexts)
+		if err != nil {
+			return 0, err
+		}
+		links[4] = cc
+	}
+	return w.w.appendBlock(blocks.CnID, links, encodeBlockData(c.Data))
+}
+
+// appendValueTexts appends a value to text CCBLOCK without default text, so
+// values without text are kept as they are
+func (w *Writer) appendValueTexts(texts map[float64]string) (int64, error) {
+	values := make([]float64, 0, len(texts))
+	for v := range texts {
+		values = append(values, v)
+	}
+	slices.Sort(values)
+
+	// tx_name, md_unit, md_comment, cc_inverse, then the texts and the
+	// default
+	links := make([]int64, 4, 4+len(values)+1)
+	for _, v := range values {
+		tx, err := w.appendText(texts[v])
+		if err != nil {
+			return 0, err
+		}
+		links = append(links, tx)
+	}
+	links = append(links, 0)
+
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, struct {
+		Type        uint8
+		Precision   uint8
+		Flags       uint16
+		RefCount    uint16
+		ValCount    uint16
+		PhyRangeMin float64
+		PhyRangeMax float64
+	}{Type: 7, RefCount: uint16(len(values) + 1), ValCount: uint16(len(values))})
+	binary.Write(&data, binary.LittleEndian, values)
+	return w.w.appendBlock(blocks.CcID, links, data.Bytes())
+}
+
+// appendText appends a TXBLOCK with the null terminated `s`
+func (w *Writer) appendText(s string) (int64, error) {
+	return w.w.appendBlock(blocks.TxID, nil, append([]byte(s), 0))
+}
+
+// Close writes the start time and the file history, and closes the file
+func (w *Writer) Close() error {
+	if err := w.finish(); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.w.close()
+}
+
+func (w *Writer) finish() error {
+	start := w.StartTime
+	if start.IsZero() {
+		start = time.Now()
+	}
+	_, offset := start.Zone()
+	data := encodeBlockData(HD.Data{
+		StartTimeNs: uint64(start.UnixNano()),
+		TZOffsetMin: int16(offset / 60),
+		TimeFlags:   blocks.TimeOffsetsValidFlag,
+	})
+	if _, err := w.w.file.WriteAt(data, blocks.IdblockSize+int64(blocks.HeaderSize)+6*8); err != nil {
+		return err
+	}
+
+	fh, err := w.w.appendFileHistory("Created", w.UserName)
+	if err != nil {
+		return err
+	}
+	// hd_fh_first
+	return w.w.setLink(blocks.IdblockSize, 1, fh)
+}
+
+// Abort closes and removes the written file
+func (w *Writer) Abort() {
+	w.w.abort()
+}